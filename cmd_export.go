@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/domain/repo"
+	"github.com/Dcarbon/projects/internal/migration"
+	"github.com/Dcarbon/projects/internal/rss"
+	"github.com/Dcarbon/projects/internal/service"
+)
+
+// runExport handles `projects export`.
+func runExport(args []string) error {
+	var flags = flag.NewFlagSet("export", flag.ExitOnError)
+	var output = flags.String("o", "-", "output file, - for stdout")
+	var format = flags.String("format", "", "csv, xlsx or jsonl, defaults to the output extension")
+	var filter = domain.RProjectGetList{}
+	flags.IntVar(&filter.Status, "status", 0, "project status")
+	flags.Int64Var(&filter.Type, "type", 0, "project type")
+	flags.Int64Var(&filter.Unit, "unit", 0, "unit range of the project type")
+	flags.StringVar(&filter.CountryId, "country", "", "country id")
+	flags.StringVar(&filter.Owner, "owner", "", "owner id")
+	flags.StringVar(&filter.SearchValue, "search", "", "full-text search")
+	flags.StringVar(&filter.Polygon, "polygon", "", "GeoJSON Polygon or MultiPolygon to export")
+	flags.BoolVar(&filter.IncludeDeleted, "include-deleted", false, "export deleted projects too")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projects export [-format csv|xlsx|jsonl] [-o file] [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); nil != err {
+		return err
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*output), ".")
+	}
+	switch *format {
+	case service.ExportFormatCSV, service.ExportFormatXLSX, service.ExportFormatJSONL:
+	default:
+		return fmt.Errorf("unknown format %q, expect csv, xlsx or jsonl", *format)
+	}
+
+	rss.SetUrl(config.GetDBUrl())
+	migrator, err := migration.NewMigrator(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	if err := migrator.Check(); nil != err {
+		return err
+	}
+	iCountry, err := repo.NewCountryImpl(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	iProject, err := repo.NewProjectImpl(rss.GetDB(), iCountry)
+	if nil != err {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if nil != err {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return service.WriteProjects(w, iProject, filter, *format)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/domain/repo"
+	"github.com/Dcarbon/projects/internal/migration"
+	"github.com/Dcarbon/projects/internal/rss"
+	"github.com/Dcarbon/projects/internal/service"
+)
+
+// runExportGeoJSON handles `projects export-geojson`.
+func runExportGeoJSON(args []string) error {
+	var flags = flag.NewFlagSet("export-geojson", flag.ExitOnError)
+	var output = flags.String("o", "-", "output file, - for stdout")
+	var lang = flags.String("lang", "", "language of the project names, before the fallback chain")
+	var filter = domain.RProjectGetList{}
+	flags.IntVar(&filter.Status, "status", 0, "project status")
+	flags.Int64Var(&filter.Type, "type", 0, "project type")
+	flags.Int64Var(&filter.Unit, "unit", 0, "unit range of the project type")
+	flags.StringVar(&filter.CountryId, "country", "", "country id")
+	flags.StringVar(&filter.Owner, "owner", "", "owner id")
+	flags.StringVar(&filter.SearchValue, "search", "", "full-text search")
+	flags.StringVar(&filter.Polygon, "polygon", "", "GeoJSON Polygon or MultiPolygon to export")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projects export-geojson [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); nil != err {
+		return err
+	}
+
+	rss.SetUrl(config.GetDBUrl())
+	migrator, err := migration.NewMigrator(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	if err := migrator.Check(); nil != err {
+		return err
+	}
+	iCountry, err := repo.NewCountryImpl(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	iProject, err := repo.NewProjectImpl(rss.GetDB(), iCountry)
+	if nil != err {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if nil != err {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	var locales = domain.NewLocaleResolver(domain.ParseFallbackLocales(
+		utils.StringEnv(service.EnvFallbackLocales, ""),
+	))
+	filter.Locales = locales.Negotiate(*lang)
+	return service.WriteGeoJSON(w, iProject, filter)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/domain/repo"
+	"github.com/Dcarbon/projects/internal/migration"
+	"github.com/Dcarbon/projects/internal/rss"
+	"github.com/Dcarbon/projects/internal/service"
+)
+
+// runImport handles `projects import`.
+func runImport(args []string) error {
+	var flags = flag.NewFlagSet("import", flag.ExitOnError)
+	var format = flags.String("format", "", "csv or json, defaults to the file extension")
+	var allOrNothing = flags.Bool("all-or-nothing", false, "create no project when a row fails")
+	var dryRun = flags.Bool("dry-run", false, "only validate the rows")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projects import [-format csv|json] [-all-or-nothing] [-dry-run] file")
+		fmt.Fprintln(flags.Output(), "A file of - reads stdin and requires -format.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); nil != err {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expect one file")
+	}
+
+	var input io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if nil != err {
+			return err
+		}
+		defer file.Close()
+		input = file
+		if *format == "" {
+			*format = strings.TrimPrefix(filepath.Ext(name), ".")
+		}
+	}
+	rows, err := service.ParseImport(input, *format)
+	if nil != err {
+		return err
+	}
+
+	rss.SetUrl(config.GetDBUrl())
+	migrator, err := migration.NewMigrator(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	if err := migrator.Check(); nil != err {
+		return err
+	}
+	iCountry, err := repo.NewCountryImpl(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	iProject, err := repo.NewProjectImpl(rss.GetDB(), iCountry)
+	if nil != err {
+		return err
+	}
+
+	report, err := iProject.ImportProjects(&domain.RProjectImport{
+		Rows:         rows,
+		AllOrNothing: *allOrNothing,
+		DryRun:       *dryRun,
+	})
+	if nil != err {
+		return err
+	}
+	for _, it := range report.Rows {
+		switch {
+		case it.Error != "":
+			fmt.Fprintf(os.Stdout, "row %d\tfailed: %s\n", it.Row, it.Error)
+		case *dryRun:
+			fmt.Fprintf(os.Stdout, "row %d\tvalid\n", it.Row)
+		default:
+			fmt.Fprintf(os.Stdout, "row %d\tproject %d\n", it.Row, it.ProjectId)
+		}
+	}
+	if *dryRun {
+		log.Printf("Dry run: %d valid row(s), %d failed", len(report.Rows)-report.Failed, report.Failed)
+		return nil
+	}
+	log.Printf("Imported %d project(s), %d row(s) failed", report.Created, report.Failed)
+	if report.Failed > 0 {
+		return fmt.Errorf("%d row(s) failed", report.Failed)
+	}
+	return nil
+}
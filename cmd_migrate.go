@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Dcarbon/projects/internal/migration"
+	"github.com/Dcarbon/projects/internal/rss"
+)
+
+// runMigrate handles `projects migrate [up|down|status]`.
+func runMigrate(args []string) error {
+	var flags = flag.NewFlagSet("migrate", flag.ExitOnError)
+	var steps = flags.Int("steps", 1, "number of migrations to roll back with down")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projects migrate [-steps n] up|down|status")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); nil != err {
+		return err
+	}
+
+	rss.SetUrl(config.GetDBUrl())
+	migrator, err := migration.NewMigrator(rss.GetDB())
+	if nil != err {
+		return err
+	}
+
+	switch flags.Arg(0) {
+	case "up":
+		applied, err := migrator.Up()
+		for _, it := range applied {
+			log.Printf("Applied %04d_%s", it.Version, it.Name)
+		}
+		if nil != err {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down(*steps)
+		for _, it := range reverted {
+			log.Printf("Reverted %04d_%s", it.Version, it.Name)
+		}
+		return err
+	case "status":
+		applied, err := migrator.Applied()
+		if nil != err {
+			return err
+		}
+		var done = make(map[int64]bool, len(applied))
+		for _, it := range applied {
+			done[it.Version] = true
+		}
+		for _, it := range migrator.Migrations() {
+			var state = "pending"
+			if done[it.Version] {
+				state = "applied"
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%s\t%s\n", it.Version, it.Name, state)
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate action %q", flags.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Dcarbon/go-shared/gutils"
+	"github.com/Dcarbon/go-shared/libs/sclient"
+	"github.com/Dcarbon/projects/internal/domain/repo"
+	"github.com/Dcarbon/projects/internal/migration"
+	"github.com/Dcarbon/projects/internal/rss"
+	"github.com/Dcarbon/projects/internal/service"
+)
+
+// runPurgeDocuments handles `projects purge-documents`.
+func runPurgeDocuments(args []string) error {
+	retention, err := service.PurgeRetention()
+	if nil != err {
+		return err
+	}
+
+	var flags = flag.NewFlagSet("purge-documents", flag.ExitOnError)
+	var dryRun = flags.Bool("dry-run", false, "only report the documents and files to purge")
+	flags.DurationVar(&retention, "retention", retention,
+		"purge documents deleted for longer than this, defaults to "+service.EnvPurgeRetention)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projects purge-documents [-dry-run] [-retention 720h]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); nil != err {
+		return err
+	}
+
+	rss.SetUrl(config.GetDBUrl())
+	migrator, err := migration.NewMigrator(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	if err := migrator.Check(); nil != err {
+		return err
+	}
+	iCountry, err := repo.NewCountryImpl(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	iProject, err := repo.NewProjectImpl(rss.GetDB(), iCountry)
+	if nil != err {
+		return err
+	}
+	gservice, err := gutils.NewGService(&config, "")
+	if nil != err {
+		return err
+	}
+	storage, err := sclient.NewStorage(config.GetStorageHost(), gservice.GetToken())
+	if nil != err {
+		return err
+	}
+
+	report, err := service.NewDocumentPurger(iProject, storage, retention).Purge(time.Now(), *dryRun)
+	if nil != err {
+		return err
+	}
+
+	for _, it := range report.Documents {
+		fmt.Fprintf(os.Stdout, "document %d\tproject %d\t%d version(s)\tdeleted %s\t%q\n",
+			it.Id, it.ProjectId, it.Versions, it.DeletedAt.Format(time.RFC3339), it.Name)
+	}
+	for _, it := range report.Files {
+		if msg, failed := report.Failed[it]; failed {
+			fmt.Fprintf(os.Stdout, "file %s\tfailed: %s\n", it, msg)
+		} else {
+			fmt.Fprintf(os.Stdout, "file %s\n", it)
+		}
+	}
+	if *dryRun {
+		log.Printf("Dry run: %d document(s) deleted before %s and %d file(s) to purge",
+			len(report.Documents), report.Before.Format(time.RFC3339), len(report.Files))
+		return nil
+	}
+	log.Printf("Purged %d document(s) deleted before %s and %d file(s), %d file(s) failed",
+		len(report.Documents), report.Before.Format(time.RFC3339),
+		len(report.Files)-len(report.Failed), len(report.Failed))
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d storage object(s) could not be deleted", len(report.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/domain/repo"
+	"github.com/Dcarbon/projects/internal/migration"
+	"github.com/Dcarbon/projects/internal/rss"
+)
+
+// runRepairCountries handles `projects repair-countries`.
+func runRepairCountries(args []string) error {
+	var flags = flag.NewFlagSet("repair-countries", flag.ExitOnError)
+	var dryRun = flags.Bool("dry-run", false, "only report the country ids to repair")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projects repair-countries [-dry-run]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); nil != err {
+		return err
+	}
+
+	rss.SetUrl(config.GetDBUrl())
+	migrator, err := migration.NewMigrator(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	if err := migrator.Check(); nil != err {
+		return err
+	}
+	iCountry, err := repo.NewCountryImpl(rss.GetDB())
+	if nil != err {
+		return err
+	}
+	iProject, err := repo.NewProjectImpl(rss.GetDB(), iCountry)
+	if nil != err {
+		return err
+	}
+
+	var actor = os.Getenv("USER")
+	if actor == "" {
+		actor = "cli"
+	}
+	report, err := iProject.RepairCountryIds(domain.Audit{
+		Actor:  actor,
+		Action: "repair-countries",
+	}, *dryRun)
+	if nil != err {
+		return err
+	}
+
+	var unresolved = 0
+	for _, it := range report {
+		var target = it.Resolved
+		if target == "" {
+			target = "unresolved"
+			unresolved++
+		}
+		fmt.Fprintf(os.Stdout, "%q\t%d project(s)\t-> %s\n", it.CountryId, it.Projects, target)
+	}
+	switch {
+	case len(report) == 0:
+		log.Println("Every project country id is canonical")
+	case *dryRun:
+		log.Printf("Dry run: %d country id(s) to repair, %d unresolved", len(report)-unresolved, unresolved)
+	default:
+		log.Printf("Repaired %d country id(s), %d unresolved", len(report)-unresolved, unresolved)
+	}
+	return nil
+}
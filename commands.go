@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// commands are the subcommands of the projects binary. Without a
+// subcommand the gRPC server is started.
+var commands = map[string]func(args []string) error{
+	"migrate":          runMigrate,
+	"export-geojson":   runExportGeoJSON,
+	"export":           runExport,
+	"repair-countries": runRepairCountries,
+	"purge-documents":  runPurgeDocuments,
+	"import":           runImport,
+}
+
+func runCommand(name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		var names = make([]string, 0, len(commands))
+		for it := range commands {
+			names = append(names, it)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q, available: %s", name, strings.Join(names, ", "))
+	}
+	return cmd(args)
+}
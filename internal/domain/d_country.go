@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultCountryLocale names a country when neither the registry nor CLDR
+// knows the requested locale.
+const DefaultCountryLocale = "en"
+
+var (
+	ErrCountryNotFound = status.Error(codes.NotFound, "country not found")
+	ErrInvalidLocale   = status.Error(codes.InvalidArgument, "locale must be a BCP 47 language tag")
+	ErrInvalidName     = status.Error(codes.InvalidArgument, "name is required")
+	ErrInvalidCountry  = status.Error(codes.InvalidArgument, "countryId must be an ISO 3166-1 alpha-2 or alpha-3 code")
+)
+
+// ICountry is the country registry. Names come from the locales added by
+// admins first, then from the CLDR data embedded in golang.org/x/text.
+type ICountry interface {
+	// GetCountry names the country in the first of locales with a name,
+	// then in DefaultCountryLocale.
+	GetCountry(id string, locales ...string) (*Country, error)
+	ListCountries(locales ...string) ([]*Country, error)
+	UpsertCountryLocale(req *RCountryLocaleUpsert) (*CountryName, error)
+	DeleteCountryLocale(req *RCountryLocaleDelete) error
+
+	// ResolveCountryId returns the registry id of an alpha-2 or alpha-3
+	// code in any case, or ErrInvalidCountry. An empty code stays empty.
+	ResolveCountryId(code string) (string, error)
+	// LookupCountryId is ResolveCountryId that also accepts country names
+	// in any locale, ignoring case and accents. It repairs legacy data.
+	LookupCountryId(value string) (string, bool)
+}
+
+// CountryRepair reports the projects whose CountryId was not canonical.
+// Resolved is empty when the value matches no country.
+type CountryRepair struct {
+	CountryId string `json:"countryId"`
+	Resolved  string `json:"resolved"`
+	Projects  int64  `json:"projects"`
+}
+
+type RCountryLocaleUpsert struct {
+	CountryId string ``
+	Locale    string ``
+	Name      string ``
+}
+
+type RCountryLocaleDelete struct {
+	CountryId string ``
+	Locale    string ``
+}
+
+// NormalizeCountryId returns the form of country ids stored in the
+// registry: the lower case ISO 3166-1 alpha-2 code.
+func NormalizeCountryId(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+// NormalizeLocale returns the canonical BCP 47 form of locale.
+func NormalizeLocale(locale string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if nil != err {
+		return "", ErrInvalidLocale
+	}
+	return tag.String(), nil
+}
+
+// CLDRCountryName returns the CLDR name of the country with the ISO code
+// in locale, or "" when CLDR has no name for that locale.
+func CLDRCountryName(code string, locale string) string {
+	region, err := language.ParseRegion(code)
+	if nil != err {
+		return ""
+	}
+	tag, err := language.Parse(locale)
+	if nil != err {
+		return ""
+	}
+	// display falls back to a related language, e.g. tlh to en, which would
+	// not be locale.
+	matched, _, confidence := cldrMatcher.Match(tag)
+	if confidence == language.No || baseOf(tag) != baseOf(matched) {
+		return ""
+	}
+	var namer = display.Regions(tag)
+	if nil == namer {
+		return ""
+	}
+	return namer.Name(region)
+}
+
+var cldrMatcher = language.NewMatcher(display.Supported.Tags())
+
+func baseOf(tag language.Tag) language.Base {
+	base, _ := tag.Base()
+	return base
+}
+
+// cldrNonISO are regions CLDR treats as countries that have no ISO 3166-1
+// code.
+var cldrNonISO = map[string]bool{
+	"AC": true, "CP": true, "DG": true, "EA": true, "EZ": true,
+	"IC": true, "TA": true, "UN": true, "XK": true,
+}
+
+// ISOCountries lists the ISO 3166-1 countries, ordered by code, as known by
+// the embedded CLDR data. It seeds the in-memory registry; the countries
+// table is seeded with the same list by migration 0008.
+func ISOCountries() []*CountryInfo {
+	var rs = make([]*CountryInfo, 0, 249)
+	for a := 'A'; a <= 'Z'; a++ {
+		for b := 'A'; b <= 'Z'; b++ {
+			region, err := language.ParseRegion(string([]rune{a, b}))
+			if nil != err || !region.IsCountry() || cldrNonISO[region.String()] ||
+				region.Canonicalize() != region || region.ISO3() == "ZZZ" ||
+				display.English.Regions().Name(region) == "" {
+				continue
+			}
+			rs = append(rs, &CountryInfo{
+				Id:     NormalizeCountryId(region.String()),
+				Code:   region.String(),
+				Alpha3: region.ISO3(),
+			})
+		}
+	}
+	return rs
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestISOCountries(t *testing.T) {
+	var countries = ISOCountries()
+	if len(countries) != 249 {
+		t.Errorf("ISOCountries expect 249 countries got %d", len(countries))
+	}
+	for _, it := range countries {
+		if it.Id == "xk" || it.Id == "un" {
+			t.Errorf("ISOCountries must not have %s", it.Id)
+		}
+	}
+}
+
+func TestCLDRCountryName(t *testing.T) {
+	var cases = map[[2]string]string{
+		{"VN", "vi"}:    "Việt Nam",
+		{"DE", "en-GB"}: "Germany",
+		{"VN", "tlh"}:   "",
+	}
+	for args, expect := range cases {
+		if rs := CLDRCountryName(args[0], args[1]); rs != expect {
+			t.Errorf("CLDRCountryName(%q, %q) expect %q got %q", args[0], args[1], expect, rs)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrInvalidCursor = status.Error(codes.InvalidArgument, "invalid cursor")
+
+// Cursor is the position of the last row of a page ordered by
+// created_at DESC, id DESC. Clients only see it as an opaque token.
+type Cursor struct {
+	CreatedAt time.Time
+	Id        int64
+}
+
+func NewCursor(createdAt time.Time, id int64) Cursor {
+	// Postgres keeps microseconds, so the cursor does as well.
+	return Cursor{CreatedAt: createdAt.Truncate(time.Microsecond), Id: id}
+}
+
+func (c Cursor) Encode() string {
+	var raw = fmt.Sprintf("%d:%d", c.CreatedAt.UnixMicro(), c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Before reports whether a row sorts after the cursor, i.e. belongs to the
+// next page.
+func (c Cursor) Before(createdAt time.Time, id int64) bool {
+	if createdAt.Equal(c.CreatedAt) {
+		return id < c.Id
+	}
+	return createdAt.Before(c.CreatedAt)
+}
+
+func ParseCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if nil != err {
+		return nil, ErrInvalidCursor
+	}
+
+	micro, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, err := strconv.ParseInt(micro, 10, 64)
+	if nil != err {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &Cursor{CreatedAt: time.UnixMicro(createdAt)}
+	if cursor.Id, err = strconv.ParseInt(id, 10, 64); nil != err {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
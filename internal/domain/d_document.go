@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrDocumentNotFound        = status.Error(codes.NotFound, "document not found")
+	ErrDocumentVersionNotFound = status.Error(codes.NotFound, "document version not found")
+	ErrDocumentSort            = status.Error(codes.InvalidArgument, "invalid document sort")
+	ErrDocumentSortCursor      = status.Error(codes.InvalidArgument, "cursor pagination only supports the default document sort")
+	ErrDocumentDateRange       = status.Error(codes.InvalidArgument, "document date range ends before it starts")
+)
+
+// DocumentSort orders a document listing. Ties are broken by id, in the
+// same direction.
+type DocumentSort int
+
+const (
+	DocumentSortCreatedDesc DocumentSort = iota // Default
+	DocumentSortCreatedAsc
+	DocumentSortUpdatedDesc
+	DocumentSortUpdatedAsc
+	DocumentSortNameAsc
+	DocumentSortNameDesc
+)
+
+// Validate checks the sort, the date ranges and that a cursor is only
+// used with the default sort.
+func (req *RProjectDocumentList) Validate() error {
+	if req.Sort < DocumentSortCreatedDesc || req.Sort > DocumentSortNameDesc {
+		return ErrDocumentSort
+	}
+	if req.Cursor != "" && req.Sort != DocumentSortCreatedDesc {
+		return ErrDocumentSortCursor
+	}
+	if !req.CreatedFrom.IsZero() && !req.CreatedTo.IsZero() && req.CreatedTo.Before(req.CreatedFrom) {
+		return ErrDocumentDateRange
+	}
+	if !req.UpdatedFrom.IsZero() && !req.UpdatedTo.IsZero() && req.UpdatedTo.Before(req.UpdatedFrom) {
+		return ErrDocumentDateRange
+	}
+	return nil
+}
+
+// Match tells whether doc passes the filters of req, deleted or not.
+func (req *RProjectDocumentList) Match(doc *ProjectDocument) bool {
+	if len(req.Ids) > 0 && !containsInt64(req.Ids, doc.Id) {
+		return false
+	}
+	if len(req.ProjectIds) > 0 && !containsInt64(req.ProjectIds, doc.ProjectId) {
+		return false
+	}
+	if len(req.DocumentTypes) > 0 && !containsString(req.DocumentTypes, doc.DocumentType) {
+		return false
+	}
+	if req.Name != "" && !strings.Contains(strings.ToLower(doc.Name), strings.ToLower(req.Name)) {
+		return false
+	}
+	return inRange(doc.CreatedAt, req.CreatedFrom, req.CreatedTo) &&
+		inRange(doc.UpdatedAt, req.UpdatedFrom, req.UpdatedTo)
+}
+
+// Less tells whether a sorts before b.
+func (s DocumentSort) Less(a, b *ProjectDocument) bool {
+	var cmp int
+	switch s {
+	case DocumentSortCreatedDesc, DocumentSortCreatedAsc:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	case DocumentSortUpdatedDesc, DocumentSortUpdatedAsc:
+		cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		cmp = strings.Compare(a.Name, b.Name)
+	}
+	if cmp == 0 && a.Id != b.Id {
+		cmp = 1
+		if a.Id < b.Id {
+			cmp = -1
+		}
+	}
+	if s.Desc() {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+func (s DocumentSort) Desc() bool {
+	return s == DocumentSortCreatedDesc || s == DocumentSortUpdatedDesc || s == DocumentSortNameDesc
+}
+
+// inRange tells whether t is within [from, to], a zero bound being open.
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+}
+
+func containsInt64(arr []int64, v int64) bool {
+	for _, it := range arr {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(arr []string, v string) bool {
+	for _, it := range arr {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
+
+// RProjectDocumentVersionList lists the versions of a document, newest
+// first.
+type RProjectDocumentVersionList struct {
+	DocumentId int64 ``
+	Skip       int   ``
+	Limit      int   ``
+}
+
+// RProjectDocumentRestore makes a copy of an old version the current one.
+type RProjectDocumentRestore struct {
+	Audit
+	DocumentId int64 ``
+	Version    int64 ``
+}
+
+// SameFile tells whether doc and current describe the same version.
+func (doc *ProjectDocument) SameFile(current *ProjectDocument) bool {
+	return doc.Name == current.Name &&
+		doc.Url == current.Url &&
+		doc.DocumentType == current.DocumentType &&
+		doc.Checksum == current.Checksum &&
+		doc.Size == current.Size
+}
+
+// NewVersion snapshots doc at its current version.
+func (doc *ProjectDocument) NewVersion(uploadedBy string) *ProjectDocumentVersion {
+	return &ProjectDocumentVersion{
+		DocumentId:   doc.Id,
+		ProjectId:    doc.ProjectId,
+		Version:      doc.Version,
+		Name:         doc.Name,
+		Url:          doc.Url,
+		DocumentType: doc.DocumentType,
+		Checksum:     doc.Checksum,
+		Size:         doc.Size,
+		UploadedBy:   uploadedBy,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// Restore sets the file of doc back to version, as a new version.
+func (doc *ProjectDocument) Restore(version *ProjectDocumentVersion) {
+	doc.Name = version.Name
+	doc.Url = version.Url
+	doc.DocumentType = version.DocumentType
+	doc.Checksum = version.Checksum
+	doc.Size = version.Size
+	doc.Version++
+	doc.UpdatedAt = time.Now()
+}
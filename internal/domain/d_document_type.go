@@ -0,0 +1,186 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:embed document_types.json
+var documentTypesJSON []byte
+
+// documentCatalogue is the catalogue in use. It starts with the embedded
+// one and may be replaced at startup by LoadDocumentCatalogue.
+var documentCatalogue = mustParseDocumentCatalogue(documentTypesJSON)
+
+type DocumentType struct {
+	Key    string            `json:"key"`
+	Labels map[string]string `json:"labels"` // Locale to label
+}
+
+// Label returns the label in the first locale of chain that has one, or
+// the key.
+func (t *DocumentType) Label(chain []string) string {
+	return localizedLabel(t.Labels, chain, t.Key)
+}
+
+// DocumentRequirement makes documents required for the projects of Types
+// located in Countries. An empty list matches any type or country.
+type DocumentRequirement struct {
+	Types     []int64  `json:"types"`
+	Countries []string `json:"countries"` // Alpha-2 codes
+	Documents []string `json:"documents"` // Keys of document types
+}
+
+func (r *DocumentRequirement) match(projectType int64, countryId string) bool {
+	if len(r.Types) > 0 {
+		var found = false
+		for _, it := range r.Types {
+			found = found || it == projectType
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.Countries) > 0 {
+		var found = false
+		for _, it := range r.Countries {
+			found = found || strings.EqualFold(it, countryId)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type DocumentCatalogue struct {
+	Types        []*DocumentType        `json:"types"`
+	Requirements []*DocumentRequirement `json:"requirements"`
+}
+
+func mustParseDocumentCatalogue(raw []byte) *DocumentCatalogue {
+	catalogue, err := ParseDocumentCatalogue(raw)
+	if nil != err {
+		panic(fmt.Sprintf("document_types.json: %s", err))
+	}
+	return catalogue
+}
+
+// ParseDocumentCatalogue reads a catalogue and checks that requirements
+// only name known document types.
+func ParseDocumentCatalogue(raw []byte) (*DocumentCatalogue, error) {
+	var catalogue = &DocumentCatalogue{}
+	if err := json.Unmarshal(raw, catalogue); nil != err {
+		return nil, err
+	}
+	var known = make(map[string]bool, len(catalogue.Types))
+	for _, it := range catalogue.Types {
+		if it.Key == "" || known[it.Key] {
+			return nil, fmt.Errorf("document type %q is empty or duplicated", it.Key)
+		}
+		known[it.Key] = true
+	}
+	for _, it := range catalogue.Requirements {
+		for _, key := range it.Documents {
+			if !known[key] {
+				return nil, fmt.Errorf("required document %q is not a document type", key)
+			}
+		}
+	}
+	return catalogue, nil
+}
+
+// LoadDocumentCatalogue replaces the embedded catalogue with the one of
+// the file at path. It is meant to be called once, at startup.
+func LoadDocumentCatalogue(path string) error {
+	raw, err := os.ReadFile(path)
+	if nil != err {
+		return err
+	}
+	catalogue, err := ParseDocumentCatalogue(raw)
+	if nil != err {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	documentCatalogue = catalogue
+	return nil
+}
+
+// GetDocumentTypes returns the document types of the catalogue.
+func GetDocumentTypes() []*DocumentType {
+	return documentCatalogue.Types
+}
+
+// RequiredDocuments returns the sorted keys of the document types required
+// for a project of type projectType located in countryId.
+func RequiredDocuments(projectType int64, countryId string) []string {
+	var set = make(map[string]bool)
+	for _, it := range documentCatalogue.Requirements {
+		if !it.match(projectType, countryId) {
+			continue
+		}
+		for _, key := range it.Documents {
+			set[key] = true
+		}
+	}
+	var rs = make([]string, 0, len(set))
+	for key := range set {
+		rs = append(rs, key)
+	}
+	sort.Strings(rs)
+	return rs
+}
+
+type RProjectDocumentCompleteness struct {
+	ProjectId int64 ``
+}
+
+// DocumentCompleteness compares the documents of a project with the ones
+// required for its type and country.
+type DocumentCompleteness struct {
+	ProjectId int64    `json:"projectId"`
+	Required  []string `json:"required"`
+	Missing   []string `json:"missing"`
+}
+
+func (c *DocumentCompleteness) Complete() bool {
+	return len(c.Missing) == 0
+}
+
+// Err returns a FailedPrecondition error listing the missing types, or nil
+// when the project is complete.
+func (c *DocumentCompleteness) Err() error {
+	if c.Complete() {
+		return nil
+	}
+	return status.Error(codes.FailedPrecondition,
+		"project misses required documents: "+strings.Join(c.Missing, ", "))
+}
+
+// CheckDocuments lists the required documents of project missing from
+// documents. Deleted documents are ignored.
+func CheckDocuments(project *Project, documents []*ProjectDocument) *DocumentCompleteness {
+	var present = make(map[string]bool, len(documents))
+	for _, it := range documents {
+		if !it.DeletedAt.Valid {
+			present[it.DocumentType] = true
+		}
+	}
+	var rs = &DocumentCompleteness{
+		ProjectId: project.Id,
+		Required:  RequiredDocuments(project.Type, project.CountryId),
+		Missing:   make([]string, 0),
+	}
+	for _, key := range rs.Required {
+		if !present[key] {
+			rs.Missing = append(rs.Missing, key)
+		}
+	}
+	return rs
+}
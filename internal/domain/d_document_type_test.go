@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Dcarbon/arch-proto/pb"
+)
+
+func TestDocumentTypes(t *testing.T) {
+	for _, it := range GetDocumentTypes() {
+		if it.Labels["en"] == "" || it.Labels["vi"] == "" {
+			t.Errorf("Document type %s expect en and vi labels", it.Key)
+		}
+	}
+	if _, err := ParseDocumentCatalogue([]byte(`{"types": [{"key": "pdd"}], "requirements": [{"documents": ["eia"]}]}`)); nil == err {
+		t.Errorf("Requirement of an unknown document type expect an error")
+	}
+}
+
+func TestRequiredDocuments(t *testing.T) {
+	var biogas = int64(pb.ProjectType_PrjT_E)
+	var cases = []struct {
+		country string
+		expect  []string
+	}{
+		{"", []string{"eia", "feedstock_contract", "grid_connection", "land_rights", "monitoring_plan", "pdd"}},
+		{"VN", []string{"eia", "feedstock_contract", "grid_connection", "land_rights", "monitoring_plan", "operating_license", "pdd"}},
+	}
+	for _, it := range cases {
+		if rs := RequiredDocuments(biogas, it.country); !reflect.DeepEqual(rs, it.expect) {
+			t.Errorf("RequiredDocuments(%d, %q) expect %v got %v", biogas, it.country, it.expect, rs)
+		}
+	}
+	if rs := RequiredDocuments(int64(pb.ProjectType_PrjT_None), "vn"); !reflect.DeepEqual(rs, []string{"monitoring_plan", "pdd"}) {
+		t.Errorf("PrjT_None expect the common documents, got %v", rs)
+	}
+}
+
+func TestCheckDocuments(t *testing.T) {
+	var project = &Project{Id: 1, Type: int64(pb.ProjectType_PrjT_None)}
+	var rs = CheckDocuments(project, []*ProjectDocument{{DocumentType: "pdd"}, {DocumentType: "other"}})
+	if rs.Complete() || !reflect.DeepEqual(rs.Missing, []string{"monitoring_plan"}) || nil == rs.Err() {
+		t.Errorf("Expect monitoring_plan missing, got %v", rs.Missing)
+	}
+}
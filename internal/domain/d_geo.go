@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// earthRadius is the mean radius in metres used by HaversineDistance.
+const earthRadius = 6371008.8
+
+const (
+	NearbyDefaultLimit = 10
+	NearbyMaxLimit     = 100
+)
+
+var (
+	ErrInvalidRadius  = status.Error(codes.InvalidArgument, "radius requires a center point and must be positive")
+	ErrInvalidBounds  = status.Error(codes.InvalidArgument, "bounding box min must not exceed max")
+	ErrInvalidPolygon = status.Error(codes.InvalidArgument, "polygon must be a GeoJSON Polygon or MultiPolygon")
+	ErrNearbyCenter   = status.Error(codes.InvalidArgument, "nearby requires a center point")
+)
+
+// RProjectGetNearby selects the Limit projects closest to Near. The other
+// fields filter like RProjectGetList.
+type RProjectGetNearby struct {
+	Near      *dmodels.Coord ``
+	Limit     int            ``
+	Status    int            ``
+	Type      int64          ``
+	Unit      int64          ``
+	CountryId string         ``
+	Locales   []string       ``
+}
+
+// Filter returns the RProjectGetList matching the filters of p.
+func (p *RProjectGetNearby) Filter() *RProjectGetList {
+	return &RProjectGetList{
+		Status:    p.Status,
+		Type:      p.Type,
+		Unit:      p.Unit,
+		CountryId: p.CountryId,
+		Near:      p.Near,
+		Locales:   p.Locales,
+	}
+}
+
+// GetLimit returns Limit bounded to NearbyMaxLimit, or NearbyDefaultLimit
+// when unset.
+func (p *RProjectGetNearby) GetLimit() int {
+	if p.Limit <= 0 {
+		return NearbyDefaultLimit
+	}
+	if p.Limit > NearbyMaxLimit {
+		return NearbyMaxLimit
+	}
+	return p.Limit
+}
+
+type BoundingBox struct {
+	MinLng float64 `json:"minLng"`
+	MinLat float64 `json:"minLat"`
+	MaxLng float64 `json:"maxLng"`
+	MaxLat float64 `json:"maxLat"`
+}
+
+func (b *BoundingBox) Contains(c *dmodels.Coord) bool {
+	return c.Lng >= b.MinLng && c.Lng <= b.MaxLng &&
+		c.Lat >= b.MinLat && c.Lat <= b.MaxLat
+}
+
+// GeoPolygon is a parsed GeoJSON Polygon or MultiPolygon geometry. Each
+// polygon is a list of rings, the first one is the shell and the others are
+// holes.
+type GeoPolygon struct {
+	Polygons [][][][2]float64
+}
+
+func ParseGeoPolygon(geojson string) (*GeoPolygon, error) {
+	var geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal([]byte(geojson), &geometry); nil != err {
+		return nil, ErrInvalidPolygon
+	}
+
+	var rs = &GeoPolygon{}
+	switch geometry.Type {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(geometry.Coordinates, &polygon); nil != err {
+			return nil, ErrInvalidPolygon
+		}
+		rs.Polygons = [][][][2]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(geometry.Coordinates, &rs.Polygons); nil != err {
+			return nil, ErrInvalidPolygon
+		}
+	default:
+		return nil, ErrInvalidPolygon
+	}
+
+	if len(rs.Polygons) == 0 {
+		return nil, ErrInvalidPolygon
+	}
+	for _, polygon := range rs.Polygons {
+		if len(polygon) == 0 {
+			return nil, ErrInvalidPolygon
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+				return nil, ErrInvalidPolygon
+			}
+		}
+	}
+	return rs, nil
+}
+
+// Contains reports whether c is inside the shell and outside the holes of
+// any polygon.
+func (p *GeoPolygon) Contains(c *dmodels.Coord) bool {
+	for _, polygon := range p.Polygons {
+		if !ringContains(polygon[0], c) {
+			continue
+		}
+		var inHole = false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, c) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains is the even-odd ray casting test.
+func ringContains(ring [][2]float64, c *dmodels.Coord) bool {
+	var inside = false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		var xi, yi = ring[i][0], ring[i][1]
+		var xj, yj = ring[j][0], ring[j][1]
+		if (yi > c.Lat) != (yj > c.Lat) &&
+			c.Lng < (xj-xi)*(c.Lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// HaversineDistance returns the great-circle distance in metres. PostGIS
+// measures on the spheroid, so results differ slightly.
+func HaversineDistance(a, b *dmodels.Coord) float64 {
+	var toRad = math.Pi / 180
+	var dLat = (b.Lat - a.Lat) * toRad
+	var dLng = (b.Lng - a.Lng) * toRad
+	var h = math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(a.Lat*toRad)*math.Cos(b.Lat*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// ValidateGeo checks the geospatial filters of p.
+func (p *RProjectGetList) ValidateGeo() error {
+	if p.RadiusM < 0 || (p.RadiusM > 0 && nil == p.Near) {
+		return ErrInvalidRadius
+	}
+	if nil != p.Bounds && (p.Bounds.MinLng > p.Bounds.MaxLng || p.Bounds.MinLat > p.Bounds.MaxLat) {
+		return ErrInvalidBounds
+	}
+	if p.Polygon != "" {
+		if _, err := ParseGeoPolygon(p.Polygon); nil != err {
+			return err
+		}
+	}
+	return nil
+}
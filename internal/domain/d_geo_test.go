@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+)
+
+func TestGeoPolygonContains(t *testing.T) {
+	polygon, err := ParseGeoPolygon(`{"type":"Polygon","coordinates":[
+		[[0,0],[10,0],[10,10],[0,10],[0,0]],
+		[[4,4],[6,4],[6,6],[4,6],[4,4]]
+	]}`)
+	if nil != err {
+		t.Fatalf("ParseGeoPolygon fail: %s", err)
+	}
+
+	var cases = map[*dmodels.Coord]bool{
+		dmodels.NewCoord4326(2, 2):  true,
+		dmodels.NewCoord4326(5, 5):  false, // In the hole
+		dmodels.NewCoord4326(11, 5): false,
+	}
+	for coord, expect := range cases {
+		if rs := polygon.Contains(coord); rs != expect {
+			t.Errorf("Contains(%v) expect %v got %v", coord, expect, rs)
+		}
+	}
+}
+
+func TestHaversineDistance(t *testing.T) {
+	// Hanoi to Ho Chi Minh City is about 1137km.
+	var rs = HaversineDistance(dmodels.NewCoord4326(105.8342, 21.0278), dmodels.NewCoord4326(106.6297, 10.8231))
+	if rs < 1130000 || rs > 1145000 {
+		t.Errorf("HaversineDistance expect about 1137km got %f", rs)
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import "fmt"
+
+// Diff returns the fields of current that req changes.
+func (req *RProjectUpdate) Diff(current *Project) HistoryDiff {
+	var diff = HistoryDiff{}
+	diff.Set("owner", current.Owner, req.Owner)
+	diff.Set("ownerId", current.OwnerId, req.OwnerId)
+	diff.Set("location", current.Location, req.Location)
+	diff.Set("locationName", current.LocationName, req.LocationName)
+	diff.Set("type", current.Type, req.Type)
+	diff.Set("unit", current.Unit, req.Unit)
+	diff.Set("countryId", current.CountryId, req.CountryId)
+	diff.Set("iframe", current.Iframe, req.Iframe)
+	diff.Set("ownerAddress", current.OwnerAddress, req.OwnerAddress)
+	return diff
+}
+
+// Diff returns the fields of current that req changes. current is nil when
+// the language does not exist yet.
+func (req *RProjectUpdateDesc) Diff(current *ProjectDesc) HistoryDiff {
+	var diff = HistoryDiff{}
+	var prefix = "descs." + req.Language + "."
+	if nil == current {
+		current = &ProjectDesc{}
+	}
+	diff.Set(prefix+"name", current.Name, req.Name)
+	diff.Set(prefix+"desc", current.Desc, req.Desc)
+	return diff
+}
+
+// Diff returns the spec keys added, removed or changed by req. current is
+// nil when the project has no specs yet.
+func (req *RProjectUpdateSpecs) Diff(current *ProjectSpecs) HistoryDiff {
+	var diff = HistoryDiff{}
+	var old = MapSFloat{}
+	if nil != current && nil != current.Specs {
+		old = current.Specs
+	}
+	for k, v := range req.Specs {
+		if ov, ok := old[k]; ok {
+			diff.Set("specs."+k, ov, v)
+		} else {
+			diff.Set("specs."+k, nil, v)
+		}
+	}
+	for k, ov := range old {
+		if _, ok := req.Specs[k]; !ok {
+			diff.Set("specs."+k, ov, nil)
+		}
+	}
+	return diff
+}
+
+// Diff returns the fields of current that doc changes. current is nil when
+// doc has just been created.
+func (doc *ProjectDocument) Diff(current *ProjectDocument) HistoryDiff {
+	var diff = HistoryDiff{}
+	var prefix = fmt.Sprintf("documents.%d.", doc.Id)
+	if nil == current {
+		current = &ProjectDocument{}
+	}
+	diff.Set(prefix+"name", current.Name, doc.Name)
+	diff.Set(prefix+"url", current.Url, doc.Url)
+	diff.Set(prefix+"documentType", current.DocumentType, doc.DocumentType)
+	diff.Set(prefix+"checksum", current.Checksum, doc.Checksum)
+	diff.Set(prefix+"version", current.Version, doc.Version)
+	return diff
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrImageNotFound     = status.Error(codes.NotFound, "image not found")
+	ErrInvalidImageOrder = status.Error(codes.InvalidArgument, "image order must list every image of the project exactly once")
+)
+
+type RProjectImageList struct {
+	ProjectId      int64 ``
+	IncludeDeleted bool  `` // Admin only
+}
+
+type RProjectImageDelete struct {
+	Audit
+	ProjectId int64 ``
+	ImageId   int64 ``
+}
+
+// RProjectImageReorder sets the gallery order. ImageIds lists every image
+// of the project, first shown first.
+type RProjectImageReorder struct {
+	Audit
+	ProjectId int64   ``
+	ImageIds  []int64 ``
+}
+
+type RProjectImageCaption struct {
+	Audit
+	ProjectId int64  ``
+	ImageId   int64  ``
+	Caption   string ``
+}
+
+// RProjectSetThumbnail promotes a gallery image to the project thumbnail.
+type RProjectSetThumbnail struct {
+	Audit
+	ProjectId int64 ``
+	ImageId   int64 ``
+}
+
+// Validate checks that req.ImageIds is a permutation of images.
+func (req *RProjectImageReorder) Validate(images []*ProjectImage) error {
+	if len(req.ImageIds) != len(images) {
+		return ErrInvalidImageOrder
+	}
+	var ids = make(map[int64]bool, len(images))
+	for _, it := range images {
+		ids[it.Id] = true
+	}
+	for _, id := range req.ImageIds {
+		if !ids[id] {
+			return ErrInvalidImageOrder
+		}
+		delete(ids, id)
+	}
+	return nil
+}
+
+// SortImages orders images as the gallery shows them.
+func SortImages(images []*ProjectImage) {
+	sort.SliceStable(images, func(i, j int) bool {
+		if images[i].Position != images[j].Position {
+			return images[i].Position < images[j].Position
+		}
+		return images[i].Id < images[j].Id
+	})
+}
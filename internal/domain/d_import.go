@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrImportEmpty = status.Error(codes.InvalidArgument, "import has no row")
+
+var errImportRolledBack = errors.New("not imported, another row failed")
+
+// ImportRow is one row of an import. Err is set when the row could not
+// be read, such rows fail without reaching the repository.
+type ImportRow struct {
+	Row    int             `` // 1-based, without the header of a csv
+	Create *RProjectCreate ``
+	Err    error           ``
+}
+
+// RProjectImport creates the projects of Rows in one transaction. Without
+// AllOrNothing the valid rows are kept when others fail.
+type RProjectImport struct {
+	Rows         []*ImportRow ``
+	AllOrNothing bool         ``
+	DryRun       bool         `` // Validate every row, create none
+}
+
+type ImportResult struct {
+	Row       int    `json:"row"`
+	ProjectId int64  `json:"projectId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Created int             `json:"created"`
+	Failed  int             `json:"failed"`
+	Rows    []*ImportResult `json:"rows"`
+}
+
+// NewImportReport starts a report with the rows that could not be read
+// already failed.
+func NewImportReport(req *RProjectImport) (*ImportReport, error) {
+	if len(req.Rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	var report = &ImportReport{Rows: make([]*ImportResult, len(req.Rows))}
+	for i, it := range req.Rows {
+		report.Rows[i] = &ImportResult{Row: it.Row}
+		if nil != it.Err {
+			report.Fail(i, it.Err)
+		}
+	}
+	return report, nil
+}
+
+func (r *ImportReport) Fail(i int, err error) {
+	if r.Rows[i].Error == "" {
+		r.Failed++
+	}
+	r.Rows[i].Error = err.Error()
+}
+
+// Succeed records the project created for the row i.
+func (r *ImportReport) Succeed(i int, projectId int64) {
+	r.Rows[i].ProjectId = projectId
+	r.Created++
+}
+
+// Ok tells whether the row i is still to be created.
+func (r *ImportReport) Ok(i int) bool {
+	return r.Rows[i].Error == ""
+}
+
+// RollBack fails every created or pending row, after the transaction of
+// an all-or-nothing import was rolled back.
+func (r *ImportReport) RollBack() {
+	for i, it := range r.Rows {
+		it.ProjectId = 0
+		if r.Ok(i) {
+			r.Fail(i, errImportRolledBack)
+		}
+	}
+	r.Created = 0
+}
+
+// ValidateDescs checks that every desc has a language and a name, with
+// one desc per language.
+func (rproject *RProjectCreate) ValidateDescs() error {
+	var languages = make(map[string]bool, len(rproject.Descs))
+	for _, it := range rproject.Descs {
+		if nil == it || strings.TrimSpace(it.Language) == "" {
+			return errors.New("desc without language")
+		}
+		if strings.TrimSpace(it.Name) == "" {
+			return fmt.Errorf("desc %s has no name", it.Language)
+		}
+		if languages[it.Language] {
+			return fmt.Errorf("desc %s is duplicated", it.Language)
+		}
+		languages[it.Language] = true
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultFallbackLocales follow the requested locales when no fallback
+// chain is configured.
+var DefaultFallbackLocales = []string{"en", "vi"}
+
+// projectTypeNames are the names of the project types per locale.
+var projectTypeNames = map[string]map[int64]string{
+	"en": {0: "None", 1: "Biomass to Gasification", 2: "Biogas to Electricity", 3: "Model S"},
+	"vi": {0: "Không", 1: "Khí hóa sinh khối", 2: "Biogas phát điện", 3: "Model S"},
+}
+
+// LocaleResolver turns the locales asked by a client into the ordered
+// chain used to pick descs, country names and type names.
+type LocaleResolver struct {
+	fallback []string
+}
+
+// NewLocaleResolver keeps the valid locales of fallback, in order.
+func NewLocaleResolver(fallback []string) *LocaleResolver {
+	var rs = &LocaleResolver{}
+	for _, it := range fallback {
+		if locale, err := NormalizeLocale(it); nil == err && it != "" {
+			rs.fallback = appendLocale(rs.fallback, locale)
+		}
+	}
+	return rs
+}
+
+// ParseFallbackLocales splits a comma separated fallback chain, as in
+// "en,vi".
+func ParseFallbackLocales(value string) []string {
+	var rs = make([]string, 0)
+	for _, it := range strings.Split(value, ",") {
+		if it = strings.TrimSpace(it); it != "" {
+			rs = append(rs, it)
+		}
+	}
+	if len(rs) == 0 {
+		return DefaultFallbackLocales
+	}
+	return rs
+}
+
+// Negotiate returns the locales of requested followed by the fallback
+// chain, without duplicates. requested is a language tag or an
+// Accept-Language value; invalid values are ignored.
+func (r *LocaleResolver) Negotiate(requested string) []string {
+	var chain = make([]string, 0, len(r.fallback)+1)
+	if tags, _, err := language.ParseAcceptLanguage(requested); nil == err {
+		for _, tag := range tags {
+			chain = appendLocale(chain, tag.String())
+		}
+	}
+	for _, it := range r.fallback {
+		chain = appendLocale(chain, it)
+	}
+	return chain
+}
+
+func appendLocale(chain []string, locale string) []string {
+	for _, it := range chain {
+		if it == locale {
+			return chain
+		}
+	}
+	return append(chain, locale)
+}
+
+// MatchLocale returns the first locale of chain found in available, either
+// exactly or by base language, e.g. "en-US" matches "en". It returns ""
+// when nothing matches.
+func MatchLocale(chain []string, available []string) string {
+	for _, want := range chain {
+		for _, it := range available {
+			if strings.EqualFold(it, want) {
+				return it
+			}
+		}
+		var base = localeBase(want)
+		for _, it := range available {
+			if localeBase(it) == base {
+				return it
+			}
+		}
+	}
+	return ""
+}
+
+func localeBase(locale string) string {
+	base, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	return base
+}
+
+// TypeName returns the name of the project type t in the first locale of
+// chain that has one, defaulting to English, and that locale.
+func TypeName(t int64, chain []string) (string, string) {
+	var locales = make([]string, 0, len(projectTypeNames))
+	for it := range projectTypeNames {
+		locales = append(locales, it)
+	}
+	sort.Strings(locales)
+	var locale = MatchLocale(chain, locales)
+	if locale == "" {
+		locale = "en"
+	}
+	return projectTypeNames[locale][t], locale
+}
+
+// Localize keeps the desc of p in the first locale of chain that has one,
+// or its oldest desc otherwise, and names its type in chain. Language
+// reports the locale of the desc kept.
+func (p *Project) Localize(chain []string) {
+	p.TypeName, _ = TypeName(p.Type, chain)
+	if len(p.Descs) == 0 {
+		return
+	}
+
+	var available = make([]string, len(p.Descs))
+	for i, it := range p.Descs {
+		available[i] = it.Language
+	}
+	var desc = p.Descs[0]
+	for _, it := range p.Descs[1:] {
+		if it.Id < desc.Id {
+			desc = it
+		}
+	}
+	if locale := MatchLocale(chain, available); locale != "" {
+		for _, it := range p.Descs {
+			if it.Language == locale {
+				desc = it
+				break
+			}
+		}
+	}
+	p.Descs = []*ProjectDesc{desc}
+	p.Language = desc.Language
+}
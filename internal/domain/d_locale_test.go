@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocaleResolverNegotiate(t *testing.T) {
+	var resolver = NewLocaleResolver([]string{"en", "vi", "not a locale"})
+	var cases = map[string][]string{
+		"":                          {"en", "vi"},
+		"vi":                        {"vi", "en"},
+		"fr-CH, fr;q=0.9, en;q=0.8": {"fr-CH", "fr", "en", "vi"},
+		"!!":                        {"en", "vi"},
+	}
+	for requested, expect := range cases {
+		if rs := resolver.Negotiate(requested); !reflect.DeepEqual(rs, expect) {
+			t.Errorf("Negotiate(%q) expect %v got %v", requested, expect, rs)
+		}
+	}
+}
+
+func TestProjectLocalize(t *testing.T) {
+	var newProject = func() *Project {
+		return &Project{
+			Type: 2,
+			Descs: []*ProjectDesc{
+				{Id: 2, Language: "vi", Name: "Tên"},
+				{Id: 1, Language: "en-US", Name: "Name"},
+			},
+		}
+	}
+
+	var project = newProject()
+	project.Localize([]string{"fr", "en"})
+	if len(project.Descs) != 1 || project.Language != "en-US" || project.TypeName != "Biogas to Electricity" {
+		t.Errorf("Localize expect en-US desc, got %q", project.Language)
+	}
+
+	project = newProject()
+	project.Localize([]string{"vi"})
+	if project.Language != "vi" || project.TypeName != "Biogas phát điện" {
+		t.Errorf("Localize expect vi desc, got %q", project.Language)
+	}
+
+	project = newProject()
+	project.Localize([]string{"fr"})
+	if project.Language != "en-US" {
+		t.Errorf("Localize expect the oldest desc, got %q", project.Language)
+	}
+}
@@ -13,15 +13,39 @@ type IProject interface {
 	Update(req *RProjectUpdate) (*int64, error)
 	UpdateDesc(req *RProjectUpdateDesc) (*ProjectDesc, error)
 	UpdateSpecs(req *RProjectUpdateSpecs) (*ProjectSpecs, error)
-	GetById(id int64, lang string) (*Project, error)
+	GetById(req *RProjectGetById) (*Project, error)
 	GetList(filter *RProjectGetList) (*int64, []*Project, error)
+	GetNearby(req *RProjectGetNearby) ([]*Project, error)
 	GetOwner(projectId int64) (string, error)
 	AddImage(*RProjectAddImage) (*ProjectImage, error)
-	ChangeStatus(id int, status ProjectStatus) error
-	GetCountry(id string, vi string) (*Country, error)
+	ListImages(req *RProjectImageList) ([]*ProjectImage, error)
+	DeleteImage(req *RProjectImageDelete) error
+	ReorderImages(req *RProjectImageReorder) ([]*ProjectImage, error)
+	UpdateImageCaption(req *RProjectImageCaption) (*ProjectImage, error)
+	SetThumbnail(req *RProjectSetThumbnail) (*ProjectImage, error)
+	ChangeStatus(req *RProjectChangeStatus) error
+	GetCountry(id string, locales ...string) (*Country, error)
 	UpsertDocument(req *RProjectDocumentUpsert) ([]*ProjectDocument, error)
 	ListDocument(req *RProjectDocumentList) ([]*ProjectDocument, int64, error)
 	DeleteDocument(req *RProjectDocumentDelete) error
+	ListDocumentVersions(req *RProjectDocumentVersionList) ([]*ProjectDocumentVersion, int64, error)
+	RestoreDocumentVersion(req *RProjectDocumentRestore) (*ProjectDocument, error)
+	GetDocumentCompleteness(req *RProjectDocumentCompleteness) (*DocumentCompleteness, error)
+	CountDocuments(projectIds []int64) (map[int64]int64, error)
+	PurgeDocuments(req *RDocumentPurge) (*DocumentPurgeReport, error)
+	ImportProjects(req *RProjectImport) (*ImportReport, error)
+	GetHistory(req *RProjectHistoryList) ([]*ProjectHistory, int64, error)
+	GetSpecsAt(req *RProjectSpecsAt) (*ProjectSpecsVersion, error)
+	GetSpecsHistory(req *RProjectSpecsHistory) ([]*ProjectSpecsVersion, int64, error)
+	DeleteProject(req *RProjectDelete) error
+	RestoreProject(req *RProjectRestore) error
+}
+
+// Audit identifies who makes a change. It is stored with the project
+// history entry written by the change.
+type Audit struct {
+	Actor  string ``
+	Action string ``
 }
 
 type RProjectCreate struct {
@@ -40,7 +64,9 @@ type RProjectCreate struct {
 }
 
 type RProjectUpdate struct {
+	Audit
 	ProjectId    int64              ``
+	Version      int64              `` // Expected version, 0 skips the check
 	CountryId    string             ``
 	OwnerId      string             ``
 	Type         int64              ``
@@ -54,6 +80,7 @@ type RProjectUpdate struct {
 }
 
 type RProjectUpdateDesc struct {
+	Audit
 	ProjectId int64  ``
 	Language  string ``
 	Name      string ``
@@ -61,8 +88,19 @@ type RProjectUpdateDesc struct {
 }
 
 type RProjectUpdateSpecs struct {
+	Audit     `json:"-"`
 	ProjectId int64              `json:"projectId"`
 	Specs     map[string]float64 `json:"specs"`
+	Version   int64              `json:"version"` // Expected version, 0 skips the check
+	// EffectiveFrom backdates the change, zero means now. Backdated
+	// changes older than the latest version only fill the history.
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+}
+
+type RProjectGetById struct {
+	Id             int64    ``
+	Locales        []string `` // Preferred locales, empty keeps every desc
+	IncludeDeleted bool     `` // Admin only
 }
 
 type RProjectGetList struct {
@@ -76,12 +114,38 @@ type RProjectGetList struct {
 	Location    string ``
 	Status      int    ``
 	Ids         []int  ``
+
+	IncludeDeleted bool     `` // Admin only
+	Locales        []string `` // Preferred locales, empty keeps every desc
+
+	// Cursor continues after a previous page and replaces Skip.
+	Cursor    string ``
+	SkipTotal bool   `` // Do not count matching rows
+
+	// Near sets the point Project.Distance is measured from. With RadiusM
+	// only projects within that many metres are kept.
+	Near    *dmodels.Coord ``
+	RadiusM float64        ``
+	Bounds  *BoundingBox   ``
+	Polygon string         `` // GeoJSON Polygon or MultiPolygon
 }
 
 type RProjectAddImage struct {
+	Audit     `json:"-"`
 	ProjectId int64  `json:"projectId"`
 	ImgPath   string `json:"imgPath"`
 	Type      int32
+	Checksum  string        `json:"checksum"`
+	Size      int64         `json:"size"`
+	Variants  ImageVariants `json:"variants"`
+}
+
+// GetSpecs returns the specs of the new project, nil when it has none.
+func (rproject *RProjectCreate) GetSpecs() map[string]float64 {
+	if nil == rproject.Specs {
+		return nil
+	}
+	return rproject.Specs.Specs
 }
 
 func (rproject *RProjectCreate) ToProject() *Project {
@@ -89,6 +153,7 @@ func (rproject *RProjectCreate) ToProject() *Project {
 		Id:           0,
 		LocationName: rproject.LocationName,
 		Status:       ProjectStatusRegister,
+		Version:      1,
 		Owner:        rproject.Owner,
 		Location:     rproject.Location,
 		Specs:        rproject.Specs.ToProjectSpecs(),
@@ -135,14 +200,7 @@ func (rspec *RProjectUpdateSpecs) ToProjectSpecs() *ProjectSpecs {
 
 func (p RProjectGetList) GetUnit() string {
 	var query string
-	var ranges [][2]int
-
-	switch p.Type {
-	case int64(*pb.ProjectType_PrjT_G.Enum()):
-		ranges = [][2]int{{0, 20}, {20, 100}, {100, -1}}
-	case int64(*pb.ProjectType_PrjT_E.Enum()), int64(*pb.ProjectType_PrjT_S.Enum()):
-		ranges = [][2]int{{0, 90}, {90, 200}, {200, -1}}
-	}
+	var ranges = p.unitRanges()
 
 	if len(ranges) > 0 {
 		switch p.Unit {
@@ -158,18 +216,79 @@ func (p RProjectGetList) GetUnit() string {
 	return query
 }
 
+// MatchUnit reports whether unit falls in the range selected by p.Unit,
+// using the same bounds as GetUnit.
+func (p RProjectGetList) MatchUnit(unit float32) bool {
+	var ranges = p.unitRanges()
+	if len(ranges) == 0 {
+		return true
+	}
+
+	switch p.Unit {
+	case 1:
+		return unit >= float32(ranges[0][0]) && unit < float32(ranges[0][1])
+	case 2:
+		return unit >= float32(ranges[1][0]) && unit <= float32(ranges[1][1])
+	case 3:
+		return unit > float32(ranges[2][0])
+	}
+	return true
+}
+
+func (p RProjectGetList) unitRanges() [][2]int {
+	switch p.Type {
+	case int64(*pb.ProjectType_PrjT_G.Enum()):
+		return [][2]int{{0, 20}, {20, 100}, {100, -1}}
+	case int64(*pb.ProjectType_PrjT_E.Enum()), int64(*pb.ProjectType_PrjT_S.Enum()):
+		return [][2]int{{0, 90}, {90, 200}, {200, -1}}
+	}
+	return nil
+}
+
 type Document struct {
 	Url          string
 	DocumentName string
 	DocumentType string
 	ProjectId    int64
 	Id           int64
+	Checksum     string
+	Size         int64
 }
 
 type RProjectDocumentUpsert struct {
+	Audit
 	Document []*Document ``
 }
 
+type RProjectChangeStatus struct {
+	Audit
+	ProjectId        int64         ``
+	Status           ProjectStatus ``
+	Reason           string        `` // Required to reject a project
+	Resubmit         bool          `` // The owner sends a rejected project back to review
+	RequireDocuments bool          `` // Refuse to activate a project missing required documents
+}
+
+// RProjectDelete soft-deletes a project with its descs, specs, images and
+// documents.
+type RProjectDelete struct {
+	Audit
+	ProjectId int64 ``
+}
+
+// RProjectRestore brings back a project and the rows deleted with it.
+// Documents deleted on their own before stay deleted.
+type RProjectRestore struct {
+	Audit
+	ProjectId int64 ``
+}
+
+type RProjectHistoryList struct {
+	ProjectId int64 ``
+	Skip      int   `json:"skip" form:"skip"`
+	Limit     int   `json:"limit" form:"limit;max=50"`
+}
+
 type RProjectDocumentDelete struct {
 	Id []int64
 }
@@ -178,4 +297,18 @@ type RProjectDocumentList struct {
 	Skip  int     `json:"skip" form:"skip"`
 	Limit int     `json:"limit" form:"limit;max=50"`
 	Ids   []int64 ``
+
+	ProjectIds    []int64      ``
+	DocumentTypes []string     ``
+	Name          string       `` // Case insensitive part of the name
+	CreatedFrom   time.Time    `` // Zero times leave the range open
+	CreatedTo     time.Time    ``
+	UpdatedFrom   time.Time    ``
+	UpdatedTo     time.Time    ``
+	Sort          DocumentSort ``
+
+	// Cursor continues after a previous page and replaces Skip. It only
+	// works with the default sort.
+	Cursor    string ``
+	SkipTotal bool   `` // Do not count matching rows
 }
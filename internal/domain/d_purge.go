@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// RDocumentPurge hard-deletes the documents soft-deleted before Before,
+// with their versions.
+type RDocumentPurge struct {
+	Before time.Time ``
+	DryRun bool      `` // Only report what would be purged
+}
+
+type PurgedDocument struct {
+	Id        int64     `json:"id"`
+	ProjectId int64     `json:"projectId"`
+	Name      string    `json:"name"`
+	Versions  int       `json:"versions"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// DocumentPurgeReport lists the purged documents and the storage objects
+// no row refers to anymore. Failed holds the objects the storage could
+// not delete.
+type DocumentPurgeReport struct {
+	DryRun    bool              `json:"dryRun"`
+	Before    time.Time         `json:"before"`
+	Documents []*PurgedDocument `json:"documents"`
+	Files     []string          `json:"files"`
+	Failed    map[string]string `json:"failed,omitempty"` // Path to error
+}
+
+// NewDocumentPurgeReport lists documents and the files of documents and
+// versions that were uploaded to storage, minus the referenced ones.
+// Documents added by url have no checksum and no storage object.
+func NewDocumentPurgeReport(req *RDocumentPurge, documents []*ProjectDocument,
+	versions []*ProjectDocumentVersion, referenced map[string]bool,
+) *DocumentPurgeReport {
+	var report = &DocumentPurgeReport{
+		DryRun:    req.DryRun,
+		Before:    req.Before,
+		Documents: make([]*PurgedDocument, 0, len(documents)),
+		Files:     make([]string, 0),
+	}
+	var files = make(map[string]bool)
+	var addFile = func(url, checksum string) {
+		if url != "" && checksum != "" && !referenced[url] {
+			files[url] = true
+		}
+	}
+
+	var counts = make(map[int64]int, len(documents))
+	for _, it := range versions {
+		counts[it.DocumentId]++
+		addFile(it.Url, it.Checksum)
+	}
+	for _, it := range documents {
+		report.Documents = append(report.Documents, &PurgedDocument{
+			Id:        it.Id,
+			ProjectId: it.ProjectId,
+			Name:      it.Name,
+			Versions:  counts[it.Id],
+			DeletedAt: it.DeletedAt.Time,
+		})
+		addFile(it.Url, it.Checksum)
+	}
+
+	for it := range files {
+		report.Files = append(report.Files, it)
+	}
+	sort.Strings(report.Files)
+	return report
+}
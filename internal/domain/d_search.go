@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SearchConfig is the Postgres text search configuration for project
+// search: the simple dictionary behind unaccent, so Vietnamese matches
+// with or without diacritics.
+const SearchConfig = "projects_search"
+
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+var ErrSearchCursor = status.Error(codes.InvalidArgument, "cursor pagination is not supported with searchValue")
+
+// CursorPaging tells whether the pages of filter can be continued with a
+// cursor. Searches are ranked, so they only page with Skip.
+func (filter *RProjectGetList) CursorPaging() bool {
+	return len(SearchTokens(filter.SearchValue)) == 0
+}
+
+// SearchTokens splits value into lower case words without diacritics.
+func SearchTokens(value string) []string {
+	return strings.FieldsFunc(FoldText(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchQuery builds a to_tsquery expression matching every word of value
+// as a prefix. It is empty when value has no words.
+func SearchQuery(value string) string {
+	var tokens = SearchTokens(value)
+	for i, token := range tokens {
+		tokens[i] = token + ":*"
+	}
+	return strings.Join(tokens, " & ")
+}
+
+// FoldText lower-cases text and strips diacritics, like unaccent does.
+func FoldText(text string) string {
+	var rs = strings.Builder{}
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			rs.WriteRune('d')
+		default:
+			rs.WriteRune(r)
+		}
+	}
+	return rs.String()
+}
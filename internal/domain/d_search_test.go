@@ -0,0 +1,16 @@
+package domain
+
+import "testing"
+
+func TestSearchQuery(t *testing.T) {
+	var cases = map[string]string{
+		"Biogas Đồng Nai": "biogas:* & dong:* & nai:*",
+		"  hà-nội ":       "ha:* & noi:*",
+		"':*&|!()":        "",
+	}
+	for value, expect := range cases {
+		if rs := SearchQuery(value); rs != expect {
+			t.Errorf("SearchQuery(%q) expect %q got %q", value, expect, rs)
+		}
+	}
+}
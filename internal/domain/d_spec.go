@@ -0,0 +1,159 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:embed spec_schemas.json
+var specSchemasJSON []byte
+
+// specSchemas maps a project type to the schema of its specs. PrjT_None
+// has an empty schema, its projects have no specs.
+var specSchemas = loadSpecSchemas(specSchemasJSON)
+
+var (
+	ErrSpecSchemaNotFound = status.Error(codes.NotFound, "project type has no spec schema")
+	ErrProjectType        = status.Error(codes.InvalidArgument, "unknown project type")
+)
+
+type SpecField struct {
+	Key      string            `json:"key"`
+	Unit     string            `json:"unit"`
+	Min      *float64          `json:"min,omitempty"`
+	Max      *float64          `json:"max,omitempty"`
+	Required bool              `json:"required"`
+	Labels   map[string]string `json:"labels"` // Locale to label
+}
+
+// Label returns the label in the first locale of chain that has one, or
+// the key.
+func (f *SpecField) Label(chain []string) string {
+	return localizedLabel(f.Labels, chain, f.Key)
+}
+
+// localizedLabel picks the label of the first locale of chain in labels,
+// else the default locale one, else fallback.
+func localizedLabel(labels map[string]string, chain []string, fallback string) string {
+	var locales = make([]string, 0, len(labels))
+	for it := range labels {
+		locales = append(locales, it)
+	}
+	sort.Strings(locales)
+	if locale := MatchLocale(chain, locales); locale != "" {
+		return labels[locale]
+	}
+	if label, ok := labels[DefaultCountryLocale]; ok {
+		return label
+	}
+	return fallback
+}
+
+type SpecSchema struct {
+	Type   int64        `json:"type"`
+	Fields []*SpecField `json:"fields"`
+}
+
+func loadSpecSchemas(raw []byte) map[int64]*SpecSchema {
+	var schemas = make([]*SpecSchema, 0)
+	if err := json.Unmarshal(raw, &schemas); nil != err {
+		panic(fmt.Sprintf("spec_schemas.json: %s", err))
+	}
+	var rs = make(map[int64]*SpecSchema, len(schemas))
+	for _, it := range schemas {
+		rs[it.Type] = it
+	}
+	return rs
+}
+
+// GetSpecSchema returns the schema of the project type t.
+func GetSpecSchema(t int64) (*SpecSchema, error) {
+	schema, ok := specSchemas[t]
+	if !ok || !isProjectType(t) {
+		return nil, ErrSpecSchemaNotFound
+	}
+	return schema, nil
+}
+
+func isProjectType(t int64) bool {
+	_, ok := pb.ProjectType_name[int32(t)]
+	return ok && int64(int32(t)) == t
+}
+
+// SpecKeys returns the keys of the schema of the project type t, in schema
+// order. With t 0 it returns the keys of every schema, by type.
+func SpecKeys(t int64) []string {
+	var types = []int64{t}
+	if t == 0 {
+		types = make([]int64, 0, len(specSchemas))
+		for it := range specSchemas {
+			types = append(types, it)
+		}
+		sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	}
+
+	var keys = make([]string, 0)
+	var seen = make(map[string]bool)
+	for _, it := range types {
+		schema, ok := specSchemas[it]
+		if !ok {
+			continue
+		}
+		for _, field := range schema.Fields {
+			if !seen[field.Key] {
+				seen[field.Key] = true
+				keys = append(keys, field.Key)
+			}
+		}
+	}
+	return keys
+}
+
+// ValidateSpecs checks specs against the schema of the project type t. It
+// returns an InvalidArgument error listing every violation, or
+// ErrProjectType when t is not a pb.ProjectType with a schema.
+func ValidateSpecs(t int64, specs map[string]float64) error {
+	schema, err := GetSpecSchema(t)
+	if nil != err {
+		return ErrProjectType
+	}
+
+	var violations = make([]string, 0)
+	var known = make(map[string]bool, len(schema.Fields))
+	for _, field := range schema.Fields {
+		known[field.Key] = true
+		value, ok := specs[field.Key]
+		switch {
+		case !ok && field.Required:
+			violations = append(violations, field.Key+" is required")
+		case !ok:
+		case nil != field.Min && value < *field.Min:
+			violations = append(violations, fmt.Sprintf("%s must be at least %g %s", field.Key, *field.Min, field.Unit))
+		case nil != field.Max && value > *field.Max:
+			violations = append(violations, fmt.Sprintf("%s must be at most %g %s", field.Key, *field.Max, field.Unit))
+		}
+	}
+
+	var unknown = make([]string, 0)
+	for key := range specs {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		violations = append(violations, key+" is not a spec of this project type")
+	}
+
+	if len(violations) > 0 {
+		return status.Error(codes.InvalidArgument, "invalid specs: "+strings.Join(violations, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSpecSchemas(t *testing.T) {
+	for _, it := range []pb.ProjectType{pb.ProjectType_PrjT_G, pb.ProjectType_PrjT_E, pb.ProjectType_PrjT_S} {
+		schema, err := GetSpecSchema(int64(it))
+		if nil != err {
+			t.Errorf("Project type %d expect a spec schema", it)
+			continue
+		}
+		for _, field := range schema.Fields {
+			if field.Labels["en"] == "" || field.Labels["vi"] == "" {
+				t.Errorf("Spec %s of type %d expect en and vi labels", field.Key, it)
+			}
+		}
+	}
+	for it := range pb.ProjectType_name {
+		if _, err := GetSpecSchema(int64(it)); nil != err {
+			t.Errorf("Project type %d expect a spec schema", it)
+		}
+	}
+	if err := ValidateSpecs(int64(pb.ProjectType_PrjT_None), nil); nil != err {
+		t.Errorf("PrjT_None expect no specs, got %s", err)
+	}
+	if err := ValidateSpecs(int64(pb.ProjectType_PrjT_None), map[string]float64{"any": 1}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("PrjT_None expect no specs, got %v", err)
+	}
+	for _, it := range []int64{-1, 99, 1 << 32} {
+		if _, err := GetSpecSchema(it); err != ErrSpecSchemaNotFound {
+			t.Errorf("Project type %d expect no spec schema", it)
+		}
+		if err := ValidateSpecs(it, nil); err != ErrProjectType {
+			t.Errorf("Project type %d expect ErrProjectType, got %v", it, err)
+		}
+	}
+}
+
+func TestSpecFieldLabel(t *testing.T) {
+	var field = &SpecField{Key: "k", Labels: map[string]string{"en": "Label", "vi": "Nhãn"}}
+	var cases = map[string]string{"vi-VN": "Nhãn", "fr": "Label"}
+	for locale, expect := range cases {
+		if rs := field.Label([]string{locale}); rs != expect {
+			t.Errorf("Label(%q) expect %q got %q", locale, expect, rs)
+		}
+	}
+}
+
+func TestSpecKeys(t *testing.T) {
+	schema, _ := GetSpecSchema(int64(pb.ProjectType_PrjT_E))
+	var keys = SpecKeys(int64(pb.ProjectType_PrjT_E))
+	if len(keys) != len(schema.Fields) || keys[0] != schema.Fields[0].Key {
+		t.Errorf("SpecKeys expect the schema keys in order, got %v", keys)
+	}
+
+	var all = SpecKeys(0)
+	var seen = map[string]bool{}
+	for _, it := range all {
+		if seen[it] {
+			t.Errorf("SpecKeys(0) repeats %s", it)
+		}
+		seen[it] = true
+	}
+	for _, it := range keys {
+		if !seen[it] {
+			t.Errorf("SpecKeys(0) expect %s", it)
+		}
+	}
+}
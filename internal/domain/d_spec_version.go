@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrSpecsNotFound        = status.Error(codes.NotFound, "project has no specs effective at that time")
+	ErrInvalidEffectiveFrom = status.Error(codes.InvalidArgument, "specs effective-from must not be in the future")
+)
+
+// RProjectSpecsAt selects the specs version in effect at At. A zero At
+// means now.
+type RProjectSpecsAt struct {
+	ProjectId int64     ``
+	At        time.Time ``
+}
+
+// RProjectSpecsHistory lists the specs versions of a project, newest
+// effective first. Zero From or To leaves that side of the range open.
+type RProjectSpecsHistory struct {
+	ProjectId int64     ``
+	From      time.Time `` // Inclusive
+	To        time.Time `` // Exclusive
+	Skip      int       ``
+	Limit     int       ``
+}
+
+// GetEffectiveFrom returns when the update takes effect, now by default.
+func (rspec *RProjectUpdateSpecs) GetEffectiveFrom(now time.Time) (time.Time, error) {
+	if rspec.EffectiveFrom.IsZero() {
+		return now, nil
+	}
+	if rspec.EffectiveFrom.After(now) {
+		return time.Time{}, ErrInvalidEffectiveFrom
+	}
+	return rspec.EffectiveFrom, nil
+}
+
+// SpecsAt returns the version of versions in effect at t, nil when none
+// is. Versions sharing an effective-from resolve to the latest recorded.
+func SpecsAt(versions []*ProjectSpecsVersion, t time.Time) *ProjectSpecsVersion {
+	var rs *ProjectSpecsVersion
+	for _, it := range versions {
+		if it.EffectiveFrom.After(t) {
+			continue
+		}
+		if nil == rs || it.EffectiveFrom.After(rs.EffectiveFrom) ||
+			(it.EffectiveFrom.Equal(rs.EffectiveFrom) && it.Id > rs.Id) {
+			rs = it
+		}
+	}
+	return rs
+}
+
+// SortSpecsVersions orders versions newest effective first.
+func SortSpecsVersions(versions []*ProjectSpecsVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		if !versions[i].EffectiveFrom.Equal(versions[j].EffectiveFrom) {
+			return versions[i].EffectiveFrom.After(versions[j].EffectiveFrom)
+		}
+		return versions[i].Id > versions[j].Id
+	})
+}
+
+// ToProjectSpecs returns the specs of the version, nil for a nil version.
+func (version *ProjectSpecsVersion) ToProjectSpecs() *ProjectSpecs {
+	if nil == version {
+		return nil
+	}
+	return &ProjectSpecs{
+		ProjectId: version.ProjectId,
+		Specs:     version.Specs,
+		CreatedAt: version.EffectiveFrom,
+		UpdatedAt: version.EffectiveFrom,
+	}
+}
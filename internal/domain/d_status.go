@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrInvalidStatus   = status.Error(codes.InvalidArgument, "unknown project status")
+	ErrStatusReason    = status.Error(codes.InvalidArgument, "a reason is required to reject a project")
+	ErrResubmitByOwner = status.Error(codes.PermissionDenied, "only the owner may resubmit a rejected project")
+	ErrResubmitStatus  = status.Error(codes.FailedPrecondition, "only a rejected project can be resubmitted")
+)
+
+// statusTransition tells what a change of status requires.
+type statusTransition struct {
+	reason   bool // The change must give a reason
+	resubmit bool // The owner may also make the change, through ResubmitProject
+}
+
+// statusTransitions lists the allowed changes of status. Reviewers make
+// every change; a rejected project also goes back to review when its owner
+// resubmits it.
+var statusTransitions = map[ProjectStatus]map[ProjectStatus]statusTransition{
+	ProjectStatusRegister: {
+		ProjectStatusActived: {},
+		ProjectStatusReject:  {reason: true},
+	},
+	ProjectStatusReject: {
+		ProjectStatusRegister: {resubmit: true},
+	},
+	ProjectStatusActived: {
+		ProjectStatusReject: {reason: true},
+	},
+}
+
+func (s ProjectStatus) String() string {
+	switch s {
+	case ProjectStatusReject:
+		return "reject"
+	case ProjectStatusRegister:
+		return "register"
+	case ProjectStatusActived:
+		return "actived"
+	}
+	return fmt.Sprintf("status(%d)", int(s))
+}
+
+func (s ProjectStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// CanChangeStatus tells whether a project may go from one status to another.
+func CanChangeStatus(from, to ProjectStatus) bool {
+	_, ok := statusTransitions[from][to]
+	return ok
+}
+
+// Validate checks the change of current to req.Status. An illegal
+// transition fails with FailedPrecondition. A resubmission must come from
+// the owner of the project.
+func (req *RProjectChangeStatus) Validate(current *Project) error {
+	if !req.Status.Valid() {
+		return ErrInvalidStatus
+	}
+	transition, ok := statusTransitions[current.Status][req.Status]
+	if req.Resubmit && !transition.resubmit {
+		return ErrResubmitStatus
+	}
+	if !ok {
+		return status.Errorf(codes.FailedPrecondition, "project cannot go from %s to %s",
+			current.Status, req.Status)
+	}
+	if transition.reason && strings.TrimSpace(req.Reason) == "" {
+		return ErrStatusReason
+	}
+	if req.Resubmit && !current.IsOwner(req.Actor) {
+		return ErrResubmitByOwner
+	}
+	return nil
+}
+
+// IsOwner tells whether actor, as found by the auth interceptor, is the
+// owner of the project, by id or by ETH address.
+func (project *Project) IsOwner(actor string) bool {
+	if actor == "" {
+		return false
+	}
+	return actor == project.OwnerId || strings.EqualFold(actor, string(project.Owner))
+}
+
+// Review applies an accepted change of status to project. The reason is
+// kept for rejections only.
+func (req *RProjectChangeStatus) Review(project *Project, now time.Time) HistoryDiff {
+	var reason = ""
+	if req.Status == ProjectStatusReject {
+		reason = strings.TrimSpace(req.Reason)
+	}
+	var diff = HistoryDiff{}
+	diff.Set("status", project.Status, req.Status)
+	diff.Set("statusReason", project.StatusReason, reason)
+
+	project.Status = req.Status
+	project.StatusReason = reason
+	project.StatusBy = req.Actor
+	project.StatusAt = &now
+	return diff
+}
@@ -0,0 +1,11 @@
+package domain
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrProjectNotDeleted = status.Error(codes.FailedPrecondition, "project is not deleted")
+	ErrVersionConflict   = status.Error(codes.Aborted, "project was modified by someone else, reload it and retry")
+)
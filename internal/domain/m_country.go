@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+const (
+	TableNameCountry     = "countries"
+	TableNameCountryName = "countries_name"
+)
+
+// CountryInfo is a row of the country registry.
+type CountryInfo struct {
+	Id        string         `json:"id"     gorm:"primaryKey"` // Lower case alpha-2 code
+	Code      string         `json:"code"`                     // ISO 3166-1 alpha-2
+	Alpha3    string         `json:"alpha3"`                   // ISO 3166-1 alpha-3
+	Names     []*CountryName `json:"names"  gorm:"foreignKey:CountryId"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+func (*CountryInfo) TableName() string { return TableNameCountry }
+
+// CountryName overrides or adds the name of a country in a locale.
+type CountryName struct {
+	CountryId string    `json:"countryId" gorm:"primaryKey"`
+	Locale    string    `json:"locale"    gorm:"primaryKey"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (*CountryName) TableName() string { return TableNameCountryName }
@@ -12,9 +12,30 @@ type ProjectDocument struct {
 	Name         string         ``
 	Url          string         ``
 	DocumentType string         ``
+	Checksum     string         `` // Hex SHA-256, empty for documents added by url
+	Size         int64          `` // Bytes
+	Version      int64          `gorm:"not null;default:1"`
 	CreatedAt    time.Time      `gorm:"autoCreateTime:true"`
 	UpdatedAt    time.Time      `gorm:"autoUpdateTime:true"`
 	DeletedAt    gorm.DeletedAt ``
 } //@name ProjectDescription
 
 func (*ProjectDocument) TableName() string { return TableNameProjectDocument }
+
+// ProjectDocumentVersion keeps one version of a document. Versions are
+// never updated or deleted.
+type ProjectDocumentVersion struct {
+	Id           int64     `json:"id"           gorm:"primaryKey"`
+	DocumentId   int64     `json:"documentId"`
+	ProjectId    int64     `json:"projectId"`
+	Version      int64     `json:"version"`
+	Name         string    `json:"name"`
+	Url          string    `json:"url"`
+	DocumentType string    `json:"documentType"`
+	Checksum     string    `json:"checksum"`
+	Size         int64     `json:"size"`
+	UploadedBy   string    `json:"uploadedBy"` // Actor of the change
+	CreatedAt    time.Time `json:"createdAt"`
+} //@name ProjectDocumentVersion
+
+func (*ProjectDocumentVersion) TableName() string { return TableNameProjectDocumentVersion }
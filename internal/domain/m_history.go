@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ProjectHistory is an append-only record of one change to a project.
+type ProjectHistory struct {
+	Id        int64       `json:"id"        gorm:"primaryKey"`
+	ProjectId int64       `json:"projectId" gorm:"index"`
+	Actor     string      `json:"actor"`  // Caller from the auth interceptor
+	Action    string      `json:"action"` // RPC name
+	Diff      HistoryDiff `json:"diff"      gorm:"type:jsonb"`
+	CreatedAt time.Time   `json:"createdAt"`
+} //@name ProjectHistory
+
+func (*ProjectHistory) TableName() string { return TableNameProjectHistory }
+
+type FieldChange struct {
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// HistoryDiff maps a field name to its change. Nested values use dotted
+// names, e.g. "descs.vi.name" or "specs.capacity".
+type HistoryDiff map[string]*FieldChange //@name HistoryDiff
+
+// Set records field when from and to differ.
+func (m HistoryDiff) Set(field string, from, to interface{}) {
+	if reflect.DeepEqual(from, to) {
+		return
+	}
+	m[field] = &FieldChange{From: from, To: to}
+}
+
+func (m *HistoryDiff) Scan(value interface{}) error {
+	switch vt := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(vt), m)
+	case []byte:
+		return json.Unmarshal(vt, m)
+	}
+	return errors.New("scan value type for HistoryDiff invalid")
+}
+
+func (m HistoryDiff) Value() (driver.Value, error) {
+	if nil == m {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Dcarbon/go-shared/dmodels"
+	"gorm.io/gorm"
 )
 
 const (
@@ -15,6 +16,10 @@ const (
 	TableNameProjectDocument = "projects_document"
 	TableNameProjectSpecs    = "projects_specs"
 	TableNameProjectImage    = "projects_image"
+	TableNameProjectHistory  = "projects_history"
+
+	TableNameProjectSpecsVersion    = "projects_specs_version"
+	TableNameProjectDocumentVersion = "projects_document_version"
 )
 
 type ProjectStatus int
@@ -30,6 +35,9 @@ type Project struct {
 	Owner        dmodels.EthAddress `json:"owner"                     gorm:"index"`                      // ETH address
 	OwnerId      string             `json:"owner"                     gorm:"index"`                      // ETH address
 	Status       ProjectStatus      `json:"status"                    `                                  //
+	StatusReason string             `json:"statusReason,omitempty"    `                                  // Reason of the last rejection
+	StatusBy     string             `json:"statusBy,omitempty"        `                                  // Reviewer of the last status change
+	StatusAt     *time.Time         `json:"statusAt,omitempty"        `                                  //
 	LocationName string             `json:"locationName,omitempty"    `                                  //
 	Location     *dmodels.Coord     `json:"location"                  gorm:"type:geometry(POINT, 4326)"` //
 	Specs        *ProjectSpecs      `json:"specs,omitempty"           gorm:"foreignKey:ProjectId"`       //
@@ -37,6 +45,7 @@ type Project struct {
 	Descs        []*ProjectDesc     `json:"descs,omitempty"           gorm:"foreignKey:ProjectId"`       //
 	Images       []*ProjectImage    `json:"images,omitempty"          gorm:"foreignKey:ProjectId"`       //
 	Thumbnail    string             `json:"thumbnail"`
+	Thumbnails   ImageVariants      `json:"thumbnails,omitempty"      gorm:"column:thumbnail_variants;type:json"`
 	CreatedAt    time.Time          `json:"createdAt"                 ` //
 	UpdatedAt    time.Time          `json:"updatedAt"                 ` //
 	Type         int64              `json:"type" gorm:"column:type"`
@@ -45,37 +54,64 @@ type Project struct {
 	Country      *Country           `json:"country" gorm:"-"`
 	Iframe       string             `json:"iframe" gorm:"iframe"`
 	OwnerAddress string             `json:"owner_address" gorm:"owner_address"`
+	Version      int64              `json:"version"                   gorm:"not null;default:1"`
+	Highlight    string             `json:"highlight,omitempty"       gorm:"-"` // Search snippet
+	Distance     float64            `json:"distance,omitempty"        gorm:"-"` // Metres from RProjectGetList.Near
+	Language     string             `json:"language,omitempty"        gorm:"-"` // Locale of Descs after Localize
+	TypeName     string             `json:"typeName,omitempty"        gorm:"-"`
+	DeletedAt    gorm.DeletedAt     `json:"deletedAt,omitempty"       gorm:"index"`
 } //@name Project
 
 func (*Project) TableName() string { return TableNameProject }
 
 type ProjectDesc struct {
-	Id        int64     `gorm:"primaryKey"`
-	ProjectId int64     `gorm:"index:idx_project_desc_lang,unique,priority:1"` //
-	Language  string    `gorm:"index:idx_project_desc_lang,unique,priority:2"` //
-	Name      string    ``
-	Desc      string    ``
-	CreatedAt time.Time ``
-	UpdatedAt time.Time ``
+	Id        int64          `gorm:"primaryKey"`
+	ProjectId int64          `gorm:"index:idx_project_desc_lang,unique,priority:1"` //
+	Language  string         `gorm:"index:idx_project_desc_lang,unique,priority:2"` //
+	Name      string         ``
+	Desc      string         ``
+	CreatedAt time.Time      ``
+	UpdatedAt time.Time      ``
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 } //@name ProjectDescription
 
 func (*ProjectDesc) TableName() string { return TableNameProjectDesc }
 
 type ProjectSpecs struct {
-	Id        int64     `json:"id"              gorm:"primaryKey"`
-	ProjectId int64     `json:"projectId"       gorm:"unique"`
-	Specs     MapSFloat `json:"specs"           gorm:"type:json"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	Id        int64          `json:"id"              gorm:"primaryKey"`
+	ProjectId int64          `json:"projectId"       gorm:"unique"`
+	Specs     MapSFloat      `json:"specs"           gorm:"type:json"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 } //@name ProjectSpec
 
 func (*ProjectSpecs) TableName() string { return TableNameProjectSpecs }
 
+// ProjectSpecsVersion is one value of the project specs together with the
+// time it became effective. ProjectSpecs mirrors the latest version.
+type ProjectSpecsVersion struct {
+	Id            int64     `json:"id"            gorm:"primaryKey"`
+	ProjectId     int64     `json:"projectId"`
+	Specs         MapSFloat `json:"specs"         gorm:"type:json"`
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+	Actor         string    `json:"actor"`
+	CreatedAt     time.Time `json:"createdAt"`
+} //@name ProjectSpecsVersion
+
+func (*ProjectSpecsVersion) TableName() string { return TableNameProjectSpecsVersion }
+
 type ProjectImage struct {
-	Id        int64     `json:"id"`        //
-	ProjectId int64     `json:"projectId"` //
-	Image     string    `json:"image"`     // Image path
-	CreatedAt time.Time `json:"createdAt"`
+	Id        int64          `json:"id"`        //
+	ProjectId int64          `json:"projectId"` //
+	Image     string         `json:"image"`     // Image path
+	Caption   string         `json:"caption"`   //
+	Position  int            `json:"position"`  // Gallery order, ascending
+	Checksum  string         `json:"checksum"`  // Hex SHA-256, empty for images added by path
+	Size      int64          `json:"size"`      // Bytes
+	Variants  ImageVariants  `json:"variants"  gorm:"type:json"`
+	CreatedAt time.Time      `json:"createdAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 func (*ProjectImage) TableName() string { return TableNameProjectImage }
@@ -95,15 +131,35 @@ func (m *MapSFloat) Scan(value interface{}) error {
 	return errors.New("scan value type for MapSFloat invalid")
 }
 
-type Country struct {
-	Id   string `json:"id"  `
-	Name string `json:"name"`
-	Code string `json:"code"`
+// ImageVariants maps an imaging.Variant name to the storage path of the
+// resized image.
+type ImageVariants map[string]string //@name ImageVariants
+
+func (m *ImageVariants) Scan(value interface{}) error {
+	switch vt := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(vt), m)
+	case []byte:
+		return json.Unmarshal(vt, m)
+	}
+	return errors.New("scan value type for ImageVariants invalid")
 }
-type Language struct {
-	Locale      string `json:"locale"`
-	Name        string `json:"name"`
-	CountryCode string `json:"country_code"`
+
+func (m ImageVariants) Value() (driver.Value, error) {
+	if nil == m {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+type Country struct {
+	Id     string `json:"id"  `
+	Name   string `json:"name"`
+	Code   string `json:"code"`
+	Alpha3 string `json:"alpha3"`
+	Locale string `json:"locale"` // Locale of Name
 }
 
 func (m MapSFloat) Value() (driver.Value, error) {
@@ -0,0 +1,248 @@
+package repo
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// countryLookupLocales are the CLDR locales LookupCountryId matches names
+// in, besides the names stored in the registry.
+var countryLookupLocales = []string{"en", "vi"}
+
+// CountryImpl is the cached domain.ICountry. The registry is read once and
+// kept in memory; locale changes write through to the database. Without a
+// database it serves domain.ISOCountries and keeps locales in memory only.
+type CountryImpl struct {
+	db        *gorm.DB
+	mut       sync.RWMutex
+	countries map[string]*domain.CountryInfo
+	ordered   []*domain.CountryInfo
+}
+
+func NewCountryImpl(db *gorm.DB) (*CountryImpl, error) {
+	var countries = make([]*domain.CountryInfo, 0)
+	var err = db.Preload("Names").Order("id").Find(&countries).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Country", err)
+	}
+	var cImpl = &CountryImpl{db: db}
+	cImpl.load(countries)
+	return cImpl, nil
+}
+
+func NewMemoryCountryImpl() *CountryImpl {
+	var cImpl = &CountryImpl{}
+	cImpl.load(domain.ISOCountries())
+	return cImpl
+}
+
+func (cImpl *CountryImpl) load(countries []*domain.CountryInfo) {
+	cImpl.countries = make(map[string]*domain.CountryInfo, len(countries))
+	for _, it := range countries {
+		cImpl.countries[it.Id] = it
+	}
+	cImpl.ordered = countries
+}
+
+func (cImpl *CountryImpl) GetCountry(id string, locales ...string) (*domain.Country, error) {
+	cImpl.mut.RLock()
+	defer cImpl.mut.RUnlock()
+
+	info, ok := cImpl.countries[domain.NormalizeCountryId(id)]
+	if !ok {
+		return nil, domain.ErrCountryNotFound
+	}
+	return localizeCountry(info, locales), nil
+}
+
+// ListCountries returns every country ordered by name.
+func (cImpl *CountryImpl) ListCountries(locales ...string) ([]*domain.Country, error) {
+	cImpl.mut.RLock()
+	defer cImpl.mut.RUnlock()
+
+	var rs = make([]*domain.Country, len(cImpl.ordered))
+	for i, it := range cImpl.ordered {
+		rs[i] = localizeCountry(it, locales)
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].Name < rs[j].Name
+	})
+	return rs, nil
+}
+
+func (cImpl *CountryImpl) UpsertCountryLocale(req *domain.RCountryLocaleUpsert,
+) (*domain.CountryName, error) {
+	locale, err := domain.NormalizeLocale(req.Locale)
+	if nil != err {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, domain.ErrInvalidName
+	}
+
+	cImpl.mut.Lock()
+	defer cImpl.mut.Unlock()
+
+	info, ok := cImpl.countries[domain.NormalizeCountryId(req.CountryId)]
+	if !ok {
+		return nil, domain.ErrCountryNotFound
+	}
+
+	var now = time.Now()
+	var name = &domain.CountryName{
+		CountryId: info.Id,
+		Locale:    locale,
+		Name:      req.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if nil != cImpl.db {
+		err = cImpl.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "country_id"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "updated_at"}),
+		}).Create(name).Error
+		if nil != err {
+			return nil, dmodels.ParsePostgresError("Country", err)
+		}
+	}
+
+	var names = make([]*domain.CountryName, 0, len(info.Names)+1)
+	for _, it := range info.Names {
+		if it.Locale == locale {
+			name.CreatedAt = it.CreatedAt
+			continue
+		}
+		names = append(names, it)
+	}
+	info.Names = append(names, name)
+	return name, nil
+}
+
+func (cImpl *CountryImpl) DeleteCountryLocale(req *domain.RCountryLocaleDelete) error {
+	locale, err := domain.NormalizeLocale(req.Locale)
+	if nil != err {
+		return err
+	}
+
+	cImpl.mut.Lock()
+	defer cImpl.mut.Unlock()
+
+	info, ok := cImpl.countries[domain.NormalizeCountryId(req.CountryId)]
+	if !ok {
+		return domain.ErrCountryNotFound
+	}
+	if nil != cImpl.db {
+		err = cImpl.db.Where("country_id = ? AND locale = ?", info.Id, locale).
+			Delete(&domain.CountryName{}).Error
+		if nil != err {
+			return dmodels.ParsePostgresError("Country", err)
+		}
+	}
+
+	var names = make([]*domain.CountryName, 0, len(info.Names))
+	for _, it := range info.Names {
+		if it.Locale != locale {
+			names = append(names, it)
+		}
+	}
+	info.Names = names
+	return nil
+}
+
+// localizeCountry names info in the first of locales with a name in the
+// registry or in CLDR, then in domain.DefaultCountryLocale.
+func localizeCountry(info *domain.CountryInfo, locales []string) *domain.Country {
+	var rs = &domain.Country{
+		Id:     info.Id,
+		Code:   info.Code,
+		Alpha3: info.Alpha3,
+	}
+	var chain = make([]string, 0, len(locales)+1)
+	chain = append(append(chain, locales...), domain.DefaultCountryLocale)
+	for _, it := range chain {
+		if normalized, err := domain.NormalizeLocale(it); nil == err {
+			it = normalized
+		}
+		if name := countryName(info, it); name != "" {
+			rs.Name = name
+			rs.Locale = it
+			return rs
+		}
+	}
+	rs.Name = info.Code
+	return rs
+}
+
+func countryName(info *domain.CountryInfo, locale string) string {
+	for _, it := range info.Names {
+		if it.Locale == locale {
+			return it.Name
+		}
+	}
+	return domain.CLDRCountryName(info.Code, locale)
+}
+
+func (cImpl *CountryImpl) ResolveCountryId(code string) (string, error) {
+	var value = strings.TrimSpace(code)
+	if value == "" {
+		return "", nil
+	}
+
+	cImpl.mut.RLock()
+	defer cImpl.mut.RUnlock()
+
+	if info, ok := cImpl.countries[domain.NormalizeCountryId(value)]; ok {
+		return info.Id, nil
+	}
+	if len(value) == 3 {
+		for _, it := range cImpl.ordered {
+			if strings.EqualFold(it.Alpha3, value) {
+				return it.Id, nil
+			}
+		}
+	}
+	return "", domain.ErrInvalidCountry
+}
+
+func (cImpl *CountryImpl) LookupCountryId(value string) (string, bool) {
+	if id, err := cImpl.ResolveCountryId(value); nil == err {
+		return id, id != ""
+	}
+
+	cImpl.mut.RLock()
+	defer cImpl.mut.RUnlock()
+
+	var folded = domain.FoldText(strings.TrimSpace(value))
+	for _, it := range cImpl.ordered {
+		for _, name := range it.Names {
+			if domain.FoldText(name.Name) == folded {
+				return it.Id, true
+			}
+		}
+		for _, locale := range countryLookupLocales {
+			if name := domain.CLDRCountryName(it.Code, locale); name != "" &&
+				domain.FoldText(name) == folded {
+				return it.Id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// localizeProjects names the country of each project in locales. With
+// locales, it also keeps one desc per project, see domain.Project.Localize.
+func localizeProjects(iCountry domain.ICountry, data []*domain.Project, locales []string) {
+	for _, it := range data {
+		if len(locales) > 0 {
+			it.Localize(locales)
+		}
+		it.Country, _ = iCountry.GetCountry(it.CountryId, locales...)
+	}
+}
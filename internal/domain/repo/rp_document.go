@@ -1,15 +1,18 @@
 package repo
 
 import (
+	"strings"
 	"time"
 
 	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 func (pImpl *ProjectImpl) UpsertDocument(req *domain.RProjectDocumentUpsert) ([]*domain.ProjectDocument, error) {
 	documents := []*domain.ProjectDocument{}
+	ids := []int64{}
 	for _, val := range req.Document {
 		documents = append(documents,
 			&domain.ProjectDocument{
@@ -18,15 +21,67 @@ func (pImpl *ProjectImpl) UpsertDocument(req *domain.RProjectDocumentUpsert) ([]
 				DocumentType: val.DocumentType,
 				ProjectId:    val.ProjectId,
 				Id:           val.Id,
+				Checksum:     val.Checksum,
+				Size:         val.Size,
 				CreatedAt:    time.Now(),
 				UpdatedAt:    time.Now()})
+		if val.Id != 0 {
+			ids = append(ids, val.Id)
+		}
 	}
 
-	if err := pImpl.tblDocument().Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "id"}}, // key column
-		DoUpdates: clause.AssignmentColumns([]string{"url", "document_type", "updated_at", "name"}),
-	}).Create(&documents).Error; err != nil {
-		return nil, err
+	err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+		var current = []*domain.ProjectDocument{}
+		if len(ids) > 0 {
+			if err := tx.Table(domain.TableNameProjectDocument).
+				Where("id IN ?", ids).Find(&current).Error; nil != err {
+				return err
+			}
+		}
+
+		// A changed file becomes a new version, an unchanged one keeps its
+		// version number.
+		var before = make(map[int64]*domain.ProjectDocument, len(current))
+		for _, it := range current {
+			before[it.Id] = it
+		}
+		var changed = make([]*domain.ProjectDocument, 0, len(documents))
+		for _, doc := range documents {
+			var old, ok = before[doc.Id]
+			switch {
+			case !ok:
+				doc.Version = 1
+			case doc.SameFile(old):
+				doc.Version = old.Version
+				continue
+			default:
+				doc.Version = old.Version + 1
+			}
+			changed = append(changed, doc)
+		}
+
+		if err := tx.Table(domain.TableNameProjectDocument).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}}, // key column
+			DoUpdates: clause.AssignmentColumns([]string{"url", "document_type", "updated_at", "name", "checksum", "size", "version"}),
+		}).Create(&documents).Error; err != nil {
+			return err
+		}
+		for _, doc := range changed {
+			if err := tx.Table(domain.TableNameProjectDocumentVersion).
+				Create(doc.NewVersion(req.Actor)).Error; nil != err {
+				return err
+			}
+		}
+
+		for projectId, diff := range documentDiffs(current, documents) {
+			if err := addHistory(tx, projectId, req.Audit, diff); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Upsert Document ", err)
 	}
 	return documents, nil
 }
@@ -38,20 +93,175 @@ func (pImpl *ProjectImpl) DeleteDocument(req *domain.RProjectDocumentDelete) err
 	}
 	return nil
 }
+
+// documentOrders are the ORDER BY clauses of the document sorts.
+var documentOrders = map[domain.DocumentSort]string{
+	domain.DocumentSortCreatedDesc: "created_at DESC, id DESC",
+	domain.DocumentSortCreatedAsc:  "created_at, id",
+	domain.DocumentSortUpdatedDesc: "updated_at DESC, id DESC",
+	domain.DocumentSortUpdatedAsc:  "updated_at, id",
+	domain.DocumentSortNameAsc:     "name, id",
+	domain.DocumentSortNameDesc:    "name DESC, id DESC",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (pImpl *ProjectImpl) ListDocument(req *domain.RProjectDocumentList) ([]*domain.ProjectDocument, int64, error) {
+	if err := req.Validate(); nil != err {
+		return nil, 0, err
+	}
+
 	documents := []*domain.ProjectDocument{}
 	var count int64
-	var tbl = pImpl.tblDocument().Where("deleted_at IS NULL")
+	var tbl = filterDocuments(pImpl.tblDocument().Where("deleted_at IS NULL"), req)
+	tbl, err := pageQuery(tbl, domain.TableNameProjectDocument, req.Skip, req.Limit,
+		req.Cursor, req.SkipTotal, &count)
+	if nil != err {
+		return nil, 0, err
+	}
+	err = tbl.Order(documentOrders[req.Sort]).Find(&documents).Error
+	if err != nil {
+		return nil, 0, dmodels.ParsePostgresError("List Document", err)
+	}
+	return documents, count, nil
+}
+
+func filterDocuments(tbl *gorm.DB, req *domain.RProjectDocumentList) *gorm.DB {
 	if len(req.Ids) > 0 {
-		tbl = tbl.Where("id = ?", req.Ids)
+		tbl = tbl.Where("id IN ?", req.Ids)
+	}
+	if len(req.ProjectIds) > 0 {
+		tbl = tbl.Where("project_id IN ?", req.ProjectIds)
+	}
+	if len(req.DocumentTypes) > 0 {
+		tbl = tbl.Where("document_type IN ?", req.DocumentTypes)
 	}
+	if req.Name != "" {
+		tbl = tbl.Where("name ILIKE ?", "%"+likeEscaper.Replace(req.Name)+"%")
+	}
+	if !req.CreatedFrom.IsZero() {
+		tbl = tbl.Where("created_at >= ?", req.CreatedFrom)
+	}
+	if !req.CreatedTo.IsZero() {
+		tbl = tbl.Where("created_at <= ?", req.CreatedTo)
+	}
+	if !req.UpdatedFrom.IsZero() {
+		tbl = tbl.Where("updated_at >= ?", req.UpdatedFrom)
+	}
+	if !req.UpdatedTo.IsZero() {
+		tbl = tbl.Where("updated_at <= ?", req.UpdatedTo)
+	}
+	return tbl
+}
+
+func (pImpl *ProjectImpl) ListDocumentVersions(req *domain.RProjectDocumentVersionList,
+) ([]*domain.ProjectDocumentVersion, int64, error) {
+	var count int64
+	var data = make([]*domain.ProjectDocumentVersion, 0)
+	var tbl = pImpl.db.Table(domain.TableNameProjectDocumentVersion).
+		Where("document_id = ?", req.DocumentId)
+
 	tbl.Count(&count).Offset(req.Skip)
 	if req.Limit > 0 {
 		tbl = tbl.Limit(req.Limit)
 	}
-	err := tbl.Order("created_at DESC").Find(&documents).Error
-	if err != nil {
-		return nil, 0, dmodels.ParsePostgresError("List Document", err)
+	err := tbl.Order("version DESC").Find(&data).Error
+	if nil != err {
+		return nil, 0, dmodels.ParsePostgresError("Document version", err)
 	}
-	return documents, count, nil
+	return data, count, nil
+}
+
+func (pImpl *ProjectImpl) RestoreDocumentVersion(req *domain.RProjectDocumentRestore,
+) (*domain.ProjectDocument, error) {
+	var doc = &domain.ProjectDocument{}
+	err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+		var docs = make([]*domain.ProjectDocument, 0, 1)
+		if err := tx.Table(domain.TableNameProjectDocument).
+			Where("id = ? AND deleted_at IS NULL", req.DocumentId).
+			Find(&docs).Error; nil != err {
+			return err
+		}
+		if len(docs) == 0 {
+			return domain.ErrDocumentNotFound
+		}
+		var versions = make([]*domain.ProjectDocumentVersion, 0, 1)
+		if err := tx.Table(domain.TableNameProjectDocumentVersion).
+			Where("document_id = ? AND version = ?", req.DocumentId, req.Version).
+			Find(&versions).Error; nil != err {
+			return err
+		}
+		if len(versions) == 0 {
+			return domain.ErrDocumentVersionNotFound
+		}
+
+		var current = *docs[0]
+		doc = docs[0]
+		doc.Restore(versions[0])
+		if err := tx.Table(domain.TableNameProjectDocument).
+			Where("id = ?", doc.Id).
+			Select("name", "url", "document_type", "checksum", "size", "version", "updated_at").
+			Updates(doc).Error; nil != err {
+			return err
+		}
+		if err := tx.Table(domain.TableNameProjectDocumentVersion).
+			Create(doc.NewVersion(req.Actor)).Error; nil != err {
+			return err
+		}
+		return addHistory(tx, doc.ProjectId, req.Audit, doc.Diff(&current))
+	})
+	if nil != err {
+		return nil, parseError("Restore document version", err)
+	}
+	return doc, nil
+}
+
+func (pImpl *ProjectImpl) GetDocumentCompleteness(req *domain.RProjectDocumentCompleteness,
+) (*domain.DocumentCompleteness, error) {
+	project, err := getProject(pImpl.db, req.ProjectId)
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project", err)
+	}
+	completeness, err := documentCompleteness(pImpl.db, project)
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Document completeness", err)
+	}
+	return completeness, nil
+}
+
+type documentCount struct {
+	ProjectId int64
+	Count     int64
+}
+
+// CountDocuments returns the number of documents of each project, deleted
+// ones aside. Projects without document are missing from the map.
+func (pImpl *ProjectImpl) CountDocuments(projectIds []int64) (map[int64]int64, error) {
+	var rows = make([]*documentCount, 0)
+	var rs = make(map[int64]int64, len(projectIds))
+	if len(projectIds) == 0 {
+		return rs, nil
+	}
+	err := pImpl.tblDocument().
+		Select("project_id, count(*) AS count").
+		Where("project_id IN ? AND deleted_at IS NULL", projectIds).
+		Group("project_id").Scan(&rows).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Count document", err)
+	}
+	for _, it := range rows {
+		rs[it.ProjectId] = it.Count
+	}
+	return rs, nil
+}
+
+func documentCompleteness(tx *gorm.DB, project *domain.Project,
+) (*domain.DocumentCompleteness, error) {
+	var documents = make([]*domain.ProjectDocument, 0)
+	if err := tx.Table(domain.TableNameProjectDocument).
+		Where("project_id = ? AND deleted_at IS NULL", project.Id).
+		Find(&documents).Error; nil != err {
+		return nil, err
+	}
+	return domain.CheckDocuments(project, documents), nil
 }
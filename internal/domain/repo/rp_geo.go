@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"sort"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const geoPoint = "ST_SetSRID(ST_MakePoint(?, ?), 4326)"
+
+// geoFilter applies the radius, bounding box and polygon filters. The
+// expressions match idx_projects_location and idx_projects_location_geog.
+func geoFilter(tbl *gorm.DB, filter *domain.RProjectGetList) *gorm.DB {
+	if nil != filter.Near && filter.RadiusM > 0 {
+		tbl = tbl.Where("ST_DWithin(projects.location::geography, "+geoPoint+"::geography, ?)",
+			filter.Near.Lng, filter.Near.Lat, filter.RadiusM)
+	}
+	if nil != filter.Bounds {
+		tbl = tbl.Where("projects.location && ST_MakeEnvelope(?, ?, ?, ?, 4326)",
+			filter.Bounds.MinLng, filter.Bounds.MinLat, filter.Bounds.MaxLng, filter.Bounds.MaxLat)
+	}
+	if filter.Polygon != "" {
+		tbl = tbl.Where("ST_Intersects(projects.location, ST_SetSRID(ST_GeomFromGeoJSON(?), 4326))",
+			filter.Polygon)
+	}
+	return tbl
+}
+
+// GetNearby uses the KNN operator on idx_projects_location_geog, then
+// orders the neighbours by their distance on the spheroid.
+func (pImpl *ProjectImpl) GetNearby(req *domain.RProjectGetNearby,
+) ([]*domain.Project, error) {
+	if nil == req.Near {
+		return nil, domain.ErrNearbyCenter
+	}
+
+	var data = make([]*domain.Project, 0)
+	var tbl = scopeDeleted(pImpl.tblProject(), domain.TableNameProject, false)
+	var err = filterProjects(tbl, req.Filter()).
+		Where("projects.location IS NOT NULL").
+		Preload("Descs", func(tx *gorm.DB) *gorm.DB {
+			return scopeDeleted(tx, domain.TableNameProjectDesc, false)
+		}).
+		Preload("Specs", func(tx *gorm.DB) *gorm.DB {
+			return scopeDeleted(tx, domain.TableNameProjectSpecs, false)
+		}).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "projects.location::geography <-> " + geoPoint + "::geography",
+			Vars: []interface{}{req.Near.Lng, req.Near.Lat},
+		}}).
+		Limit(req.GetLimit()).
+		Find(&data).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project", err)
+	}
+
+	localizeProjects(pImpl.iCountry, data, req.Locales)
+	if err := pImpl.distance(data, req.Near); nil != err {
+		return nil, dmodels.ParsePostgresError("Project", err)
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].Distance < data[j].Distance
+	})
+	return data, nil
+}
+
+// distance fills Project.Distance with the geodesic distance in metres
+// from near.
+func (pImpl *ProjectImpl) distance(data []*domain.Project, near *dmodels.Coord) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var ids = make([]int64, len(data))
+	for i, it := range data {
+		ids[i] = it.Id
+	}
+
+	var rows = make([]*struct {
+		Id       int64
+		Distance float64
+	}, 0)
+	var err = pImpl.db.Raw(`
+		SELECT id, ST_Distance(location::geography, `+geoPoint+`::geography) AS distance
+		FROM `+domain.TableNameProject+`
+		WHERE id IN ? AND location IS NOT NULL`, near.Lng, near.Lat, ids).
+		Scan(&rows).Error
+	if nil != err {
+		return err
+	}
+
+	var byId = make(map[int64]float64, len(rows))
+	for _, row := range rows {
+		byId[row.Id] = row.Distance
+	}
+	for _, it := range data {
+		it.Distance = byId[it.Id]
+	}
+	return nil
+}
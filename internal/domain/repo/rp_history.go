@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+)
+
+func (pImpl *ProjectImpl) GetHistory(req *domain.RProjectHistoryList,
+) ([]*domain.ProjectHistory, int64, error) {
+	var count int64
+	var data = make([]*domain.ProjectHistory, 0)
+	var tbl = pImpl.tblHistory().Where("project_id = ?", req.ProjectId)
+
+	tbl.Count(&count).Offset(req.Skip)
+	if req.Limit > 0 {
+		tbl = tbl.Limit(req.Limit)
+	}
+	err := tbl.Order("created_at DESC, id DESC").Find(&data).Error
+	if nil != err {
+		return nil, 0, dmodels.ParsePostgresError("Project history", err)
+	}
+	return data, count, nil
+}
+
+func (pImpl *ProjectImpl) tblHistory() *gorm.DB {
+	return pImpl.db.Table(domain.TableNameProjectHistory)
+}
+
+// addHistory appends a history entry inside tx. Nothing is written when
+// diff is empty.
+func addHistory(tx *gorm.DB, projectId int64, audit domain.Audit, diff domain.HistoryDiff,
+) error {
+	if len(diff) == 0 {
+		return nil
+	}
+	return tx.Table(domain.TableNameProjectHistory).Create(newHistory(projectId, audit, diff)).Error
+}
+
+func newHistory(projectId int64, audit domain.Audit, diff domain.HistoryDiff,
+) *domain.ProjectHistory {
+	return &domain.ProjectHistory{
+		ProjectId: projectId,
+		Actor:     audit.Actor,
+		Action:    audit.Action,
+		Diff:      diff,
+		CreatedAt: time.Now(),
+	}
+}
+
+// documentDiffs groups the changes made by an upsert per project. current
+// holds the rows as they were before the upsert.
+func documentDiffs(current, documents []*domain.ProjectDocument,
+) map[int64]domain.HistoryDiff {
+	var before = make(map[int64]*domain.ProjectDocument, len(current))
+	for _, it := range current {
+		before[it.Id] = it
+	}
+
+	var diffs = make(map[int64]domain.HistoryDiff)
+	for _, doc := range documents {
+		var diff = diffs[doc.ProjectId]
+		if nil == diff {
+			diff = domain.HistoryDiff{}
+			diffs[doc.ProjectId] = diff
+		}
+		for field, change := range doc.Diff(before[doc.Id]) {
+			diff[field] = change
+		}
+	}
+	return diffs
+}
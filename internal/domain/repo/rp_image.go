@@ -0,0 +1,211 @@
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+)
+
+func (pImpl *ProjectImpl) ListImages(req *domain.RProjectImageList,
+) ([]*domain.ProjectImage, error) {
+	var data = make([]*domain.ProjectImage, 0)
+	var err = scopeDeleted(pImpl.tblImage(), domain.TableNameProjectImage, req.IncludeDeleted).
+		Where("project_id = ?", req.ProjectId).
+		Order("position, id").
+		Find(&data).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project image", err)
+	}
+	return data, nil
+}
+
+// DeleteImage soft-deletes a gallery image. When it is the thumbnail, the
+// first remaining image takes its place, or the thumbnail is cleared.
+func (pImpl *ProjectImpl) DeleteImage(req *domain.RProjectImageDelete) error {
+	err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+		project, err := getProject(tx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+		image, err := getImage(tx, req.ProjectId, req.ImageId)
+		if nil != err {
+			return err
+		}
+		if err := bumpVersion(tx, project, 0); nil != err {
+			return err
+		}
+		if err := tx.Table(domain.TableNameProjectImage).
+			Where("id = ?", image.Id).
+			Update("deleted_at", time.Now()).Error; nil != err {
+			return err
+		}
+
+		var diff = domain.HistoryDiff{}
+		diff.Set(fmt.Sprintf("images.%d", image.Id), image.Image, nil)
+		if project.Thumbnail == image.Image {
+			var next = &domain.ProjectImage{}
+			var images = make([]*domain.ProjectImage, 0, 1)
+			if err := tx.Table(domain.TableNameProjectImage).
+				Where("project_id = ? AND deleted_at IS NULL", req.ProjectId).
+				Order("position, id").Limit(1).
+				Find(&images).Error; nil != err {
+				return err
+			}
+			if len(images) > 0 {
+				next = images[0]
+			}
+			if err := tx.Table(domain.TableNameProject).
+				Where("id = ?", req.ProjectId).
+				Updates(map[string]interface{}{
+					"thumbnail":          next.Image,
+					"thumbnail_variants": next.Variants,
+				}).Error; nil != err {
+				return err
+			}
+			diff.Set("thumbnail", project.Thumbnail, next.Image)
+		}
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	if nil != err {
+		return parseError("Delete image", err)
+	}
+	return nil
+}
+
+func (pImpl *ProjectImpl) ReorderImages(req *domain.RProjectImageReorder,
+) ([]*domain.ProjectImage, error) {
+	var images = make([]*domain.ProjectImage, 0)
+	err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+		project, err := getProject(tx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+		if err := tx.Table(domain.TableNameProjectImage).
+			Where("project_id = ? AND deleted_at IS NULL", req.ProjectId).
+			Order("position, id").
+			Find(&images).Error; nil != err {
+			return err
+		}
+		if err := req.Validate(images); nil != err {
+			return err
+		}
+		if err := bumpVersion(tx, project, 0); nil != err {
+			return err
+		}
+
+		var before = imageIds(images)
+		var byId = make(map[int64]*domain.ProjectImage, len(images))
+		for _, it := range images {
+			byId[it.Id] = it
+		}
+		for i, id := range req.ImageIds {
+			if err := tx.Table(domain.TableNameProjectImage).
+				Where("id = ?", id).
+				Update("position", i+1).Error; nil != err {
+				return err
+			}
+			byId[id].Position = i + 1
+		}
+		domain.SortImages(images)
+
+		var diff = domain.HistoryDiff{}
+		diff.Set("images.order", before, imageIds(images))
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	if nil != err {
+		return nil, parseError("Reorder images", err)
+	}
+	return images, nil
+}
+
+func (pImpl *ProjectImpl) UpdateImageCaption(req *domain.RProjectImageCaption,
+) (*domain.ProjectImage, error) {
+	var image *domain.ProjectImage
+	err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+		project, err := getProject(tx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+		image, err = getImage(tx, req.ProjectId, req.ImageId)
+		if nil != err {
+			return err
+		}
+		if err := bumpVersion(tx, project, 0); nil != err {
+			return err
+		}
+		if err := tx.Table(domain.TableNameProjectImage).
+			Where("id = ?", image.Id).
+			Update("caption", req.Caption).Error; nil != err {
+			return err
+		}
+
+		var diff = domain.HistoryDiff{}
+		diff.Set(fmt.Sprintf("images.%d.caption", image.Id), image.Caption, req.Caption)
+		image.Caption = req.Caption
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	if nil != err {
+		return nil, parseError("Update image caption", err)
+	}
+	return image, nil
+}
+
+func (pImpl *ProjectImpl) SetThumbnail(req *domain.RProjectSetThumbnail,
+) (*domain.ProjectImage, error) {
+	var image *domain.ProjectImage
+	err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+		project, err := getProject(tx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+		image, err = getImage(tx, req.ProjectId, req.ImageId)
+		if nil != err {
+			return err
+		}
+		if err := bumpVersion(tx, project, 0); nil != err {
+			return err
+		}
+		if err := tx.Table(domain.TableNameProject).
+			Where("id = ?", req.ProjectId).
+			Updates(map[string]interface{}{
+				"thumbnail":          image.Image,
+				"thumbnail_variants": image.Variants,
+			}).Error; nil != err {
+			return err
+		}
+
+		var diff = domain.HistoryDiff{}
+		diff.Set("thumbnail", project.Thumbnail, image.Image)
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	if nil != err {
+		return nil, parseError("Set thumbnail", err)
+	}
+	return image, nil
+}
+
+// getImage returns a gallery image of the project unless it is deleted.
+func getImage(tx *gorm.DB, projectId, imageId int64) (*domain.ProjectImage, error) {
+	var images = make([]*domain.ProjectImage, 0, 1)
+	if err := tx.Table(domain.TableNameProjectImage).
+		Where("id = ? AND project_id = ? AND deleted_at IS NULL", imageId, projectId).
+		Limit(1).
+		Find(&images).Error; nil != err {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, domain.ErrImageNotFound
+	}
+	return images[0], nil
+}
+
+func imageIds(images []*domain.ProjectImage) []int64 {
+	var ids = make([]int64, len(images))
+	for i, it := range images {
+		ids[i] = it.Id
+	}
+	return ids
+}
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+)
+
+var errImportRow = errors.New("import row failed")
+
+// ImportProjects validates every row, then creates the valid ones in one
+// transaction. A row failing an all-or-nothing import rolls back the
+// others. Without AllOrNothing each row has its own savepoint.
+func (pImpl *ProjectImpl) ImportProjects(req *domain.RProjectImport,
+) (*domain.ImportReport, error) {
+	report, err := validateImport(pImpl.iCountry, req)
+	if nil != err || req.DryRun || report.Failed > 0 && req.AllOrNothing {
+		return report, err
+	}
+
+	err = pImpl.db.Transaction(func(tx *gorm.DB) error {
+		for i, row := range req.Rows {
+			if !report.Ok(i) {
+				continue
+			}
+			var project = row.Create.ToProject()
+			if req.AllOrNothing {
+				if err := insertProject(tx, project); nil != err {
+					report.Fail(i, err)
+					return errImportRow
+				}
+				report.Succeed(i, project.Id)
+				continue
+			}
+
+			if err := tx.SavePoint("import_row").Error; nil != err {
+				return err
+			}
+			if err := insertProject(tx, project); nil != err {
+				report.Fail(i, err)
+				if err := tx.RollbackTo("import_row").Error; nil != err {
+					return err
+				}
+				continue
+			}
+			report.Succeed(i, project.Id)
+		}
+		return nil
+	})
+	if errors.Is(err, errImportRow) {
+		report.RollBack()
+		return report, nil
+	}
+	if nil != err {
+		return nil, parseError("Import project", err)
+	}
+	return report, nil
+}
+
+// validateImport checks every row as Create would. An all-or-nothing
+// import with a failed row fails the others too.
+func validateImport(iCountry domain.ICountry, req *domain.RProjectImport,
+) (*domain.ImportReport, error) {
+	report, err := domain.NewImportReport(req)
+	if nil != err {
+		return nil, err
+	}
+	for i, row := range req.Rows {
+		if !report.Ok(i) {
+			continue
+		}
+		if err := prepareCreate(iCountry, row.Create); nil != err {
+			report.Fail(i, err)
+			continue
+		}
+		if err := row.Create.ValidateDescs(); nil != err {
+			report.Fail(i, err)
+		}
+	}
+	if report.Failed > 0 && req.AllOrNothing && !req.DryRun {
+		report.RollBack()
+	}
+	return report, nil
+}
@@ -0,0 +1,1257 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+)
+
+// MemoryImpl is an in-memory domain.IProject. It mirrors the behaviour of
+// ProjectImpl closely enough to back service tests and local development
+// without a database.
+type MemoryImpl struct {
+	mut       sync.RWMutex
+	projects  map[int64]*domain.Project
+	documents map[int64]*domain.ProjectDocument
+	histories []*domain.ProjectHistory
+	versions  []*domain.ProjectSpecsVersion
+	docVers   []*domain.ProjectDocumentVersion
+	lastId    int64
+	iCountry  domain.ICountry
+}
+
+func NewMemoryImpl() *MemoryImpl {
+	return &MemoryImpl{
+		projects:  make(map[int64]*domain.Project),
+		documents: make(map[int64]*domain.ProjectDocument),
+		iCountry:  NewMemoryCountryImpl(),
+	}
+}
+
+func (mImpl *MemoryImpl) Create(req *domain.RProjectCreate,
+) (*domain.Project, error) {
+	if err := prepareCreate(mImpl.iCountry, req); nil != err {
+		return nil, err
+	}
+
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	var project = mImpl.insertProject(req)
+	project.Country, _ = mImpl.GetCountry(project.CountryId, domain.DefaultFallbackLocales...)
+	return project, nil
+}
+
+func (mImpl *MemoryImpl) ImportProjects(req *domain.RProjectImport,
+) (*domain.ImportReport, error) {
+	report, err := validateImport(mImpl.iCountry, req)
+	if nil != err || req.DryRun || report.Failed > 0 && req.AllOrNothing {
+		return report, err
+	}
+
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	for i, row := range req.Rows {
+		if report.Ok(i) {
+			report.Succeed(i, mImpl.insertProject(row.Create).Id)
+		}
+	}
+	return report, nil
+}
+
+// insertProject stores the project of req. The caller holds the lock.
+func (mImpl *MemoryImpl) insertProject(req *domain.RProjectCreate) *domain.Project {
+	var project = req.ToProject()
+	project.Id = mImpl.nextId()
+	// Postgres keeps microseconds, cursors rely on the same precision.
+	project.CreatedAt = project.CreatedAt.Truncate(time.Microsecond)
+	project.UpdatedAt = project.UpdatedAt.Truncate(time.Microsecond)
+	for _, desc := range project.Descs {
+		desc.Id = mImpl.nextId()
+		desc.ProjectId = project.Id
+		desc.CreatedAt = project.CreatedAt
+		desc.UpdatedAt = project.UpdatedAt
+	}
+	if nil != project.Specs {
+		project.Specs.Id = mImpl.nextId()
+		project.Specs.ProjectId = project.Id
+		project.Specs.CreatedAt = project.CreatedAt
+		project.Specs.UpdatedAt = project.UpdatedAt
+		mImpl.versions = append(mImpl.versions, &domain.ProjectSpecsVersion{
+			Id:            mImpl.nextId(),
+			ProjectId:     project.Id,
+			Specs:         cloneSpecs(project.Specs).Specs,
+			EffectiveFrom: project.CreatedAt,
+			CreatedAt:     project.CreatedAt,
+		})
+	}
+	mImpl.projects[project.Id] = cloneProject(project)
+	return project
+}
+
+func (mImpl *MemoryImpl) Update(req *domain.RProjectUpdate) (*int64, error) {
+	var err error
+	if req.CountryId, err = mImpl.iCountry.ResolveCountryId(req.CountryId); nil != err {
+		return nil, err
+	}
+
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return nil, dmodels.ParsePostgresError("Update Project ", gorm.ErrRecordNotFound)
+	}
+	if req.Type != project.Type {
+		var specs map[string]float64
+		if nil != project.Specs {
+			specs = project.Specs.Specs
+		}
+		if err := domain.ValidateSpecs(req.Type, specs); nil != err {
+			return nil, err
+		}
+	}
+	if err := bumpMemoryVersion(project, req.Version); nil != err {
+		return nil, err
+	}
+
+	var diff = req.Diff(project)
+	project.Owner = dmodels.EthAddress(req.Owner)
+	project.OwnerId = req.OwnerId
+	project.Location = req.Location
+	project.LocationName = req.LocationName
+	project.Type = req.Type
+	project.Unit = req.Unit
+	project.CountryId = req.CountryId
+	project.Iframe = req.Iframe
+	project.OwnerAddress = req.OwnerAddress
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	return &req.ProjectId, nil
+}
+
+func (mImpl *MemoryImpl) UpdateDesc(req *domain.RProjectUpdateDesc,
+) (*domain.ProjectDesc, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return nil, dmodels.ParsePostgresError("Update project desc", gorm.ErrRecordNotFound)
+	}
+	project.Version++
+
+	var desc = req.ToProjectDesc()
+	desc.CreatedAt = time.Now()
+	desc.UpdatedAt = desc.CreatedAt
+	for i, it := range project.Descs {
+		if it.Language == desc.Language {
+			desc.Id = it.Id
+			project.Descs[i] = desc
+			mImpl.addHistory(req.ProjectId, req.Audit, req.Diff(it))
+			return cloneDesc(desc), nil
+		}
+	}
+	desc.Id = mImpl.nextId()
+	project.Descs = append(project.Descs, desc)
+	mImpl.addHistory(req.ProjectId, req.Audit, req.Diff(nil))
+	return cloneDesc(desc), nil
+}
+
+func (mImpl *MemoryImpl) UpdateSpecs(req *domain.RProjectUpdateSpecs,
+) (*domain.ProjectSpecs, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	var spec = req.ToProjectSpecs()
+	if nil == spec {
+		return nil, dmodels.ParsePostgresError("Update project desc", gorm.ErrInvalidValue)
+	}
+	var now = time.Now().Truncate(time.Microsecond)
+	effectiveFrom, err := req.GetEffectiveFrom(now)
+	if nil != err {
+		return nil, err
+	}
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return nil, dmodels.ParsePostgresError("Update project desc", gorm.ErrRecordNotFound)
+	}
+	if err := domain.ValidateSpecs(project.Type, req.Specs); nil != err {
+		return nil, err
+	}
+	if err := bumpMemoryVersion(project, req.Version); nil != err {
+		return nil, err
+	}
+
+	var versions = mImpl.specsVersions(req.ProjectId)
+	var previous = domain.SpecsAt(versions, effectiveFrom)
+	var latest = true
+	for _, it := range versions {
+		if it.EffectiveFrom.After(effectiveFrom) {
+			latest = false
+		}
+	}
+	var version = newSpecsVersion(req, effectiveFrom)
+	version.Id = mImpl.nextId()
+	version.Specs = cloneSpecs(spec).Specs
+	mImpl.versions = append(mImpl.versions, version)
+
+	var diff = req.Diff(previous.ToProjectSpecs())
+	if !req.EffectiveFrom.IsZero() {
+		diff.Set("specsEffectiveFrom", nil, effectiveFrom)
+	}
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	if !latest {
+		return cloneSpecs(spec), nil
+	}
+
+	spec.UpdatedAt = now
+	if nil != project.Specs {
+		spec.Id = project.Specs.Id
+		spec.CreatedAt = project.Specs.CreatedAt
+	} else {
+		spec.Id = mImpl.nextId()
+		spec.CreatedAt = spec.UpdatedAt
+	}
+	project.Specs = spec
+	return cloneSpecs(spec), nil
+}
+
+func (mImpl *MemoryImpl) GetById(req *domain.RProjectGetById,
+) (*domain.Project, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	project, ok := mImpl.projects[req.Id]
+	if !ok || (project.DeletedAt.Valid && !req.IncludeDeleted) {
+		return nil, dmodels.ParsePostgresError("Project", gorm.ErrRecordNotFound)
+	}
+	var rs = cloneProject(project)
+	rs.Images = make([]*domain.ProjectImage, 0, len(project.Images))
+	for _, img := range memoryImages(project, req.IncludeDeleted) {
+		// GetById only selects the gallery fields
+		rs.Images = append(rs.Images, &domain.ProjectImage{
+			Id:        img.Id,
+			ProjectId: img.ProjectId,
+			Image:     img.Image,
+			Caption:   img.Caption,
+			Position:  img.Position,
+			Variants:  cloneVariants(img.Variants),
+		})
+	}
+
+	localizeProjects(mImpl.iCountry, []*domain.Project{rs}, req.Locales)
+	return rs, nil
+}
+
+func (mImpl *MemoryImpl) GetList(filter *domain.RProjectGetList,
+) (*int64, []*domain.Project, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	if err := filter.ValidateGeo(); nil != err {
+		return nil, nil, err
+	}
+	var polygon *domain.GeoPolygon
+	if filter.Polygon != "" {
+		polygon, _ = domain.ParseGeoPolygon(filter.Polygon)
+	}
+
+	var tokens = domain.SearchTokens(filter.SearchValue)
+	if len(tokens) > 0 && filter.Cursor != "" {
+		return nil, nil, domain.ErrSearchCursor
+	}
+
+	var ids = make(map[int64]bool, len(filter.Ids))
+	for _, id := range filter.Ids {
+		ids[int64(id)] = true
+	}
+
+	var ranks = make(map[int64]int)
+	var data = make([]*domain.Project, 0)
+	for _, project := range mImpl.projects {
+		if project.DeletedAt.Valid && !filter.IncludeDeleted {
+			continue
+		}
+		var highlight string
+		if len(tokens) > 0 {
+			var rank int
+			if rank, highlight = searchProject(project, tokens); rank == 0 {
+				continue
+			}
+			ranks[project.Id] = rank
+		}
+		if len(ids) > 0 && !ids[project.Id] {
+			continue
+		}
+		if !matchProject(project, filter, polygon) {
+			continue
+		}
+		var rs = cloneProject(project)
+		rs.Images = nil
+		rs.Highlight = highlight
+		if nil != filter.Near && nil != project.Location {
+			rs.Distance = domain.HaversineDistance(filter.Near, project.Location)
+		}
+		data = append(data, rs)
+	}
+	sortByCreatedDesc(data, func(p *domain.Project) (time.Time, int64) {
+		return p.CreatedAt, p.Id
+	})
+	if len(tokens) > 0 {
+		sort.SliceStable(data, func(i, j int) bool {
+			return ranks[data[i].Id] > ranks[data[j].Id]
+		})
+	}
+
+	var count = int64(len(data))
+	data, err := paginateCursor(data, filter.Skip, filter.Limit, filter.Cursor,
+		func(p *domain.Project) (time.Time, int64) {
+			return p.CreatedAt, p.Id
+		})
+	if nil != err {
+		return nil, nil, err
+	}
+	localizeProjects(mImpl.iCountry, data, filter.Locales)
+	if filter.SkipTotal {
+		return nil, data, nil
+	}
+	return &count, data, nil
+}
+
+func (mImpl *MemoryImpl) GetOwner(projectId int64) (string, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	if project, ok := mImpl.projects[projectId]; ok {
+		return string(project.Owner), nil
+	}
+	return "", nil
+}
+
+func (mImpl *MemoryImpl) AddImage(req *domain.RProjectAddImage) (*domain.ProjectImage, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return nil, dmodels.ParsePostgresError("AddImage", gorm.ErrRecordNotFound)
+	}
+
+	var diff = domain.HistoryDiff{}
+	if req.Type != 0 { // Add thumbnail
+		project.Version++
+		diff.Set("thumbnail", project.Thumbnail, req.ImgPath)
+		project.Thumbnail = req.ImgPath
+		project.Thumbnails = cloneVariants(req.Variants)
+		mImpl.addHistory(req.ProjectId, req.Audit, diff)
+		return &domain.ProjectImage{
+			ProjectId: req.ProjectId,
+			Image:     req.ImgPath,
+			CreatedAt: time.Now(),
+		}, nil
+	}
+
+	var img = &domain.ProjectImage{
+		Id:        mImpl.nextId(),
+		ProjectId: req.ProjectId,
+		Image:     req.ImgPath,
+		Position:  1,
+		Checksum:  req.Checksum,
+		Size:      req.Size,
+		Variants:  cloneVariants(req.Variants),
+		CreatedAt: time.Now(),
+	}
+	for _, it := range memoryImages(project, false) {
+		if it.Position >= img.Position {
+			img.Position = it.Position + 1
+		}
+	}
+	project.Images = append(project.Images, img)
+	diff.Set("images", nil, req.ImgPath)
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	var rs = *img
+	return &rs, nil
+}
+
+func (mImpl *MemoryImpl) ListImages(req *domain.RProjectImageList,
+) ([]*domain.ProjectImage, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	var data = make([]*domain.ProjectImage, 0)
+	project, ok := mImpl.projects[req.ProjectId]
+	if !ok {
+		return data, nil
+	}
+	for _, it := range memoryImages(project, req.IncludeDeleted) {
+		var cp = *it
+		data = append(data, &cp)
+	}
+	return data, nil
+}
+
+func (mImpl *MemoryImpl) DeleteImage(req *domain.RProjectImageDelete) error {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return dmodels.ParsePostgresError("Delete image", gorm.ErrRecordNotFound)
+	}
+	image, err := getMemoryImage(project, req.ImageId)
+	if nil != err {
+		return err
+	}
+	if err := bumpMemoryVersion(project, 0); nil != err {
+		return err
+	}
+	image.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+	var diff = domain.HistoryDiff{}
+	diff.Set(fmt.Sprintf("images.%d", image.Id), image.Image, nil)
+	if project.Thumbnail == image.Image {
+		var next = &domain.ProjectImage{}
+		if images := memoryImages(project, false); len(images) > 0 {
+			next = images[0]
+		}
+		diff.Set("thumbnail", project.Thumbnail, next.Image)
+		project.Thumbnail = next.Image
+		project.Thumbnails = cloneVariants(next.Variants)
+	}
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	return nil
+}
+
+func (mImpl *MemoryImpl) ReorderImages(req *domain.RProjectImageReorder,
+) ([]*domain.ProjectImage, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return nil, dmodels.ParsePostgresError("Reorder images", gorm.ErrRecordNotFound)
+	}
+	var images = memoryImages(project, false)
+	if err := req.Validate(images); nil != err {
+		return nil, err
+	}
+	if err := bumpMemoryVersion(project, 0); nil != err {
+		return nil, err
+	}
+
+	var before = imageIds(images)
+	var byId = make(map[int64]*domain.ProjectImage, len(images))
+	for _, it := range images {
+		byId[it.Id] = it
+	}
+	for i, id := range req.ImageIds {
+		byId[id].Position = i + 1
+	}
+	domain.SortImages(images)
+
+	var diff = domain.HistoryDiff{}
+	diff.Set("images.order", before, imageIds(images))
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+
+	var rs = make([]*domain.ProjectImage, len(images))
+	for i, it := range images {
+		var cp = *it
+		rs[i] = &cp
+	}
+	return rs, nil
+}
+
+func (mImpl *MemoryImpl) UpdateImageCaption(req *domain.RProjectImageCaption,
+) (*domain.ProjectImage, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return nil, dmodels.ParsePostgresError("Update image caption", gorm.ErrRecordNotFound)
+	}
+	image, err := getMemoryImage(project, req.ImageId)
+	if nil != err {
+		return nil, err
+	}
+	if err := bumpMemoryVersion(project, 0); nil != err {
+		return nil, err
+	}
+
+	var diff = domain.HistoryDiff{}
+	diff.Set(fmt.Sprintf("images.%d.caption", image.Id), image.Caption, req.Caption)
+	image.Caption = req.Caption
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	var rs = *image
+	return &rs, nil
+}
+
+func (mImpl *MemoryImpl) SetThumbnail(req *domain.RProjectSetThumbnail,
+) (*domain.ProjectImage, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return nil, dmodels.ParsePostgresError("Set thumbnail", gorm.ErrRecordNotFound)
+	}
+	image, err := getMemoryImage(project, req.ImageId)
+	if nil != err {
+		return nil, err
+	}
+	if err := bumpMemoryVersion(project, 0); nil != err {
+		return nil, err
+	}
+
+	var diff = domain.HistoryDiff{}
+	diff.Set("thumbnail", project.Thumbnail, image.Image)
+	project.Thumbnail = image.Image
+	project.Thumbnails = cloneVariants(image.Variants)
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	var rs = *image
+	return &rs, nil
+}
+
+// memoryImages returns the gallery of project in display order.
+func memoryImages(project *domain.Project, includeDeleted bool) []*domain.ProjectImage {
+	var rs = make([]*domain.ProjectImage, 0, len(project.Images))
+	for _, it := range project.Images {
+		if it.DeletedAt.Valid && !includeDeleted {
+			continue
+		}
+		rs = append(rs, it)
+	}
+	domain.SortImages(rs)
+	return rs
+}
+
+// getMemoryImage is the in-memory counterpart of getImage.
+func getMemoryImage(project *domain.Project, imageId int64) (*domain.ProjectImage, error) {
+	for _, it := range project.Images {
+		if it.Id == imageId && !it.DeletedAt.Valid {
+			return it, nil
+		}
+	}
+	return nil, domain.ErrImageNotFound
+}
+
+func (mImpl *MemoryImpl) ChangeStatus(req *domain.RProjectChangeStatus,
+) error {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return dmodels.ParsePostgresError("Project", gorm.ErrRecordNotFound)
+	}
+
+	if err := req.Validate(project); nil != err {
+		return err
+	}
+	if req.RequireDocuments && req.Status == domain.ProjectStatusActived {
+		if err := mImpl.documentCompleteness(project).Err(); nil != err {
+			return err
+		}
+	}
+
+	var diff = req.Review(project, time.Now().Truncate(time.Microsecond))
+	project.Version++
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	return nil
+}
+
+func (mImpl *MemoryImpl) GetCountry(id string, locales ...string) (*domain.Country, error) {
+	return mImpl.iCountry.GetCountry(id, locales...)
+}
+
+func (mImpl *MemoryImpl) UpsertDocument(req *domain.RProjectDocumentUpsert,
+) ([]*domain.ProjectDocument, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	var current = make([]*domain.ProjectDocument, 0)
+	var documents = make([]*domain.ProjectDocument, 0, len(req.Document))
+	for _, val := range req.Document {
+		var now = time.Now().Truncate(time.Microsecond)
+		if existing, ok := mImpl.documents[val.Id]; ok && val.Id != 0 {
+			var old = cloneDocument(existing)
+			current = append(current, old)
+			existing.Url = val.Url
+			existing.DocumentType = val.DocumentType
+			existing.Name = val.DocumentName
+			existing.Checksum = val.Checksum
+			existing.Size = val.Size
+			existing.UpdatedAt = now
+			if !existing.SameFile(old) {
+				existing.Version++
+				mImpl.addDocumentVersion(existing, req.Actor)
+			}
+			documents = append(documents, cloneDocument(existing))
+			continue
+		}
+
+		var doc = &domain.ProjectDocument{
+			Id:           val.Id,
+			Name:         val.DocumentName,
+			Url:          val.Url,
+			DocumentType: val.DocumentType,
+			ProjectId:    val.ProjectId,
+			Checksum:     val.Checksum,
+			Size:         val.Size,
+			Version:      1,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if doc.Id == 0 {
+			doc.Id = mImpl.nextId()
+		} else if doc.Id > mImpl.lastId {
+			mImpl.lastId = doc.Id
+		}
+		mImpl.documents[doc.Id] = doc
+		mImpl.addDocumentVersion(doc, req.Actor)
+		documents = append(documents, cloneDocument(doc))
+	}
+
+	for projectId, diff := range documentDiffs(current, documents) {
+		mImpl.addHistory(projectId, req.Audit, diff)
+	}
+	return documents, nil
+}
+
+func (mImpl *MemoryImpl) DeleteDocument(req *domain.RProjectDocumentDelete) error {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	var now = time.Now()
+	for _, id := range req.Id {
+		if doc, ok := mImpl.documents[id]; ok && !doc.DeletedAt.Valid {
+			doc.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		}
+	}
+	return nil
+}
+
+func (mImpl *MemoryImpl) ListDocument(req *domain.RProjectDocumentList,
+) ([]*domain.ProjectDocument, int64, error) {
+	if err := req.Validate(); nil != err {
+		return nil, 0, err
+	}
+
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	var documents = make([]*domain.ProjectDocument, 0)
+	for _, doc := range mImpl.documents {
+		if doc.DeletedAt.Valid || !req.Match(doc) {
+			continue
+		}
+		documents = append(documents, cloneDocument(doc))
+	}
+	sort.SliceStable(documents, func(i, j int) bool {
+		return req.Sort.Less(documents[i], documents[j])
+	})
+
+	var count = int64(len(documents))
+	documents, err := paginateCursor(documents, req.Skip, req.Limit, req.Cursor,
+		func(d *domain.ProjectDocument) (time.Time, int64) {
+			return d.CreatedAt, d.Id
+		})
+	if nil != err {
+		return nil, 0, err
+	}
+	if req.SkipTotal {
+		count = 0
+	}
+	return documents, count, nil
+}
+
+func (mImpl *MemoryImpl) ListDocumentVersions(req *domain.RProjectDocumentVersionList,
+) ([]*domain.ProjectDocumentVersion, int64, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	var data = make([]*domain.ProjectDocumentVersion, 0)
+	for _, it := range mImpl.docVers {
+		if it.DocumentId == req.DocumentId {
+			var cp = *it
+			data = append(data, &cp)
+		}
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].Version > data[j].Version })
+
+	var count = int64(len(data))
+	return paginate(data, req.Skip, req.Limit), count, nil
+}
+
+func (mImpl *MemoryImpl) RestoreDocumentVersion(req *domain.RProjectDocumentRestore,
+) (*domain.ProjectDocument, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	doc, ok := mImpl.documents[req.DocumentId]
+	if !ok || doc.DeletedAt.Valid {
+		return nil, domain.ErrDocumentNotFound
+	}
+	var version *domain.ProjectDocumentVersion
+	for _, it := range mImpl.docVers {
+		if it.DocumentId == req.DocumentId && it.Version == req.Version {
+			version = it
+		}
+	}
+	if nil == version {
+		return nil, domain.ErrDocumentVersionNotFound
+	}
+
+	var current = cloneDocument(doc)
+	doc.Restore(version)
+	doc.UpdatedAt = doc.UpdatedAt.Truncate(time.Microsecond)
+	mImpl.addDocumentVersion(doc, req.Actor)
+	mImpl.addHistory(doc.ProjectId, req.Audit, doc.Diff(current))
+	return cloneDocument(doc), nil
+}
+
+func (mImpl *MemoryImpl) GetDocumentCompleteness(req *domain.RProjectDocumentCompleteness,
+) (*domain.DocumentCompleteness, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return nil, dmodels.ParsePostgresError("Project", gorm.ErrRecordNotFound)
+	}
+	return mImpl.documentCompleteness(project), nil
+}
+
+func (mImpl *MemoryImpl) PurgeDocuments(req *domain.RDocumentPurge,
+) (*domain.DocumentPurgeReport, error) {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	var purged = make(map[int64]bool)
+	var documents = make([]*domain.ProjectDocument, 0)
+	for _, it := range mImpl.documents {
+		if !it.DeletedAt.Valid || !it.DeletedAt.Time.Before(req.Before) {
+			continue
+		}
+		if project, ok := mImpl.projects[it.ProjectId]; ok && project.DeletedAt.Valid &&
+			project.DeletedAt.Time.Equal(it.DeletedAt.Time) {
+			continue
+		}
+		purged[it.Id] = true
+		documents = append(documents, cloneDocument(it))
+	}
+	sort.Slice(documents, func(i, j int) bool { return documents[i].Id < documents[j].Id })
+
+	var versions = make([]*domain.ProjectDocumentVersion, 0)
+	var kept = make([]*domain.ProjectDocumentVersion, 0, len(mImpl.docVers))
+	var referenced = make(map[string]bool)
+	for _, it := range mImpl.docVers {
+		if purged[it.DocumentId] {
+			versions = append(versions, it)
+		} else {
+			kept = append(kept, it)
+			referenced[it.Url] = true
+		}
+	}
+	for _, it := range mImpl.documents {
+		if !purged[it.Id] {
+			referenced[it.Url] = true
+		}
+	}
+
+	var report = domain.NewDocumentPurgeReport(req, documents, versions, referenced)
+	if req.DryRun {
+		return report, nil
+	}
+	for id := range purged {
+		delete(mImpl.documents, id)
+	}
+	mImpl.docVers = kept
+	return report, nil
+}
+
+func (mImpl *MemoryImpl) addDocumentVersion(doc *domain.ProjectDocument, uploadedBy string) {
+	var version = doc.NewVersion(uploadedBy)
+	version.Id = mImpl.nextId()
+	mImpl.docVers = append(mImpl.docVers, version)
+}
+
+func (mImpl *MemoryImpl) GetHistory(req *domain.RProjectHistoryList,
+) ([]*domain.ProjectHistory, int64, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	var data = make([]*domain.ProjectHistory, 0)
+	for _, it := range mImpl.histories {
+		if it.ProjectId == req.ProjectId {
+			var cp = *it
+			data = append(data, &cp)
+		}
+	}
+	sortByCreatedDesc(data, func(h *domain.ProjectHistory) (time.Time, int64) {
+		return h.CreatedAt, h.Id
+	})
+
+	var count = int64(len(data))
+	return paginate(data, req.Skip, req.Limit), count, nil
+}
+
+func (mImpl *MemoryImpl) GetSpecsAt(req *domain.RProjectSpecsAt,
+) (*domain.ProjectSpecsVersion, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	var at = req.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if _, ok := mImpl.getProject(req.ProjectId); !ok {
+		return nil, dmodels.ParsePostgresError("Project", gorm.ErrRecordNotFound)
+	}
+	var version = domain.SpecsAt(mImpl.specsVersions(req.ProjectId), at)
+	if nil == version {
+		return nil, domain.ErrSpecsNotFound
+	}
+	return cloneSpecsVersion(version), nil
+}
+
+func (mImpl *MemoryImpl) GetSpecsHistory(req *domain.RProjectSpecsHistory,
+) ([]*domain.ProjectSpecsVersion, int64, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	if _, ok := mImpl.getProject(req.ProjectId); !ok {
+		return nil, 0, dmodels.ParsePostgresError("Project", gorm.ErrRecordNotFound)
+	}
+	var data = make([]*domain.ProjectSpecsVersion, 0)
+	for _, it := range mImpl.specsVersions(req.ProjectId) {
+		if !req.From.IsZero() && it.EffectiveFrom.Before(req.From) {
+			continue
+		}
+		if !req.To.IsZero() && !it.EffectiveFrom.Before(req.To) {
+			continue
+		}
+		data = append(data, cloneSpecsVersion(it))
+	}
+	domain.SortSpecsVersions(data)
+
+	var count = int64(len(data))
+	return paginate(data, req.Skip, req.Limit), count, nil
+}
+
+// specsVersions returns the stored specs versions of a project.
+func (mImpl *MemoryImpl) specsVersions(projectId int64) []*domain.ProjectSpecsVersion {
+	var rs = make([]*domain.ProjectSpecsVersion, 0)
+	for _, it := range mImpl.versions {
+		if it.ProjectId == projectId {
+			rs = append(rs, it)
+		}
+	}
+	return rs
+}
+
+func (mImpl *MemoryImpl) DeleteProject(req *domain.RProjectDelete) error {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.getProject(req.ProjectId)
+	if !ok {
+		return dmodels.ParsePostgresError("Delete project", gorm.ErrRecordNotFound)
+	}
+
+	var now = time.Now()
+	mImpl.restamp(project, gorm.DeletedAt{}, gorm.DeletedAt{Time: now, Valid: true})
+
+	var diff = domain.HistoryDiff{}
+	diff.Set("deletedAt", nil, now)
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	return nil
+}
+
+func (mImpl *MemoryImpl) RestoreProject(req *domain.RProjectRestore) error {
+	mImpl.mut.Lock()
+	defer mImpl.mut.Unlock()
+
+	project, ok := mImpl.projects[req.ProjectId]
+	if !ok {
+		return dmodels.ParsePostgresError("Restore project", gorm.ErrRecordNotFound)
+	}
+	if !project.DeletedAt.Valid {
+		return domain.ErrProjectNotDeleted
+	}
+
+	var deletedAt = project.DeletedAt
+	mImpl.restamp(project, deletedAt, gorm.DeletedAt{})
+
+	var diff = domain.HistoryDiff{}
+	diff.Set("deletedAt", deletedAt.Time, nil)
+	mImpl.addHistory(req.ProjectId, req.Audit, diff)
+	return nil
+}
+
+// restamp sets the deletion time of project and of its descs, specs,
+// images and documents whose deletion time is from to to, like the
+// projectTables of ProjectImpl. Rows deleted on their own keep their time.
+func (mImpl *MemoryImpl) restamp(project *domain.Project, from, to gorm.DeletedAt) {
+	var same = func(it gorm.DeletedAt) bool {
+		return it.Valid == from.Valid && it.Time.Equal(from.Time)
+	}
+	project.DeletedAt = to
+	for _, it := range project.Descs {
+		if same(it.DeletedAt) {
+			it.DeletedAt = to
+		}
+	}
+	if nil != project.Specs && same(project.Specs.DeletedAt) {
+		project.Specs.DeletedAt = to
+	}
+	for _, it := range project.Images {
+		if same(it.DeletedAt) {
+			it.DeletedAt = to
+		}
+	}
+	for _, it := range mImpl.documents {
+		if it.ProjectId == project.Id && same(it.DeletedAt) {
+			it.DeletedAt = to
+		}
+	}
+}
+
+// bumpMemoryVersion is the in-memory counterpart of bumpVersion.
+func bumpMemoryVersion(project *domain.Project, expected int64) error {
+	if expected != 0 && expected != project.Version {
+		return domain.ErrVersionConflict
+	}
+	project.Version++
+	return nil
+}
+
+func (mImpl *MemoryImpl) documentCompleteness(project *domain.Project) *domain.DocumentCompleteness {
+	var documents = make([]*domain.ProjectDocument, 0)
+	for _, doc := range mImpl.documents {
+		if doc.ProjectId == project.Id {
+			documents = append(documents, doc)
+		}
+	}
+	return domain.CheckDocuments(project, documents)
+}
+
+// getProject returns the stored project unless it is deleted.
+func (mImpl *MemoryImpl) getProject(id int64) (*domain.Project, bool) {
+	project, ok := mImpl.projects[id]
+	if !ok || project.DeletedAt.Valid {
+		return nil, false
+	}
+	return project, true
+}
+
+// CountDocuments returns the number of documents of each project, deleted
+// ones aside. Projects without document are missing from the map.
+func (mImpl *MemoryImpl) CountDocuments(projectIds []int64) (map[int64]int64, error) {
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	var rs = make(map[int64]int64, len(projectIds))
+	for _, id := range projectIds {
+		for _, doc := range mImpl.documents {
+			if doc.ProjectId == id && !doc.DeletedAt.Valid {
+				rs[id]++
+			}
+		}
+	}
+	return rs, nil
+}
+
+func (mImpl *MemoryImpl) addHistory(projectId int64, audit domain.Audit, diff domain.HistoryDiff) {
+	if len(diff) == 0 {
+		return
+	}
+	var history = newHistory(projectId, audit, diff)
+	history.Id = mImpl.nextId()
+	mImpl.histories = append(mImpl.histories, history)
+}
+
+func (mImpl *MemoryImpl) nextId() int64 {
+	mImpl.lastId++
+	return mImpl.lastId
+}
+
+func (mImpl *MemoryImpl) GetNearby(req *domain.RProjectGetNearby,
+) ([]*domain.Project, error) {
+	if nil == req.Near {
+		return nil, domain.ErrNearbyCenter
+	}
+
+	mImpl.mut.RLock()
+	defer mImpl.mut.RUnlock()
+
+	var filter = req.Filter()
+	var data = make([]*domain.Project, 0)
+	for _, project := range mImpl.projects {
+		if project.DeletedAt.Valid || nil == project.Location ||
+			!matchProject(project, filter, nil) {
+			continue
+		}
+		var rs = cloneProject(project)
+		rs.Images = nil
+		rs.Distance = domain.HaversineDistance(req.Near, project.Location)
+		data = append(data, rs)
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		if data[i].Distance != data[j].Distance {
+			return data[i].Distance < data[j].Distance
+		}
+		return data[i].Id < data[j].Id
+	})
+	if len(data) > req.GetLimit() {
+		data = data[:req.GetLimit()]
+	}
+
+	localizeProjects(mImpl.iCountry, data, req.Locales)
+	return data, nil
+}
+
+// matchProject applies the filters of RProjectGetList other than ids,
+// search and paging.
+func matchProject(project *domain.Project, filter *domain.RProjectGetList, polygon *domain.GeoPolygon) bool {
+	if filter.Status != 0 && int(project.Status) != filter.Status {
+		return false
+	}
+	if filter.Owner != "" && project.OwnerId != filter.Owner {
+		return false
+	}
+	if filter.CountryId != "" && project.CountryId != domain.NormalizeCountryId(filter.CountryId) {
+		return false
+	}
+	if filter.Type != 0 &&
+		(project.Type != filter.Type || !filter.MatchUnit(project.Unit)) {
+		return false
+	}
+	if filter.Location != "" && !strings.Contains(project.LocationName, filter.Location) {
+		return false
+	}
+	return matchGeo(project, filter, polygon)
+}
+
+// matchGeo applies the radius, bounding box and polygon filters. Projects
+// without a location never match them.
+func matchGeo(project *domain.Project, filter *domain.RProjectGetList, polygon *domain.GeoPolygon) bool {
+	if filter.RadiusM == 0 && nil == filter.Bounds && nil == polygon {
+		return true
+	}
+	if nil == project.Location {
+		return false
+	}
+	if filter.RadiusM > 0 && domain.HaversineDistance(filter.Near, project.Location) > filter.RadiusM {
+		return false
+	}
+	if nil != filter.Bounds && !filter.Bounds.Contains(project.Location) {
+		return false
+	}
+	if nil != polygon && !polygon.Contains(project.Location) {
+		return false
+	}
+	return true
+}
+
+// searchProject approximates the Postgres full-text search: every token
+// must prefix a word of the descs or of the location name of the project.
+// Name matches weigh more than desc matches, which weigh more than
+// location. The highlight comes from the desc with the most matches.
+func searchProject(project *domain.Project, tokens []string) (int, string) {
+	var texts = []string{project.LocationName}
+	var rank = countMatches(project.LocationName, tokens)
+	var best, highlight = -1, highlightText(project.LocationName, tokens)
+	for _, desc := range project.Descs {
+		if desc.DeletedAt.Valid {
+			continue
+		}
+		texts = append(texts, desc.Name, desc.Desc)
+		var descRank = 3*countMatches(desc.Name, tokens) + 2*countMatches(desc.Desc, tokens)
+		rank += descRank
+		if descRank > best {
+			best = descRank
+			highlight = highlightText(desc.Name+" - "+desc.Desc+" - "+project.LocationName, tokens)
+		}
+	}
+	if !matchAll(strings.Join(texts, " "), tokens) {
+		return 0, ""
+	}
+	return rank, highlight
+}
+
+func matchAll(text string, tokens []string) bool {
+	var words = domain.SearchTokens(text)
+	for _, token := range tokens {
+		if !hasPrefixWord(words, token) {
+			return false
+		}
+	}
+	return true
+}
+
+func countMatches(text string, tokens []string) int {
+	var count = 0
+	for _, word := range domain.SearchTokens(text) {
+		if prefixedBy(word, tokens) {
+			count++
+		}
+	}
+	return count
+}
+
+func hasPrefixWord(words []string, token string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixedBy(word string, tokens []string) bool {
+	for _, token := range tokens {
+		if strings.HasPrefix(word, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlightText wraps the words of text matching a token like ts_headline.
+func highlightText(text string, tokens []string) string {
+	var words = strings.Fields(text)
+	for i, word := range words {
+		for _, part := range domain.SearchTokens(word) {
+			if prefixedBy(part, tokens) {
+				words[i] = domain.HighlightStart + word + domain.HighlightStop
+				break
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// sortByCreatedDesc orders arr like "ORDER BY created_at DESC", breaking
+// ties on id so results are stable.
+func sortByCreatedDesc[T any](arr []*T, key func(*T) (time.Time, int64)) {
+	sort.SliceStable(arr, func(i, j int) bool {
+		ti, idi := key(arr[i])
+		tj, idj := key(arr[j])
+		if ti.Equal(tj) {
+			return idi > idj
+		}
+		return ti.After(tj)
+	})
+}
+
+func paginate[T any](arr []*T, skip, limit int) []*T {
+	if skip >= len(arr) {
+		return arr[:0]
+	}
+	if skip > 0 {
+		arr = arr[skip:]
+	}
+	if limit > 0 && limit < len(arr) {
+		arr = arr[:limit]
+	}
+	return arr
+}
+
+// paginateCursor is paginate for listings that accept a cursor. arr must
+// already be sorted by sortByCreatedDesc.
+func paginateCursor[T any](arr []*T, skip, limit int, cursor string, key func(*T) (time.Time, int64),
+) ([]*T, error) {
+	if cursor == "" {
+		return paginate(arr, skip, limit), nil
+	}
+
+	after, err := domain.ParseCursor(cursor)
+	if nil != err {
+		return nil, err
+	}
+	var start = len(arr)
+	for i, it := range arr {
+		if after.Before(key(it)) {
+			start = i
+			break
+		}
+	}
+	return paginate(arr[start:], 0, limit), nil
+}
+
+func cloneProject(in *domain.Project) *domain.Project {
+	var rs = *in
+	if nil != in.Location {
+		var loc = *in.Location
+		rs.Location = &loc
+	}
+	rs.Specs = cloneSpecs(in.Specs)
+	rs.Thumbnails = cloneVariants(in.Thumbnails)
+	if nil != in.Descs {
+		rs.Descs = make([]*domain.ProjectDesc, len(in.Descs))
+		for i, desc := range in.Descs {
+			rs.Descs[i] = cloneDesc(desc)
+		}
+	}
+	if nil != in.Images {
+		rs.Images = make([]*domain.ProjectImage, len(in.Images))
+		for i, img := range in.Images {
+			var cp = *img
+			rs.Images[i] = &cp
+		}
+	}
+	return &rs
+}
+
+func cloneDesc(in *domain.ProjectDesc) *domain.ProjectDesc {
+	if nil == in {
+		return nil
+	}
+	var rs = *in
+	return &rs
+}
+
+func cloneSpecs(in *domain.ProjectSpecs) *domain.ProjectSpecs {
+	if nil == in {
+		return nil
+	}
+	var rs = *in
+	if nil != in.Specs {
+		rs.Specs = make(domain.MapSFloat, len(in.Specs))
+		for k, v := range in.Specs {
+			rs.Specs[k] = v
+		}
+	}
+	return &rs
+}
+
+func cloneSpecsVersion(in *domain.ProjectSpecsVersion) *domain.ProjectSpecsVersion {
+	var rs = *in
+	rs.Specs = cloneSpecs(&domain.ProjectSpecs{Specs: in.Specs}).Specs
+	return &rs
+}
+
+func cloneVariants(in domain.ImageVariants) domain.ImageVariants {
+	if nil == in {
+		return nil
+	}
+	var rs = make(domain.ImageVariants, len(in))
+	for k, v := range in {
+		rs[k] = v
+	}
+	return rs
+}
+
+func cloneDocument(in *domain.ProjectDocument) *domain.ProjectDocument {
+	var rs = *in
+	return &rs
+}
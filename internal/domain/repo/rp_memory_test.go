@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+func newMemoryImpl(t *testing.T) domain.IProject {
+	return NewMemoryImpl()
+}
+
+func TestMemoryCreate(t *testing.T) {
+	testProjectCreate(t, newMemoryImpl)
+}
+
+func TestMemoryUpdateDesc(t *testing.T) {
+	testProjectUpdateDesc(t, newMemoryImpl)
+}
+
+func TestMemoryUpdateSpecs(t *testing.T) {
+	testProjectUpdateSpecs(t, newMemoryImpl)
+}
+
+func TestMemoryGetList(t *testing.T) {
+	testProjectGetList(t, newMemoryImpl)
+}
+
+func TestMemoryDocument(t *testing.T) {
+	testProjectDocument(t, newMemoryImpl)
+}
+
+func TestMemoryHistory(t *testing.T) {
+	testProjectHistory(t, newMemoryImpl)
+}
+
+func TestMemoryDelete(t *testing.T) {
+	testProjectDelete(t, newMemoryImpl)
+}
+
+func TestMemoryVersion(t *testing.T) {
+	testProjectVersion(t, newMemoryImpl)
+}
+
+func TestMemoryCursor(t *testing.T) {
+	testProjectCursor(t, newMemoryImpl)
+}
+
+func TestMemorySearch(t *testing.T) {
+	testProjectSearch(t, newMemoryImpl)
+}
+
+func TestMemoryGeo(t *testing.T) {
+	testProjectGeo(t, newMemoryImpl)
+}
+
+func TestMemoryNearby(t *testing.T) {
+	testProjectNearby(t, newMemoryImpl)
+}
+
+func TestMemoryCountry(t *testing.T) {
+	testCountry(t, func(t *testing.T) domain.ICountry {
+		return NewMemoryCountryImpl()
+	})
+}
+
+func TestMemoryCountryId(t *testing.T) {
+	testProjectCountryId(t, newMemoryImpl)
+}
+
+func TestMemoryLocale(t *testing.T) {
+	testProjectLocale(t, newMemoryImpl)
+}
+
+func TestMemorySpecSchema(t *testing.T) {
+	testProjectSpecSchema(t, newMemoryImpl)
+}
+
+func TestMemorySpecsHistory(t *testing.T) {
+	testProjectSpecsHistory(t, newMemoryImpl)
+}
+
+func TestMemoryImages(t *testing.T) {
+	testProjectImages(t, newMemoryImpl)
+}
+
+func TestMemoryDocumentVersions(t *testing.T) {
+	testProjectDocumentVersions(t, newMemoryImpl)
+}
+
+func TestMemoryDocumentCompleteness(t *testing.T) {
+	testProjectDocumentCompleteness(t, newMemoryImpl)
+}
+
+func TestMemoryDocumentFilter(t *testing.T) {
+	testProjectDocumentFilter(t, newMemoryImpl)
+}
+
+func TestMemoryPurgeDocuments(t *testing.T) {
+	testProjectPurgeDocuments(t, newMemoryImpl)
+}
+
+func TestMemoryImport(t *testing.T) {
+	testProjectImport(t, newMemoryImpl)
+}
+
+func TestMemoryStatusTransitions(t *testing.T) {
+	testProjectStatusTransitions(t, newMemoryImpl)
+}
+
+func TestMemoryPurgeDeletedProject(t *testing.T) {
+	testProjectPurgeDeletedProject(t, newMemoryImpl)
+}
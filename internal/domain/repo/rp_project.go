@@ -1,70 +1,117 @@
 package repo
 
 import (
-	"encoding/json"
-	"io"
-	"os"
-	"strings"
+	"errors"
 	"time"
 
 	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/projects/internal/domain"
+	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type ProjectImpl struct {
-	db *gorm.DB
+	db       *gorm.DB
+	iCountry domain.ICountry
 }
 
-func NewProjectImpl(db *gorm.DB) (*ProjectImpl, error) {
-	err := db.AutoMigrate(
-		&domain.Project{},
-		&domain.ProjectImage{},
-		&domain.ProjectSpecs{},
-		&domain.ProjectDesc{},
-		&domain.ProjectDocument{},
-	)
-	if nil != err {
-		return nil, err
-	}
-
+// NewProjectImpl expects the schema to be created by the migration package.
+func NewProjectImpl(db *gorm.DB, iCountry domain.ICountry) (*ProjectImpl, error) {
 	var pp = &ProjectImpl{
-		db: db,
+		db:       db,
+		iCountry: iCountry,
 	}
 	return pp, nil
 }
 
 func (pImpl *ProjectImpl) Create(req *domain.RProjectCreate,
 ) (*domain.Project, error) {
+	if err := prepareCreate(pImpl.iCountry, req); nil != err {
+		return nil, err
+	}
 	project := req.ToProject()
 	if err := pImpl.tblProject().Transaction(func(dbTx *gorm.DB) error {
-		if err := dbTx.Table(domain.TableNameProject).Omit("Images").
-			Create(project).Error; err != nil {
-			return dmodels.ParsePostgresError("Create project", err)
-		}
-		return nil
+		return insertProject(dbTx, project)
 	}); err != nil {
 		return nil, err
 	}
-	country, _ := pImpl.GetCountry(project.CountryId, "vi") //TODO: fix 'vi'
-	project.Country = country
+	project.Country, _ = pImpl.GetCountry(project.CountryId, domain.DefaultFallbackLocales...)
 	return project, nil
 }
 
+// prepareCreate resolves the country of req and checks its specs.
+func prepareCreate(iCountry domain.ICountry, req *domain.RProjectCreate) error {
+	var err error
+	if req.CountryId, err = iCountry.ResolveCountryId(req.CountryId); nil != err {
+		return err
+	}
+	return domain.ValidateSpecs(int64(req.Type), req.GetSpecs())
+}
+
+// insertProject creates project with its descs, specs and first specs
+// version.
+func insertProject(tx *gorm.DB, project *domain.Project) error {
+	if err := tx.Table(domain.TableNameProject).Omit("Images").
+		Create(project).Error; err != nil {
+		return dmodels.ParsePostgresError("Create project", err)
+	}
+	if nil == project.Specs {
+		return nil
+	}
+	if err := tx.Table(domain.TableNameProjectSpecsVersion).
+		Create(&domain.ProjectSpecsVersion{
+			ProjectId:     project.Id,
+			Specs:         project.Specs.Specs,
+			EffectiveFrom: project.CreatedAt,
+			CreatedAt:     project.CreatedAt,
+		}).Error; err != nil {
+		return dmodels.ParsePostgresError("Create project specs", err)
+	}
+	return nil
+}
+
 func (pImpl *ProjectImpl) UpdateDesc(req *domain.RProjectUpdateDesc,
 ) (*domain.ProjectDesc, error) {
 	desc := req.ToProjectDesc()
-	if err := pImpl.tblProjectDesc().
-		Clauses(
-			clause.OnConflict{
-				Columns: []clause.Column{
-					{Name: "project_id"}, {Name: "language"},
-				},
-				UpdateAll: true,
-			}).
-		Create(desc).Error; nil != err {
-		return nil, dmodels.ParsePostgresError("Update project desc", err)
+	err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+		project, err := getProject(tx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+		if err := bumpVersion(tx, project, 0); nil != err {
+			return err
+		}
+
+		var current = make([]*domain.ProjectDesc, 0, 1)
+		if err := tx.Table(domain.TableNameProjectDesc).
+			Where("project_id = ? AND language = ?", req.ProjectId, req.Language).
+			Find(&current).Error; nil != err {
+			return err
+		}
+
+		if err := tx.Table(domain.TableNameProjectDesc).
+			Clauses(
+				clause.OnConflict{
+					Columns: []clause.Column{
+						{Name: "project_id"}, {Name: "language"},
+					},
+					UpdateAll: true,
+				}).
+			Create(desc).Error; nil != err {
+			return err
+		}
+
+		var diff domain.HistoryDiff
+		if len(current) > 0 {
+			diff = req.Diff(current[0])
+		} else {
+			diff = req.Diff(nil)
+		}
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	if nil != err {
+		return nil, parseError("Update project desc", err)
 	}
 	return desc, nil
 }
@@ -72,54 +119,143 @@ func (pImpl *ProjectImpl) UpdateDesc(req *domain.RProjectUpdateDesc,
 func (pImpl *ProjectImpl) UpdateSpecs(req *domain.RProjectUpdateSpecs,
 ) (*domain.ProjectSpecs, error) {
 	var spec = req.ToProjectSpecs()
+	effectiveFrom, err := req.GetEffectiveFrom(time.Now())
+	if nil != err {
+		return nil, err
+	}
 
-	if err := pImpl.tblProjectSpec().
-		Clauses(
-			clause.OnConflict{
-				Columns: []clause.Column{{Name: "project_id"}},
-				DoUpdates: clause.AssignmentColumns(
-					[]string{"specs", "updated_at"},
-				),
-			},
-		).Create(spec).Error; nil != err {
-		return nil, dmodels.ParsePostgresError("Update project desc", err)
+	err = pImpl.db.Transaction(func(tx *gorm.DB) error {
+		project, err := getProject(tx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+		if err := domain.ValidateSpecs(project.Type, req.Specs); nil != err {
+			return err
+		}
+		if err := bumpVersion(tx, project, req.Version); nil != err {
+			return err
+		}
+
+		previous, err := specsAt(tx, req.ProjectId, effectiveFrom)
+		if nil != err {
+			return err
+		}
+		latest, err := addSpecsVersion(tx, newSpecsVersion(req, effectiveFrom))
+		if nil != err {
+			return err
+		}
+
+		if latest {
+			if err := tx.Table(domain.TableNameProjectSpecs).
+				Clauses(
+					clause.OnConflict{
+						Columns: []clause.Column{{Name: "project_id"}},
+						DoUpdates: clause.AssignmentColumns(
+							[]string{"specs", "updated_at"},
+						),
+					},
+				).Create(spec).Error; nil != err {
+				return err
+			}
+		}
+
+		var diff = req.Diff(previous.ToProjectSpecs())
+		if !req.EffectiveFrom.IsZero() {
+			diff.Set("specsEffectiveFrom", nil, effectiveFrom)
+		}
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	if nil != err {
+		return nil, parseError("Update project desc", err)
 	}
 	return spec, nil
 }
 
-func (pImpl *ProjectImpl) GetById(id int64, lang string,
+func (pImpl *ProjectImpl) GetById(req *domain.RProjectGetById,
 ) (*domain.Project, error) {
 	var project = &domain.Project{}
-	var query = pImpl.tblProject().Where("id = ?", id).
+	var query = scopeDeleted(pImpl.tblProject(), domain.TableNameProject, req.IncludeDeleted).
+		Where("id = ?", req.Id).
 		Preload("Images", func(tx *gorm.DB) *gorm.DB {
-			return tx.Select("project_id, image")
+			return scopeDeleted(tx, domain.TableNameProjectImage, req.IncludeDeleted).
+				Select("id, project_id, image, caption, position, variants").
+				Order("position, id")
 		}).
-		Preload("Specs")
-	if lang == "" {
-		lang = "vi"
-	}
+		Preload("Specs", func(tx *gorm.DB) *gorm.DB {
+			return scopeDeleted(tx, domain.TableNameProjectSpecs, req.IncludeDeleted)
+		})
 	query.Preload("Descs", func(tx *gorm.DB) *gorm.DB {
-		return tx.Where("language = ?", lang)
+		return scopeDeleted(tx, domain.TableNameProjectDesc, req.IncludeDeleted)
 	})
 	var err = query.First(project).Error
 	if nil != err {
 		return nil, dmodels.ParsePostgresError("Project", err)
 	}
-	country, _ := pImpl.GetCountry(project.CountryId, lang)
-	project.Country = country
+	localizeProjects(pImpl.iCountry, []*domain.Project{project}, req.Locales)
 	return project, nil
 }
 
 func (pImpl *ProjectImpl) GetList(filter *domain.RProjectGetList,
 ) (*int64, []*domain.Project, error) {
+	if err := filter.ValidateGeo(); nil != err {
+		return nil, nil, err
+	}
+
 	var count int64
-	var tbl = pImpl.tblProject()
+	var tbl = scopeDeleted(pImpl.tblProject(), domain.TableNameProject, filter.IncludeDeleted)
 	var data = make([]*domain.Project, 0)
 
-	if filter.SearchValue != "" {
-		tbl = tbl.Joins("JOIN projects_desc on projects_desc.project_id = projects.id").
-			Where("projects_desc.name LIKE ?", "%"+filter.SearchValue+"%")
+	var search = domain.SearchQuery(filter.SearchValue)
+	if search != "" {
+		if filter.Cursor != "" {
+			return nil, nil, domain.ErrSearchCursor
+		}
+		tbl = tbl.Joins(searchJoin, search)
+	}
+	tbl = filterProjects(tbl, filter)
+
+	tbl, err := pageQuery(tbl, domain.TableNameProject, filter.Skip, filter.Limit,
+		filter.Cursor, filter.SkipTotal, &count)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	if search != "" {
+		tbl = tbl.Order("search.rank DESC")
+	}
+	err = tbl.
+		Preload("Descs", func(tx *gorm.DB) *gorm.DB {
+			return scopeDeleted(tx, domain.TableNameProjectDesc, filter.IncludeDeleted)
+		}).
+		Preload("Specs", func(tx *gorm.DB) *gorm.DB {
+			return scopeDeleted(tx, domain.TableNameProjectSpecs, filter.IncludeDeleted)
+		}).
+		Order("projects.created_at DESC, projects.id DESC").Find(&data).Error
+	if err != nil {
+		return nil, nil, dmodels.ParsePostgresError("Project", err)
+	}
+
+	localizeProjects(pImpl.iCountry, data, filter.Locales)
+	if search != "" {
+		if err := pImpl.highlight(data, search); nil != err {
+			return nil, nil, dmodels.ParsePostgresError("Project", err)
+		}
+	}
+	if nil != filter.Near {
+		if err := pImpl.distance(data, filter.Near); nil != err {
+			return nil, nil, dmodels.ParsePostgresError("Project", err)
+		}
+	}
+
+	if filter.SkipTotal {
+		return nil, data, nil
 	}
+	return &count, data, nil
+}
+
+// filterProjects applies the filters of RProjectGetList other than search
+// and paging.
+func filterProjects(tbl *gorm.DB, filter *domain.RProjectGetList) *gorm.DB {
 	if filter.Status != 0 {
 		tbl = tbl.Where("status = ?", filter.Status)
 	}
@@ -130,7 +266,7 @@ func (pImpl *ProjectImpl) GetList(filter *domain.RProjectGetList,
 		tbl = tbl.Where("owner_id = ?", filter.Owner)
 	}
 	if filter.CountryId != "" {
-		tbl = tbl.Where("UPPER(country_id) = ?", strings.ToUpper(filter.CountryId))
+		tbl = tbl.Where("country_id = ?", domain.NormalizeCountryId(filter.CountryId))
 	}
 	if filter.Type != 0 {
 		tbl = tbl.Where("type = ?", filter.Type)
@@ -141,23 +277,7 @@ func (pImpl *ProjectImpl) GetList(filter *domain.RProjectGetList,
 	if filter.Location != "" {
 		tbl = tbl.Where("location_name LIKE ?", "%"+filter.Location+"%")
 	}
-
-	tbl.Count(&count).Offset(filter.Skip)
-	if filter.Limit > 0 {
-		tbl = tbl.Limit(filter.Limit)
-	}
-
-	err := tbl.Preload("Descs").Preload("Specs").Order("created_at DESC").Find(&data).Error
-	if err != nil {
-		return nil, nil, dmodels.ParsePostgresError("Project", err)
-	}
-
-	for _, dat := range data {
-		country, _ := pImpl.GetCountry(dat.CountryId, "vi") // TODO: fix 'vi'
-		dat.Country = country
-	}
-
-	return &count, data, nil
+	return geoFilter(tbl, filter)
 }
 
 func (pImpl *ProjectImpl) GetByID(id int64) (*domain.Project, error) {
@@ -169,13 +289,43 @@ func (pImpl *ProjectImpl) GetByID(id int64) (*domain.Project, error) {
 	return data, nil
 }
 
-func (pImpl *ProjectImpl) ChangeStatus(id int, status domain.ProjectStatus,
+func (pImpl *ProjectImpl) ChangeStatus(req *domain.RProjectChangeStatus,
 ) error {
-	var err = pImpl.tblProject().
-		Where("id = ?", id).
-		Update("status", status).
-		Error
-	return dmodels.ParsePostgresError("Project", err)
+	var err = pImpl.db.Transaction(func(tx *gorm.DB) error {
+		current, err := getProject(tx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+		if err := req.Validate(current); nil != err {
+			return err
+		}
+		if req.RequireDocuments && req.Status == domain.ProjectStatusActived {
+			completeness, err := documentCompleteness(tx, current)
+			if nil != err {
+				return err
+			}
+			if err := completeness.Err(); nil != err {
+				return err
+			}
+		}
+		if err := bumpVersion(tx, current, 0); nil != err {
+			return err
+		}
+
+		var diff = req.Review(current, time.Now())
+		if err := tx.Table(domain.TableNameProject).
+			Where("id = ?", req.ProjectId).
+			Updates(map[string]interface{}{
+				"status":        current.Status,
+				"status_reason": current.StatusReason,
+				"status_by":     current.StatusBy,
+				"status_at":     current.StatusAt,
+			}).Error; nil != err {
+			return err
+		}
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	return parseError("Project", err)
 }
 
 func (pImpl *ProjectImpl) GetOwner(projectId int64) (string, error) {
@@ -190,73 +340,278 @@ func (pImpl *ProjectImpl) GetOwner(projectId int64) (string, error) {
 }
 
 func (pImpl *ProjectImpl) AddImage(req *domain.RProjectAddImage) (*domain.ProjectImage, error) {
-	if req.Type != 0 { // Add thumbnail
-		err := pImpl.tblProject().Where("id = ?", req.ProjectId).Update("thumbnail", req.ImgPath).Error
-		if err != nil {
-			return nil, dmodels.ParsePostgresError("AddImage", err)
-		}
-		return &domain.ProjectImage{
-			ProjectId: req.ProjectId,
-			Image:     req.ImgPath,
-			CreatedAt: time.Now(),
-		}, nil
-	}
 	img := &domain.ProjectImage{
 		ProjectId: req.ProjectId,
 		Image:     req.ImgPath,
+		Checksum:  req.Checksum,
+		Size:      req.Size,
+		Variants:  req.Variants,
 		CreatedAt: time.Now(),
 	}
 
-	err := pImpl.tblImage().Create(img).Error
+	if req.Type != 0 { // Add thumbnail
+		err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+			current, err := getProject(tx, req.ProjectId)
+			if nil != err {
+				return err
+			}
+			if err := bumpVersion(tx, current, 0); nil != err {
+				return err
+			}
+
+			if err := tx.Table(domain.TableNameProject).
+				Where("id = ?", req.ProjectId).
+				Updates(map[string]interface{}{
+					"thumbnail":          req.ImgPath,
+					"thumbnail_variants": req.Variants,
+				}).Error; nil != err {
+				return err
+			}
+
+			var diff = domain.HistoryDiff{}
+			diff.Set("thumbnail", current.Thumbnail, req.ImgPath)
+			return addHistory(tx, req.ProjectId, req.Audit, diff)
+		})
+		if err != nil {
+			return nil, parseError("AddImage", err)
+		}
+		return img, nil
+	}
+
+	err := pImpl.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := getProject(tx, req.ProjectId); nil != err {
+			return err
+		}
+		// New images go to the end of the gallery.
+		if err := tx.Table(domain.TableNameProjectImage).
+			Where("project_id = ? AND deleted_at IS NULL", req.ProjectId).
+			Select("COALESCE(MAX(position), 0) + 1").
+			Scan(&img.Position).Error; nil != err {
+			return err
+		}
+		if err := tx.Table(domain.TableNameProjectImage).Create(img).Error; nil != err {
+			return err
+		}
+
+		var diff = domain.HistoryDiff{}
+		diff.Set("images", nil, req.ImgPath)
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
 	if err != nil {
 		return nil, dmodels.ParsePostgresError("AddImage", err)
 	}
 
-	return nil, nil
+	return img, nil
 }
 
-func (pImpl *ProjectImpl) GetCountry(id string, locale string) (*domain.Country, error) {
-
-	jsonPath := "json/country.json"
-	jsonFile, err := os.Open(jsonPath)
-	if err != nil {
-		return &domain.Country{}, dmodels.ParsePostgresError("Get Country ", err)
-	}
-	defer jsonFile.Close()
-	jsonByte, _ := io.ReadAll(jsonFile)
-	countries := map[string][]domain.Language{}
-	_ = json.Unmarshal(jsonByte, &countries)
-	for _, language := range countries[id] {
-		if language.Locale == locale {
-			return &domain.Country{
-				Id:   id,
-				Name: language.Name,
-				Code: language.CountryCode,
-			}, nil
-		}
-	}
-	return &domain.Country{}, nil
+func (pImpl *ProjectImpl) GetCountry(id string, locales ...string) (*domain.Country, error) {
+	return pImpl.iCountry.GetCountry(id, locales...)
 }
 
 func (pImpl *ProjectImpl) Update(req *domain.RProjectUpdate) (*int64, error) {
-	if err := pImpl.tblProject().Select("owner", "owner_id", "location_name", "location",
-		"type", "unit", "country_id", "iframe", "owner_address").
-		Where("id = ?", req.ProjectId).Updates(domain.Project{
-		Owner:        dmodels.EthAddress(req.Owner),
-		OwnerId:      req.OwnerId,
-		Location:     req.Location,
-		LocationName: req.LocationName,
-		Type:         req.Type,
-		Unit:         req.Unit,
-		CountryId:    req.CountryId,
-		Iframe:       req.Iframe,
-		OwnerAddress: req.OwnerAddress,
-	}).Error; nil != err {
-		return nil, dmodels.ParsePostgresError("Update Project ", err)
+	var err error
+	if req.CountryId, err = pImpl.iCountry.ResolveCountryId(req.CountryId); nil != err {
+		return nil, err
+	}
+	err = pImpl.db.Transaction(func(tx *gorm.DB) error {
+		current, err := getProject(tx, req.ProjectId)
+		if nil != err {
+			return err
+		}
+		var diff = req.Diff(current)
+		if req.Type != current.Type {
+			if err := validateStoredSpecs(tx, req.ProjectId, req.Type); nil != err {
+				return err
+			}
+		}
+		if err := bumpVersion(tx, current, req.Version); nil != err {
+			return err
+		}
+
+		if err := tx.Table(domain.TableNameProject).Select("owner", "owner_id", "location_name", "location",
+			"type", "unit", "country_id", "iframe", "owner_address").
+			Where("id = ?", req.ProjectId).Updates(domain.Project{
+			Owner:        dmodels.EthAddress(req.Owner),
+			OwnerId:      req.OwnerId,
+			Location:     req.Location,
+			LocationName: req.LocationName,
+			Type:         req.Type,
+			Unit:         req.Unit,
+			CountryId:    req.CountryId,
+			Iframe:       req.Iframe,
+			OwnerAddress: req.OwnerAddress,
+		}).Error; nil != err {
+			return err
+		}
+
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	if nil != err {
+		return nil, parseError("Update Project ", err)
 	}
 	return &req.ProjectId, nil
 }
 
+// validateStoredSpecs checks the current specs of a project against the
+// schema of the type it moves to.
+func validateStoredSpecs(tx *gorm.DB, projectId int64, t int64) error {
+	var specs = &domain.ProjectSpecs{}
+	err := tx.Table(domain.TableNameProjectSpecs).
+		Where("project_id = ? AND deleted_at IS NULL", projectId).
+		Take(specs).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ValidateSpecs(t, nil)
+	}
+	if nil != err {
+		return err
+	}
+	return domain.ValidateSpecs(t, specs.Specs)
+}
+
+func (pImpl *ProjectImpl) DeleteProject(req *domain.RProjectDelete) error {
+	var err = pImpl.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := getProject(tx, req.ProjectId); nil != err {
+			return err
+		}
+
+		var now = time.Now()
+		for _, table := range projectTables {
+			var column = "project_id"
+			if table == domain.TableNameProject {
+				column = "id"
+			}
+			if err := tx.Table(table).
+				Where(column+" = ? AND deleted_at IS NULL", req.ProjectId).
+				Update("deleted_at", now).Error; nil != err {
+				return err
+			}
+		}
+
+		var diff = domain.HistoryDiff{}
+		diff.Set("deletedAt", nil, now)
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	return dmodels.ParsePostgresError("Delete project", err)
+}
+
+func (pImpl *ProjectImpl) RestoreProject(req *domain.RProjectRestore) error {
+	var err = pImpl.db.Transaction(func(tx *gorm.DB) error {
+		var current = &domain.Project{}
+		if err := tx.Unscoped().Table(domain.TableNameProject).
+			Where("id = ?", req.ProjectId).
+			First(current).Error; nil != err {
+			return err
+		}
+		if !current.DeletedAt.Valid {
+			return domain.ErrProjectNotDeleted
+		}
+
+		// Only rows deleted together with the project come back.
+		for _, table := range projectTables {
+			var column = "project_id"
+			if table == domain.TableNameProject {
+				column = "id"
+			}
+			if err := tx.Table(table).
+				Where(column+" = ? AND deleted_at = ?", req.ProjectId, current.DeletedAt.Time).
+				Update("deleted_at", nil).Error; nil != err {
+				return err
+			}
+		}
+
+		var diff = domain.HistoryDiff{}
+		diff.Set("deletedAt", current.DeletedAt.Time, nil)
+		return addHistory(tx, req.ProjectId, req.Audit, diff)
+	})
+	return parseError("Restore project", err)
+}
+
+// projectTables are soft-deleted and restored together.
+var projectTables = []string{
+	domain.TableNameProject,
+	domain.TableNameProjectDesc,
+	domain.TableNameProjectSpecs,
+	domain.TableNameProjectImage,
+	domain.TableNameProjectDocument,
+}
+
+// bumpVersion increments the version of project inside tx. A non-zero
+// expected version must match the stored one, otherwise
+// domain.ErrVersionConflict is returned.
+func bumpVersion(tx *gorm.DB, project *domain.Project, expected int64) error {
+	if expected != 0 && expected != project.Version {
+		return domain.ErrVersionConflict
+	}
+
+	var rs = tx.Table(domain.TableNameProject).
+		Where("id = ? AND version = ?", project.Id, project.Version).
+		Update("version", gorm.Expr("version + 1"))
+	if nil != rs.Error {
+		return rs.Error
+	}
+	if rs.RowsAffected == 0 {
+		return domain.ErrVersionConflict
+	}
+	project.Version++
+	return nil
+}
+
+// parseError keeps domain errors, which already carry a gRPC status, and
+// maps everything else through dmodels.ParsePostgresError.
+func parseError(label string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return dmodels.ParsePostgresError(label, err)
+}
+
+// getProject loads a project that is not deleted inside tx.
+func getProject(tx *gorm.DB, id int64) (*domain.Project, error) {
+	var project = &domain.Project{}
+	if err := tx.Table(domain.TableNameProject).
+		Where("id = ? AND deleted_at IS NULL", id).
+		First(project).Error; nil != err {
+		return nil, err
+	}
+	return project, nil
+}
+
+// pageQuery counts the rows matched by tbl unless skipTotal is set, then
+// moves to the requested page of a listing ordered by created_at DESC,
+// id DESC. A cursor takes precedence over skip.
+func pageQuery(tbl *gorm.DB, table string, skip, limit int, cursor string, skipTotal bool, count *int64,
+) (*gorm.DB, error) {
+	if !skipTotal {
+		if err := tbl.Count(count).Error; nil != err {
+			return nil, dmodels.ParsePostgresError("Count", err)
+		}
+	}
+
+	if cursor != "" {
+		after, err := domain.ParseCursor(cursor)
+		if nil != err {
+			return nil, err
+		}
+		tbl = tbl.Where("("+table+".created_at, "+table+".id) < (?, ?)", after.CreatedAt, after.Id)
+	} else {
+		tbl = tbl.Offset(skip)
+	}
+	if limit > 0 {
+		tbl = tbl.Limit(limit)
+	}
+	return tbl, nil
+}
+
+// scopeDeleted hides soft-deleted rows of table unless includeDeleted is
+// set. The condition is explicit because Count on a bare table does not get
+// gorm's soft delete scope.
+func scopeDeleted(tx *gorm.DB, table string, includeDeleted bool) *gorm.DB {
+	if includeDeleted {
+		return tx.Unscoped()
+	}
+	return tx.Where(table + ".deleted_at IS NULL")
+}
+
 func (pImpl *ProjectImpl) tblProject() *gorm.DB {
 	return pImpl.db.Table(domain.TableNameProject)
 }
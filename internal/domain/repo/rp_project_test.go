@@ -4,180 +4,166 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/go-shared/libs/utils"
 	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/migration"
 )
 
-func TestProjectCreate(t *testing.T) {
+func newProjectImpl(t *testing.T) domain.IProject {
 	db, err := ConnectMockPostgresql()
 	if !errors.Is(err, nil) {
-		t.Errorf("fail to init Databases.")
-		return
-	}
-	service, err := NewProjectImpl(db)
-
-	specs := map[string]float64{"a": 12344, "b": 121232, "c": 121212}
-	req := domain.RProjectCreate{
-		Owner:    dmodels.EthAddress("0x5348a62dc343a9fa6881a64b51ea6137968506c5"),
-		Location: dmodels.NewCoord4326(111.2222, 232323),
-		Specs: &domain.RProjectUpdateSpecs{
-			Specs: specs,
-		},
-		Descs: []*domain.RProjectUpdateDesc{
-			{
-				Language: "vi",
-				Name:     "Description Name",
-				Desc:     "Description",
-			},
-		},
-		Area:         1000,
-		LocationName: "LOCATION_NAME",
+		t.Fatalf("fail to init Databases.")
 	}
+	migrator, err := migration.NewMigrator(db)
+	utils.PanicError("", err)
+	_, err = migrator.Up()
+	utils.PanicError("", err)
 
-	t.Run("test create project success", func(t *testing.T) {
-		project, err := service.Create(&req)
-		if err != nil {
-			t.Errorf("fail to create project, err= %s", err)
-		}
-		if project.Id == 0 {
-			t.Errorf("fail when create project. ")
-		}
-		if project.Descs[0].Id == 0 {
-			t.Errorf("fail when create project descs ")
-		}
-		if project.Specs.Id == 0 {
-			t.Errorf("fail when create project specs ")
-		}
-	})
+	iCountry, err := NewCountryImpl(db)
+	utils.PanicError("", err)
+	service, err := NewProjectImpl(db, iCountry)
 	utils.PanicError("", err)
+	return service
 }
 
-func TestProjectUpdateDesc(t *testing.T) {
+func newCountryImpl(t *testing.T) domain.ICountry {
 	db, err := ConnectMockPostgresql()
 	if !errors.Is(err, nil) {
-		t.Errorf("fail to init Databases.")
-		return
-	}
-	service, err := NewProjectImpl(db)
-
-	specs := map[string]float64{"a": 12344, "b": 121232, "c": 121212}
-	req := domain.RProjectCreate{
-		Owner:    dmodels.EthAddress("0x5348a62dc343a9fa6881a64b51ea6137968506c5"),
-		Location: dmodels.NewCoord4326(111.2222, 232323),
-		Specs: &domain.RProjectUpdateSpecs{
-			Specs: specs,
-		},
-		Descs: []*domain.RProjectUpdateDesc{
-			{
-				Language: "vi",
-				Name:     "Description Name",
-				Desc:     "Description",
-			},
-		},
-		Area:         1000,
-		LocationName: "LOCATION_NAME",
+		t.Fatalf("fail to init Databases.")
 	}
-	t.Run("test update description project fail when project not exists", func(t *testing.T) {
-		_, err := service.UpdateDesc(&domain.RProjectUpdateDesc{
-			ProjectId: 999,
-			Language:  "vi",
-			Name:      "name",
-			Desc:      "description",
-		})
-		if err == nil {
-			t.Errorf("Update project description fail.")
-			return
-		}
-		//It must not create a new project.
-		if _, err := service.GetByID(999); err == nil {
-			t.Errorf("get project by id fail when id not exist")
-			return
-		}
-	})
-
-	t.Run("test update description project success", func(t *testing.T) {
-		prj, err := service.Create(&req)
-		if err != nil {
-			t.Errorf("Create new project fail.")
-			return
-		}
-		desc, err := service.UpdateDesc(&domain.RProjectUpdateDesc{
-			ProjectId: prj.Id,
-			Language:  "vi",
-			Name:      "name",
-			Desc:      "description",
-		})
-		if err != nil {
-			t.Errorf("Update project description fail.")
-			return
-		}
-		if desc.Id == 0 {
-			t.Errorf("Update project description fail.")
-			return
-		}
-	})
+	migrator, err := migration.NewMigrator(db)
+	utils.PanicError("", err)
+	_, err = migrator.Up()
+	utils.PanicError("", err)
+
+	iCountry, err := NewCountryImpl(db)
 	utils.PanicError("", err)
+	return iCountry
 }
+
+func TestProjectCreate(t *testing.T) {
+	testProjectCreate(t, newProjectImpl)
+}
+
+func TestProjectUpdateDesc(t *testing.T) {
+	testProjectUpdateDesc(t, newProjectImpl)
+}
+
 func TestProjectUpdateSpecs(t *testing.T) {
-	db, err := ConnectMockPostgresql()
-	if !errors.Is(err, nil) {
-		t.Errorf("fail to init Databases.")
-		return
-	}
-	service, err := NewProjectImpl(db)
-
-	specs := map[string]float64{"a": 12344, "b": 121232, "c": 121212}
-	req := domain.RProjectCreate{
-		Owner:    dmodels.EthAddress("0x5348a62dc343a9fa6881a64b51ea6137968506c5"),
-		Location: dmodels.NewCoord4326(111.2222, 232323),
-		Specs: &domain.RProjectUpdateSpecs{
-			Specs: specs,
-		},
-		Descs: []*domain.RProjectUpdateDesc{
-			{
-				Language: "vi",
-				Name:     "Description Name",
-				Desc:     "Description",
-			},
-		},
-		Area:         1000,
-		LocationName: "LOCATION_NAME",
+	testProjectUpdateSpecs(t, newProjectImpl)
+}
+
+func TestProjectGetList(t *testing.T) {
+	testProjectGetList(t, newProjectImpl)
+}
+
+func TestProjectDocument(t *testing.T) {
+	testProjectDocument(t, newProjectImpl)
+}
+
+func TestProjectHistory(t *testing.T) {
+	testProjectHistory(t, newProjectImpl)
+}
+
+func TestProjectDelete(t *testing.T) {
+	testProjectDelete(t, newProjectImpl)
+}
+
+func TestProjectVersion(t *testing.T) {
+	testProjectVersion(t, newProjectImpl)
+}
+
+func TestProjectCursor(t *testing.T) {
+	testProjectCursor(t, newProjectImpl)
+}
+
+func TestProjectSearch(t *testing.T) {
+	testProjectSearch(t, newProjectImpl)
+}
+
+func TestProjectGeo(t *testing.T) {
+	testProjectGeo(t, newProjectImpl)
+}
+
+func TestProjectNearby(t *testing.T) {
+	testProjectNearby(t, newProjectImpl)
+}
+
+func TestProjectCountry(t *testing.T) {
+	testCountry(t, newCountryImpl)
+}
+
+func TestProjectCountryId(t *testing.T) {
+	testProjectCountryId(t, newProjectImpl)
+}
+
+func TestProjectRepairCountryIds(t *testing.T) {
+	var service = newProjectImpl(t).(*ProjectImpl)
+	prj, err := service.Create(newCreateRequest())
+	utils.PanicError("", err)
+	other, err := service.Create(newCreateRequest())
+	utils.PanicError("", err)
+
+	// Rows written before country ids were validated.
+	utils.PanicError("", service.db.Exec("UPDATE projects SET country_id = ? WHERE id = ?", "Viet Nam", prj.Id).Error)
+	utils.PanicError("", service.db.Exec("UPDATE projects SET country_id = ? WHERE id = ?", "Atlantis", other.Id).Error)
+
+	report, err := service.RepairCountryIds(domain.Audit{Actor: "test"}, true)
+	utils.PanicError("", err)
+	if len(report) != 2 || report[0].CountryId != "Atlantis" || report[0].Resolved != "" ||
+		report[1].CountryId != "Viet Nam" || report[1].Resolved != "vn" {
+		t.Fatalf("Repair report is wrong: %+v", report)
 	}
-	t.Run("test update specs project fail when project not exists", func(t *testing.T) {
-		_, err := service.UpdateSpecs(&domain.RProjectUpdateSpecs{
-			ProjectId: 999,
-			Specs:     map[string]float64{"d": 1010101, "asasasa": 101010},
-		})
-		if err == nil {
-			t.Errorf("Update project description fail.")
-			return
-		}
-		//It must not create a new project.
-		if _, err := service.GetByID(999); err == nil {
-			t.Errorf("get project by id fail when id not exist")
-			return
-		}
-	})
-
-	t.Run("test update specs project success", func(t *testing.T) {
-		prj, err := service.Create(&req)
-		if err != nil {
-			t.Errorf("Create new project fail.")
-			return
-		}
-		desc, err := service.UpdateSpecs(&domain.RProjectUpdateSpecs{
-			ProjectId: prj.Id,
-			Specs:     map[string]float64{"as": 10101, "bc": 2345},
-		})
-		if err != nil {
-			t.Errorf("Update project description fail.")
-			return
-		}
-		if desc.Id == 0 {
-			t.Errorf("Update project description fail.")
-			return
-		}
-	})
+
+	_, err = service.RepairCountryIds(domain.Audit{Actor: "test"}, false)
 	utils.PanicError("", err)
+	data, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+	utils.PanicError("", err)
+	if data.CountryId != "vn" || data.Version != 2 {
+		t.Errorf("Repair expect country id vn at version 2, got %q %d", data.CountryId, data.Version)
+	}
+}
+
+func TestProjectLocale(t *testing.T) {
+	testProjectLocale(t, newProjectImpl)
+}
+
+func TestProjectSpecSchema(t *testing.T) {
+	testProjectSpecSchema(t, newProjectImpl)
+}
+
+func TestProjectSpecsHistory(t *testing.T) {
+	testProjectSpecsHistory(t, newProjectImpl)
+}
+
+func TestProjectImages(t *testing.T) {
+	testProjectImages(t, newProjectImpl)
+}
+
+func TestProjectDocumentVersions(t *testing.T) {
+	testProjectDocumentVersions(t, newProjectImpl)
+}
+
+func TestProjectDocumentCompleteness(t *testing.T) {
+	testProjectDocumentCompleteness(t, newProjectImpl)
+}
+
+func TestProjectDocumentFilter(t *testing.T) {
+	testProjectDocumentFilter(t, newProjectImpl)
+}
+
+func TestProjectPurgeDocuments(t *testing.T) {
+	testProjectPurgeDocuments(t, newProjectImpl)
+}
+
+func TestProjectImport(t *testing.T) {
+	testProjectImport(t, newProjectImpl)
+}
+
+func TestProjectStatusTransitions(t *testing.T) {
+	testProjectStatusTransitions(t, newProjectImpl)
+}
+
+func TestProjectPurgeDeletedProject(t *testing.T) {
+	testProjectPurgeDeletedProject(t, newProjectImpl)
 }
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+)
+
+// PurgeDocuments hard-deletes the documents soft-deleted before
+// req.Before and their versions. Documents deleted with their project stay
+// while the project is deleted, so that RestoreProject brings them back.
+// The report lists the storage objects left without any row, the caller
+// deletes them once the rows are gone.
+func (pImpl *ProjectImpl) PurgeDocuments(req *domain.RDocumentPurge,
+) (*domain.DocumentPurgeReport, error) {
+	var report *domain.DocumentPurgeReport
+	var err = pImpl.db.Transaction(func(tx *gorm.DB) error {
+		var documents = make([]*domain.ProjectDocument, 0)
+		if err := tx.Unscoped().Table(domain.TableNameProjectDocument+" d").
+			Where("d.deleted_at IS NOT NULL AND d.deleted_at < ?", req.Before).
+			Where("NOT EXISTS (SELECT 1 FROM " + domain.TableNameProject + " p" +
+				" WHERE p.id = d.project_id AND p.deleted_at = d.deleted_at)").
+			Order("d.id").Find(&documents).Error; nil != err {
+			return err
+		}
+		var ids = make([]int64, 0, len(documents))
+		for _, it := range documents {
+			ids = append(ids, it.Id)
+		}
+		if len(ids) == 0 {
+			report = domain.NewDocumentPurgeReport(req, nil, nil, nil)
+			return nil
+		}
+
+		var versions = make([]*domain.ProjectDocumentVersion, 0)
+		if err := tx.Table(domain.TableNameProjectDocumentVersion).
+			Where("document_id IN ?", ids).Find(&versions).Error; nil != err {
+			return err
+		}
+		referenced, err := referencedFiles(tx, ids, documents, versions)
+		if nil != err {
+			return err
+		}
+		report = domain.NewDocumentPurgeReport(req, documents, versions, referenced)
+		if req.DryRun {
+			return nil
+		}
+
+		// Lifts the append-only trigger of the versions for this
+		// transaction only.
+		if err := tx.Exec("SET LOCAL projects.purge = 'on'").Error; nil != err {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM "+domain.TableNameProjectDocumentVersion+
+			" WHERE document_id IN ?", ids).Error; nil != err {
+			return err
+		}
+		return tx.Exec("DELETE FROM "+domain.TableNameProjectDocument+
+			" WHERE id IN ?", ids).Error
+	})
+	if nil != err {
+		return nil, parseError("Purge document", err)
+	}
+	return report, nil
+}
+
+// referencedFiles returns the urls of the purged rows that documents or
+// versions kept by the purge still use.
+func referencedFiles(tx *gorm.DB, ids []int64, documents []*domain.ProjectDocument,
+	versions []*domain.ProjectDocumentVersion,
+) (map[string]bool, error) {
+	var urls = make([]string, 0, len(documents)+len(versions))
+	for _, it := range documents {
+		urls = append(urls, it.Url)
+	}
+	for _, it := range versions {
+		urls = append(urls, it.Url)
+	}
+
+	var kept = make([]string, 0)
+	err := tx.Raw("SELECT url FROM "+domain.TableNameProjectDocument+
+		" WHERE url IN ? AND id NOT IN ?"+
+		" UNION SELECT url FROM "+domain.TableNameProjectDocumentVersion+
+		" WHERE url IN ? AND document_id NOT IN ?",
+		urls, ids, urls, ids).Scan(&kept).Error
+	if nil != err {
+		return nil, err
+	}
+	var rs = make(map[string]bool, len(kept))
+	for _, it := range kept {
+		rs[it] = true
+	}
+	return rs, nil
+}
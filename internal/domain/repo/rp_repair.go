@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+)
+
+// RepairCountryIds reports the project country ids that are not registry
+// ids. Unless dryRun, the ones LookupCountryId resolves are rewritten, with
+// a version bump and a history entry per project. Deleted projects are
+// repaired too.
+func (pImpl *ProjectImpl) RepairCountryIds(audit domain.Audit, dryRun bool,
+) ([]*domain.CountryRepair, error) {
+	var rows = make([]*domain.CountryRepair, 0)
+	var err = pImpl.db.Table(domain.TableNameProject).
+		Select("country_id, count(*) AS projects").
+		Where("country_id IS NOT NULL AND country_id <> ''").
+		Group("country_id").Order("country_id").
+		Scan(&rows).Error
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project", err)
+	}
+
+	var report = make([]*domain.CountryRepair, 0)
+	for _, row := range rows {
+		if id, err := pImpl.iCountry.ResolveCountryId(row.CountryId); nil == err && id == row.CountryId {
+			continue
+		}
+		row.Resolved, _ = pImpl.iCountry.LookupCountryId(row.CountryId)
+		report = append(report, row)
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	err = pImpl.db.Transaction(func(tx *gorm.DB) error {
+		for _, it := range report {
+			if it.Resolved == "" {
+				continue
+			}
+			var ids = make([]int64, 0)
+			var tbl = tx.Table(domain.TableNameProject).Where("country_id = ?", it.CountryId)
+			if err := tbl.Pluck("id", &ids).Error; nil != err {
+				return err
+			}
+			err := tx.Table(domain.TableNameProject).Where("id IN ?", ids).
+				Updates(map[string]interface{}{
+					"country_id": it.Resolved,
+					"version":    gorm.Expr("version + 1"),
+				}).Error
+			if nil != err {
+				return err
+			}
+			for _, id := range ids {
+				var diff = domain.HistoryDiff{}
+				diff.Set("countryId", it.CountryId, it.Resolved)
+				if err := addHistory(tx, id, audit, diff); nil != err {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, parseError("Repair country", err)
+	}
+	return report, nil
+}
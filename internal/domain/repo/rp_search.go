@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+// searchJoin keeps the projects whose descs and location name together
+// match the tsquery, with its rank. The descs keep their A and B weights,
+// the location name its C weight.
+const searchJoin = `JOIN (
+	SELECT p.id AS project_id, ts_rank(v.search_vector, q.query) AS rank
+	FROM ` + domain.TableNameProject + ` p, to_tsquery('` + domain.SearchConfig + `', ?) q,
+	LATERAL (
+		SELECT coalesce(tsvector_agg(d.search_vector), '') || p.search_vector AS search_vector
+		FROM ` + domain.TableNameProjectDesc + ` d
+		WHERE d.project_id = p.id AND d.deleted_at IS NULL
+	) v
+	WHERE v.search_vector @@ q.query
+) search ON search.project_id = projects.id`
+
+const headlineOptions = "StartSel=" + domain.HighlightStart + ", StopSel=" + domain.HighlightStop +
+	", MaxWords=25, MinWords=8, MaxFragments=2"
+
+// highlight fills Project.Highlight with a snippet of the best matching
+// desc followed by the location name, or of the location name alone when
+// the project has no desc.
+func (pImpl *ProjectImpl) highlight(data []*domain.Project, search string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var ids = make([]int64, len(data))
+	for i, it := range data {
+		ids[i] = it.Id
+	}
+
+	var rows = make([]*struct {
+		ProjectId int64
+		Highlight string
+	}, 0)
+	var err = pImpl.db.Raw(`
+		SELECT DISTINCT ON (d.project_id) d.project_id,
+			ts_headline('`+domain.SearchConfig+`',
+				coalesce(d.name, '') || ' - ' || coalesce(d."desc", '') || ' - ' || coalesce(p.location_name, ''),
+				to_tsquery('`+domain.SearchConfig+`', ?), ?) AS highlight
+		FROM `+domain.TableNameProjectDesc+` d
+		JOIN `+domain.TableNameProject+` p ON p.id = d.project_id
+		WHERE d.project_id IN ? AND d.deleted_at IS NULL
+		ORDER BY d.project_id,
+			ts_rank(d.search_vector || p.search_vector, to_tsquery('`+domain.SearchConfig+`', ?)) DESC
+		`, search, headlineOptions, ids, search).
+		Scan(&rows).Error
+	if nil != err {
+		return err
+	}
+
+	var byId = make(map[int64]string, len(rows))
+	for _, row := range rows {
+		byId[row.ProjectId] = row.Highlight
+	}
+
+	var missing = make([]int64, 0)
+	for _, it := range data {
+		if hl, ok := byId[it.Id]; ok {
+			it.Highlight = hl
+		} else {
+			missing = append(missing, it.Id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	rows = rows[:0]
+	err = pImpl.db.Raw(`
+		SELECT id AS project_id,
+			ts_headline('`+domain.SearchConfig+`', location_name, to_tsquery('`+domain.SearchConfig+`', ?), ?) AS highlight
+		FROM `+domain.TableNameProject+`
+		WHERE id IN ?`, search, headlineOptions, missing).
+		Scan(&rows).Error
+	if nil != err {
+		return err
+	}
+	for _, row := range rows {
+		byId[row.ProjectId] = row.Highlight
+	}
+	for _, it := range data {
+		if it.Highlight == "" {
+			it.Highlight = byId[it.Id]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"gorm.io/gorm"
+)
+
+// GetSpecsAt and GetSpecsHistory hide the specs of deleted projects, like
+// GetById.
+func (pImpl *ProjectImpl) GetSpecsAt(req *domain.RProjectSpecsAt,
+) (*domain.ProjectSpecsVersion, error) {
+	var at = req.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if _, err := getProject(pImpl.db, req.ProjectId); nil != err {
+		return nil, dmodels.ParsePostgresError("Project", err)
+	}
+	version, err := specsAt(pImpl.db, req.ProjectId, at)
+	if nil != err {
+		return nil, dmodels.ParsePostgresError("Project specs", err)
+	}
+	if nil == version {
+		return nil, domain.ErrSpecsNotFound
+	}
+	return version, nil
+}
+
+func (pImpl *ProjectImpl) GetSpecsHistory(req *domain.RProjectSpecsHistory,
+) ([]*domain.ProjectSpecsVersion, int64, error) {
+	if _, err := getProject(pImpl.db, req.ProjectId); nil != err {
+		return nil, 0, dmodels.ParsePostgresError("Project", err)
+	}
+	var count int64
+	var data = make([]*domain.ProjectSpecsVersion, 0)
+	var tbl = pImpl.db.Table(domain.TableNameProjectSpecsVersion).
+		Where("project_id = ?", req.ProjectId)
+	if !req.From.IsZero() {
+		tbl = tbl.Where("effective_from >= ?", req.From)
+	}
+	if !req.To.IsZero() {
+		tbl = tbl.Where("effective_from < ?", req.To)
+	}
+
+	tbl.Count(&count).Offset(req.Skip)
+	if req.Limit > 0 {
+		tbl = tbl.Limit(req.Limit)
+	}
+	err := tbl.Order("effective_from DESC, id DESC").Find(&data).Error
+	if nil != err {
+		return nil, 0, dmodels.ParsePostgresError("Project specs", err)
+	}
+	return data, count, nil
+}
+
+// specsAt returns the version of the project specs in effect at t, nil when
+// there is none.
+func specsAt(tx *gorm.DB, projectId int64, t time.Time,
+) (*domain.ProjectSpecsVersion, error) {
+	var version = &domain.ProjectSpecsVersion{}
+	err := tx.Table(domain.TableNameProjectSpecsVersion).
+		Where("project_id = ? AND effective_from <= ?", projectId, t).
+		Order("effective_from DESC, id DESC").
+		First(version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if nil != err {
+		return nil, err
+	}
+	return version, nil
+}
+
+// addSpecsVersion records version inside tx. It returns whether version is
+// now the latest one, which projects_specs has to mirror.
+func addSpecsVersion(tx *gorm.DB, version *domain.ProjectSpecsVersion,
+) (bool, error) {
+	var newer int64
+	if err := tx.Table(domain.TableNameProjectSpecsVersion).
+		Where("project_id = ? AND effective_from > ?", version.ProjectId, version.EffectiveFrom).
+		Count(&newer).Error; nil != err {
+		return false, err
+	}
+	if err := tx.Table(domain.TableNameProjectSpecsVersion).
+		Create(version).Error; nil != err {
+		return false, err
+	}
+	return newer == 0, nil
+}
+
+// newSpecsVersion builds the version written by req.
+func newSpecsVersion(req *domain.RProjectUpdateSpecs, effectiveFrom time.Time,
+) *domain.ProjectSpecsVersion {
+	return &domain.ProjectSpecsVersion{
+		ProjectId:     req.ProjectId,
+		Specs:         req.Specs,
+		EffectiveFrom: effectiveFrom,
+		Actor:         req.Actor,
+		CreatedAt:     time.Now(),
+	}
+}
@@ -0,0 +1,1640 @@
+package repo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/projects/internal/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// repoFactory builds the domain.IProject under test. Every implementation
+// runs the same cases below.
+type repoFactory func(t *testing.T) domain.IProject
+
+func newCreateRequest() *domain.RProjectCreate {
+	specs := map[string]float64{}
+	return &domain.RProjectCreate{
+		Owner:    dmodels.EthAddress("0x5348a62dc343a9fa6881a64b51ea6137968506c5"),
+		Location: dmodels.NewCoord4326(111.2222, 232323),
+		Specs: &domain.RProjectUpdateSpecs{
+			Specs: specs,
+		},
+		Descs: []*domain.RProjectUpdateDesc{
+			{
+				Language: "vi",
+				Name:     "Description Name",
+				Desc:     "Description",
+			},
+		},
+		Area:         1000,
+		LocationName: "LOCATION_NAME",
+	}
+}
+
+// newSpecs returns specs valid for the schema of the project type t.
+func newSpecs(t int32) map[string]float64 {
+	var specs = map[string]float64{}
+	schema, err := domain.GetSpecSchema(int64(t))
+	if nil != err {
+		return specs
+	}
+	for _, field := range schema.Fields {
+		if field.Required {
+			specs[field.Key] = 1
+		}
+	}
+	return specs
+}
+
+// newCapacitySpecs returns specs valid for PrjT_G with the given capacity.
+func newCapacitySpecs(capacity float64) map[string]float64 {
+	var specs = newSpecs(int32(pb.ProjectType_PrjT_G))
+	specs["capacity_kw"] = capacity
+	return specs
+}
+
+// newTypedRequest is newCreateRequest for a PrjT_G project.
+func newTypedRequest() *domain.RProjectCreate {
+	var req = newCreateRequest()
+	req.Type = int32(pb.ProjectType_PrjT_G)
+	req.Specs.Specs = newCapacitySpecs(1)
+	return req
+}
+
+func testProjectCreate(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	req := newCreateRequest()
+
+	t.Run("test create project success", func(t *testing.T) {
+		project, err := service.Create(req)
+		if err != nil {
+			t.Errorf("fail to create project, err= %s", err)
+			return
+		}
+		if project.Id == 0 {
+			t.Errorf("fail when create project. ")
+		}
+		if project.Descs[0].Id == 0 {
+			t.Errorf("fail when create project descs ")
+		}
+		if project.Specs.Id == 0 {
+			t.Errorf("fail when create project specs ")
+		}
+	})
+}
+
+func testProjectUpdateDesc(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	req := newCreateRequest()
+
+	t.Run("test update description project fail when project not exists", func(t *testing.T) {
+		_, err := service.UpdateDesc(&domain.RProjectUpdateDesc{
+			ProjectId: 999,
+			Language:  "vi",
+			Name:      "name",
+			Desc:      "description",
+		})
+		if err == nil {
+			t.Errorf("Update project description fail.")
+			return
+		}
+		//It must not create a new project.
+		if _, err := service.GetById(&domain.RProjectGetById{Id: 999}); err == nil {
+			t.Errorf("get project by id fail when id not exist")
+			return
+		}
+	})
+
+	t.Run("test update description project success", func(t *testing.T) {
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail.")
+			return
+		}
+		desc, err := service.UpdateDesc(&domain.RProjectUpdateDesc{
+			ProjectId: prj.Id,
+			Language:  "vi",
+			Name:      "name",
+			Desc:      "description",
+		})
+		if err != nil {
+			t.Errorf("Update project description fail.")
+			return
+		}
+		if desc.Id == 0 {
+			t.Errorf("Update project description fail.")
+			return
+		}
+	})
+}
+
+func testProjectUpdateSpecs(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	req := newTypedRequest()
+
+	t.Run("test update specs project fail when project not exists", func(t *testing.T) {
+		_, err := service.UpdateSpecs(&domain.RProjectUpdateSpecs{
+			ProjectId: 999,
+			Specs:     map[string]float64{"d": 1010101, "asasasa": 101010},
+		})
+		if err == nil {
+			t.Errorf("Update project description fail.")
+			return
+		}
+		//It must not create a new project.
+		if _, err := service.GetById(&domain.RProjectGetById{Id: 999}); err == nil {
+			t.Errorf("get project by id fail when id not exist")
+			return
+		}
+	})
+
+	t.Run("test update specs project success", func(t *testing.T) {
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail.")
+			return
+		}
+		desc, err := service.UpdateSpecs(&domain.RProjectUpdateSpecs{
+			ProjectId: prj.Id,
+			Specs:     newCapacitySpecs(10101),
+		})
+		if err != nil {
+			t.Errorf("Update project description fail.")
+			return
+		}
+		if desc.Id == 0 {
+			t.Errorf("Update project description fail.")
+			return
+		}
+	})
+}
+
+func testProjectGetList(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	var owner = "owner-get-list"
+	var created = make([]*domain.Project, 0)
+	for i, name := range []string{"Biogas Alpha", "Biogas Beta", "Solar Gamma"} {
+		req := newCreateRequest()
+		req.OwnerId = owner
+		req.CountryId = "vn"
+		req.Type = int32(pb.ProjectType_PrjT_G)
+		if i%2 == 1 {
+			req.Type = int32(pb.ProjectType_PrjT_E)
+		}
+		req.Specs.Specs = newSpecs(req.Type)
+		req.Unit = float32(10 + 50*i)
+		req.Descs[0].Name = name
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail: %s", err)
+			return
+		}
+		created = append(created, prj)
+	}
+
+	t.Run("test get list filter by owner", func(t *testing.T) {
+		count, data, err := service.GetList(&domain.RProjectGetList{Owner: owner})
+		if err != nil {
+			t.Errorf("Get list fail: %s", err)
+			return
+		}
+		if *count != 3 || len(data) != 3 {
+			t.Errorf("Get list by owner expect 3 got %d", *count)
+			return
+		}
+		if data[0].Id != created[2].Id {
+			t.Errorf("Get list must order by created_at desc")
+		}
+	})
+
+	t.Run("test get list filter by search value and country", func(t *testing.T) {
+		count, _, err := service.GetList(&domain.RProjectGetList{
+			Owner:       owner,
+			SearchValue: "Biogas",
+			CountryId:   "VN",
+		})
+		if err != nil {
+			t.Errorf("Get list fail: %s", err)
+			return
+		}
+		if *count != 2 {
+			t.Errorf("Get list by search value expect 2 got %d", *count)
+		}
+	})
+
+	t.Run("test get list filter by type and unit", func(t *testing.T) {
+		count, data, err := service.GetList(&domain.RProjectGetList{
+			Owner: owner,
+			Type:  int64(pb.ProjectType_PrjT_G),
+			Unit:  3,
+		})
+		if err != nil {
+			t.Errorf("Get list fail: %s", err)
+			return
+		}
+		if *count != 1 || data[0].Id != created[2].Id {
+			t.Errorf("Get list by type and unit expect project %d", created[2].Id)
+		}
+	})
+
+	t.Run("test get list filter by ids with paging", func(t *testing.T) {
+		count, data, err := service.GetList(&domain.RProjectGetList{
+			Ids:   []int{int(created[0].Id), int(created[1].Id)},
+			Skip:  1,
+			Limit: 1,
+		})
+		if err != nil {
+			t.Errorf("Get list fail: %s", err)
+			return
+		}
+		if *count != 2 || len(data) != 1 || data[0].Id != created[0].Id {
+			t.Errorf("Get list by ids with paging fail")
+		}
+	})
+}
+
+func testProjectDocument(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	prj, err := service.Create(newCreateRequest())
+	if err != nil {
+		t.Errorf("Create new project fail: %s", err)
+		return
+	}
+
+	docs, err := service.UpsertDocument(&domain.RProjectDocumentUpsert{
+		Document: []*domain.Document{
+			{ProjectId: prj.Id, DocumentName: "PDD", Url: "/pdd.pdf", DocumentType: "pdd"},
+		},
+	})
+	if err != nil || len(docs) != 1 || docs[0].Id == 0 {
+		t.Errorf("Upsert document fail: %v", err)
+		return
+	}
+
+	t.Run("test upsert document update existing", func(t *testing.T) {
+		updated, err := service.UpsertDocument(&domain.RProjectDocumentUpsert{
+			Document: []*domain.Document{
+				{Id: docs[0].Id, ProjectId: prj.Id, DocumentName: "PDD v2", Url: "/pdd-2.pdf", DocumentType: "pdd"},
+			},
+		})
+		if err != nil {
+			t.Errorf("Upsert document fail: %s", err)
+			return
+		}
+		if updated[0].Id != docs[0].Id || updated[0].Url != "/pdd-2.pdf" {
+			t.Errorf("Upsert document must update existing row")
+		}
+	})
+
+	t.Run("test delete document hide from list", func(t *testing.T) {
+		if err := service.DeleteDocument(&domain.RProjectDocumentDelete{
+			Id: []int64{docs[0].Id},
+		}); err != nil {
+			t.Errorf("Delete document fail: %s", err)
+			return
+		}
+		_, count, err := service.ListDocument(&domain.RProjectDocumentList{
+			Ids: []int64{docs[0].Id},
+		})
+		if err != nil {
+			t.Errorf("List document fail: %s", err)
+			return
+		}
+		if count != 0 {
+			t.Errorf("Deleted document must not be listed")
+		}
+	})
+}
+
+func testProjectHistory(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	prj, err := service.Create(newCreateRequest())
+	if err != nil {
+		t.Errorf("Create new project fail: %s", err)
+		return
+	}
+	var audit = domain.Audit{Actor: "admin", Action: "/pb.ProjectService/Update"}
+
+	if _, err := service.Update(&domain.RProjectUpdate{
+		Audit:        audit,
+		ProjectId:    prj.Id,
+		Owner:        prj.Owner,
+		Location:     prj.Location,
+		LocationName: "NEW_LOCATION",
+	}); err != nil {
+		t.Errorf("Update project fail: %s", err)
+		return
+	}
+	if err := service.ChangeStatus(&domain.RProjectChangeStatus{
+		Audit:     domain.Audit{Actor: "admin", Action: "/pb.ProjectService/ChangeStatus"},
+		ProjectId: prj.Id,
+		Status:    domain.ProjectStatusActived,
+	}); err != nil {
+		t.Errorf("Change status fail: %s", err)
+		return
+	}
+
+	t.Run("test history records every change", func(t *testing.T) {
+		data, count, err := service.GetHistory(&domain.RProjectHistoryList{ProjectId: prj.Id})
+		if err != nil {
+			t.Errorf("Get history fail: %s", err)
+			return
+		}
+		if count != 2 || len(data) != 2 {
+			t.Errorf("Get history expect 2 entries got %d", count)
+			return
+		}
+		if data[0].Action != "/pb.ProjectService/ChangeStatus" || data[0].Diff["status"] == nil {
+			t.Errorf("Latest history entry must be the status change")
+		}
+		change := data[1].Diff["locationName"]
+		if data[1].Actor != "admin" || change == nil || change.To != "NEW_LOCATION" {
+			t.Errorf("History entry must record actor and location diff")
+		}
+	})
+
+	t.Run("test history skip unchanged update", func(t *testing.T) {
+		if _, err := service.UpdateDesc(&domain.RProjectUpdateDesc{
+			Audit:     audit,
+			ProjectId: prj.Id,
+			Language:  "vi",
+			Name:      "Description Name",
+			Desc:      "Description",
+		}); err != nil {
+			t.Errorf("Update desc fail: %s", err)
+			return
+		}
+		_, count, err := service.GetHistory(&domain.RProjectHistoryList{ProjectId: prj.Id})
+		if err != nil || count != 2 {
+			t.Errorf("Unchanged update must not add history")
+		}
+	})
+}
+
+func testProjectDelete(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	prj, err := service.Create(newCreateRequest())
+	if err != nil {
+		t.Errorf("Create new project fail: %s", err)
+		return
+	}
+	docs, err := service.UpsertDocument(&domain.RProjectDocumentUpsert{
+		Document: []*domain.Document{
+			{ProjectId: prj.Id, DocumentName: "PDD", Url: "/pdd.pdf", DocumentType: "pdd"},
+			{ProjectId: prj.Id, DocumentName: "EIA", Url: "/eia.pdf", DocumentType: "eia"},
+		},
+	})
+	if err != nil {
+		t.Errorf("Upsert document fail: %s", err)
+		return
+	}
+	if err := service.DeleteDocument(&domain.RProjectDocumentDelete{Id: []int64{docs[0].Id}}); err != nil {
+		t.Errorf("Delete document fail: %s", err)
+		return
+	}
+	if _, err := service.AddImage(&domain.RProjectAddImage{ProjectId: prj.Id, ImgPath: "/a.png"}); err != nil {
+		t.Errorf("Add image fail: %s", err)
+		return
+	}
+
+	t.Run("test delete project hide it", func(t *testing.T) {
+		if err := service.DeleteProject(&domain.RProjectDelete{ProjectId: prj.Id}); err != nil {
+			t.Errorf("Delete project fail: %s", err)
+			return
+		}
+		if _, err := service.GetById(&domain.RProjectGetById{Id: prj.Id}); err == nil {
+			t.Errorf("Deleted project must not be found")
+		}
+		if _, err := service.GetById(&domain.RProjectGetById{Id: prj.Id, IncludeDeleted: true}); err != nil {
+			t.Errorf("Deleted project must be found with include deleted")
+		}
+		count, _, _ := service.GetList(&domain.RProjectGetList{Ids: []int{int(prj.Id)}})
+		if *count != 0 {
+			t.Errorf("Deleted project must not be listed")
+		}
+		count, _, _ = service.GetList(&domain.RProjectGetList{Ids: []int{int(prj.Id)}, IncludeDeleted: true})
+		if *count != 1 {
+			t.Errorf("Deleted project must be listed with include deleted")
+		}
+		images, err := service.ListImages(&domain.RProjectImageList{ProjectId: prj.Id})
+		if err != nil || len(images) != 0 {
+			t.Errorf("Images of a deleted project must not be listed, got %d %v", len(images), err)
+		}
+		images, _ = service.ListImages(&domain.RProjectImageList{ProjectId: prj.Id, IncludeDeleted: true})
+		if len(images) != 1 {
+			t.Errorf("Images of a deleted project must be listed with include deleted")
+		}
+	})
+
+	t.Run("test restore project", func(t *testing.T) {
+		if err := service.RestoreProject(&domain.RProjectRestore{ProjectId: prj.Id}); err != nil {
+			t.Errorf("Restore project fail: %s", err)
+			return
+		}
+		restored, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		if err != nil || len(restored.Descs) != 1 || restored.Specs == nil {
+			t.Errorf("Restored project must come back with descs and specs")
+		}
+		if images, _ := service.ListImages(&domain.RProjectImageList{ProjectId: prj.Id}); len(images) != 1 {
+			t.Errorf("Restored project must come back with its images")
+		}
+		_, count, err := service.ListDocument(&domain.RProjectDocumentList{
+			Ids: []int64{docs[1].Id},
+		})
+		if err != nil || count != 1 {
+			t.Errorf("Documents deleted with the project must be restored")
+		}
+		_, count, _ = service.ListDocument(&domain.RProjectDocumentList{
+			Ids: []int64{docs[0].Id},
+		})
+		if count != 0 {
+			t.Errorf("Documents deleted before the project must stay deleted")
+		}
+		if err := service.RestoreProject(&domain.RProjectRestore{ProjectId: prj.Id}); err == nil {
+			t.Errorf("Restore project must fail when it is not deleted")
+		}
+	})
+}
+
+func testProjectVersion(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	prj, err := service.Create(newTypedRequest())
+	if err != nil {
+		t.Errorf("Create new project fail: %s", err)
+		return
+	}
+	if prj.Version != 1 {
+		t.Errorf("New project version expect 1 got %d", prj.Version)
+	}
+
+	var update = &domain.RProjectUpdate{
+		ProjectId:    prj.Id,
+		Version:      prj.Version,
+		Type:         prj.Type,
+		Owner:        prj.Owner,
+		Location:     prj.Location,
+		LocationName: "EDIT_1",
+	}
+	if _, err := service.Update(update); err != nil {
+		t.Errorf("Update with current version fail: %s", err)
+		return
+	}
+
+	t.Run("test update with stale version fail", func(t *testing.T) {
+		update.LocationName = "EDIT_2"
+		_, err := service.Update(update)
+		if status.Code(err) != codes.Aborted {
+			t.Errorf("Update with stale version expect Aborted got %v", err)
+		}
+		_, err = service.UpdateSpecs(&domain.RProjectUpdateSpecs{
+			ProjectId: prj.Id,
+			Version:   prj.Version,
+			Specs:     newCapacitySpecs(2),
+		})
+		if status.Code(err) != codes.Aborted {
+			t.Errorf("Update specs with stale version expect Aborted got %v", err)
+		}
+	})
+
+	t.Run("test update without version skip the check", func(t *testing.T) {
+		if _, err := service.UpdateSpecs(&domain.RProjectUpdateSpecs{
+			ProjectId: prj.Id,
+			Specs:     newCapacitySpecs(2),
+		}); err != nil {
+			t.Errorf("Update specs without version fail: %s", err)
+			return
+		}
+		current, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		if err != nil || current.Version != 3 || current.LocationName != "EDIT_1" {
+			t.Errorf("Project version expect 3 after two updates")
+		}
+	})
+}
+
+func testProjectCursor(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	var owner = "owner-cursor"
+	var created = make([]*domain.Project, 0)
+	for i := 0; i < 3; i++ {
+		req := newCreateRequest()
+		req.OwnerId = owner
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail: %s", err)
+			return
+		}
+		created = append(created, prj)
+	}
+
+	t.Run("test get list with cursor", func(t *testing.T) {
+		_, first, err := service.GetList(&domain.RProjectGetList{Owner: owner, Limit: 2})
+		if err != nil || len(first) != 2 {
+			t.Errorf("Get first page fail: %v", err)
+			return
+		}
+		var last = first[len(first)-1]
+		count, second, err := service.GetList(&domain.RProjectGetList{
+			Owner:     owner,
+			Limit:     2,
+			Cursor:    domain.NewCursor(last.CreatedAt, last.Id).Encode(),
+			SkipTotal: true,
+		})
+		if err != nil {
+			t.Errorf("Get next page fail: %s", err)
+			return
+		}
+		if count != nil {
+			t.Errorf("Get list with skip total must not count")
+		}
+		if len(second) != 1 || second[0].Id != created[0].Id {
+			t.Errorf("Next page expect the oldest project")
+		}
+	})
+
+	t.Run("test get list with invalid cursor", func(t *testing.T) {
+		_, _, err := service.GetList(&domain.RProjectGetList{Owner: owner, Cursor: "not-a-cursor"})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Invalid cursor expect InvalidArgument got %v", err)
+		}
+	})
+}
+
+func testProjectSearch(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	var owner = "owner-search"
+	req := newCreateRequest()
+	req.OwnerId = owner
+	req.Descs = []*domain.RProjectUpdateDesc{
+		{Language: "vi", Name: "Hầm biogas Đồng Nai", Desc: "Hộ gia đình chăn nuôi"},
+		{Language: "en", Name: "Dong Nai biogas digester", Desc: "Household farm"},
+	}
+	prj, err := service.Create(req)
+	if err != nil {
+		t.Errorf("Create new project fail: %s", err)
+		return
+	}
+	req = newCreateRequest()
+	req.OwnerId = owner
+	req.LocationName = "Đồng Nai"
+	req.Descs[0].Name = "Solar"
+	if _, err := service.Create(req); err != nil {
+		t.Errorf("Create new project fail: %s", err)
+		return
+	}
+
+	t.Run("test search ignore accents and deduplicate", func(t *testing.T) {
+		count, data, err := service.GetList(&domain.RProjectGetList{
+			Owner:       owner,
+			SearchValue: "dong nai biogas",
+		})
+		if err != nil {
+			t.Errorf("Search fail: %s", err)
+			return
+		}
+		if *count != 1 || len(data) != 1 || data[0].Id != prj.Id {
+			t.Errorf("Search expect only project %d", prj.Id)
+			return
+		}
+		if !strings.Contains(data[0].Highlight, domain.HighlightStart) {
+			t.Errorf("Search result must have a highlight, got %q", data[0].Highlight)
+		}
+	})
+
+	t.Run("test search match location and rank desc first", func(t *testing.T) {
+		count, data, err := service.GetList(&domain.RProjectGetList{
+			Owner:       owner,
+			SearchValue: "Đồng",
+		})
+		if err != nil {
+			t.Errorf("Search fail: %s", err)
+			return
+		}
+		if *count != 2 || data[0].Id != prj.Id {
+			t.Errorf("Search expect both projects with the desc match first")
+		}
+	})
+
+	t.Run("test search across desc and location name", func(t *testing.T) {
+		count, data, err := service.GetList(&domain.RProjectGetList{
+			Owner:       owner,
+			SearchValue: "solar dong",
+		})
+		if err != nil {
+			t.Errorf("Search fail: %s", err)
+			return
+		}
+		if *count != 1 || len(data) != 1 || data[0].Id == prj.Id {
+			t.Errorf("Search expect the project named solar in dong nai, got %d", *count)
+			return
+		}
+		if !strings.Contains(data[0].Highlight, domain.HighlightStart+"Solar"+domain.HighlightStop) ||
+			!strings.Contains(data[0].Highlight, domain.HighlightStart+"Đồng"+domain.HighlightStop) {
+			t.Errorf("Search highlight expect the name and the location, got %q", data[0].Highlight)
+		}
+	})
+}
+
+func testProjectGeo(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	var owner = "owner-geo"
+	var ids = make(map[string]int64)
+	for name, coord := range map[string]*dmodels.Coord{
+		"hanoi":  dmodels.NewCoord4326(105.8342, 21.0278),
+		"danang": dmodels.NewCoord4326(108.2022, 16.0544),
+		"hcmc":   dmodels.NewCoord4326(106.6297, 10.8231),
+	} {
+		req := newCreateRequest()
+		req.OwnerId = owner
+		req.Location = coord
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail: %s", err)
+			return
+		}
+		ids[name] = prj.Id
+	}
+
+	t.Run("test get list within radius with distance", func(t *testing.T) {
+		count, data, err := service.GetList(&domain.RProjectGetList{
+			Owner:   owner,
+			Near:    dmodels.NewCoord4326(105.85, 21.03),
+			RadiusM: 200000,
+		})
+		if err != nil {
+			t.Errorf("Get list fail: %s", err)
+			return
+		}
+		if *count != 1 || data[0].Id != ids["hanoi"] {
+			t.Errorf("Get list within radius expect only hanoi")
+			return
+		}
+		if data[0].Distance <= 0 || data[0].Distance > 5000 {
+			t.Errorf("Distance expect about 1.6km, got %f", data[0].Distance)
+		}
+	})
+
+	t.Run("test get list within bounding box", func(t *testing.T) {
+		count, _, err := service.GetList(&domain.RProjectGetList{
+			Owner:  owner,
+			Bounds: &domain.BoundingBox{MinLng: 100, MinLat: 10, MaxLng: 110, MaxLat: 17},
+		})
+		if err != nil {
+			t.Errorf("Get list fail: %s", err)
+			return
+		}
+		if *count != 2 {
+			t.Errorf("Get list within bounds expect 2, got %d", *count)
+		}
+	})
+
+	t.Run("test get list within polygon", func(t *testing.T) {
+		count, data, err := service.GetList(&domain.RProjectGetList{
+			Owner:   owner,
+			Polygon: `{"type":"Polygon","coordinates":[[[106,10],[107,10],[107,11.5],[106,11.5],[106,10]]]}`,
+		})
+		if err != nil {
+			t.Errorf("Get list fail: %s", err)
+			return
+		}
+		if *count != 1 || data[0].Id != ids["hcmc"] {
+			t.Errorf("Get list within polygon expect only hcmc")
+		}
+	})
+
+	t.Run("test get list reject invalid geo filters", func(t *testing.T) {
+		for _, filter := range []*domain.RProjectGetList{
+			{RadiusM: 1000},
+			{Bounds: &domain.BoundingBox{MinLng: 10, MaxLng: 5}},
+			{Polygon: `{"type":"Point","coordinates":[1,2]}`},
+		} {
+			_, _, err := service.GetList(filter)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("Get list expect InvalidArgument, got %v", err)
+			}
+		}
+	})
+}
+
+func testProjectNearby(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	var ids = make(map[string]int64)
+	for name, coord := range map[string]*dmodels.Coord{
+		"hanoi":  dmodels.NewCoord4326(105.8342, 21.0278),
+		"danang": dmodels.NewCoord4326(108.2022, 16.0544),
+		"hcmc":   dmodels.NewCoord4326(106.6297, 10.8231),
+	} {
+		req := newCreateRequest()
+		req.OwnerId = "owner-nearby"
+		req.Location = coord
+		req.CountryId = "vn"
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail: %s", err)
+			return
+		}
+		ids[name] = prj.Id
+	}
+
+	t.Run("test get nearby order by distance", func(t *testing.T) {
+		data, err := service.GetNearby(&domain.RProjectGetNearby{
+			Near:      dmodels.NewCoord4326(108, 16),
+			Limit:     2,
+			CountryId: "VN",
+		})
+		if err != nil {
+			t.Errorf("Get nearby fail: %s", err)
+			return
+		}
+		if len(data) != 2 || data[0].Id != ids["danang"] || data[1].Id != ids["hcmc"] {
+			t.Errorf("Get nearby expect danang then hcmc")
+			return
+		}
+		if data[0].Distance >= data[1].Distance {
+			t.Errorf("Get nearby distance must increase")
+		}
+	})
+
+	t.Run("test get nearby require center", func(t *testing.T) {
+		_, err := service.GetNearby(&domain.RProjectGetNearby{})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Get nearby expect InvalidArgument, got %v", err)
+		}
+	})
+}
+
+// countryFactory builds the domain.ICountry under test.
+type countryFactory func(t *testing.T) domain.ICountry
+
+func testCountry(t *testing.T, newCountry countryFactory) {
+	iCountry := newCountry(t)
+
+	t.Run("test get country with cldr name", func(t *testing.T) {
+		country, err := iCountry.GetCountry("DE", "vi")
+		if err != nil {
+			t.Errorf("Get country fail: %s", err)
+			return
+		}
+		if country.Id != "de" || country.Code != "DE" || country.Alpha3 != "DEU" || country.Name != "Đức" {
+			t.Errorf("Get country return wrong country: %+v", country)
+		}
+	})
+
+	t.Run("test get country fallback to default locale", func(t *testing.T) {
+		country, err := iCountry.GetCountry("de", "tlh")
+		if err != nil {
+			t.Errorf("Get country fail: %s", err)
+			return
+		}
+		if country.Name != "Germany" || country.Locale != domain.DefaultCountryLocale {
+			t.Errorf("Get country expect english name, got %q", country.Name)
+		}
+	})
+
+	t.Run("test get country not found", func(t *testing.T) {
+		_, err := iCountry.GetCountry("zz", "en")
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("Get country expect NotFound, got %v", err)
+		}
+	})
+
+	t.Run("test upsert and delete country locale", func(t *testing.T) {
+		if _, err := iCountry.UpsertCountryLocale(&domain.RCountryLocaleUpsert{
+			CountryId: "DE", Locale: "tlh", Name: "DoyIchlan",
+		}); err != nil {
+			t.Errorf("Upsert country locale fail: %s", err)
+			return
+		}
+		country, _ := iCountry.GetCountry("de", "tlh")
+		if country.Name != "DoyIchlan" || country.Locale != "tlh" {
+			t.Errorf("Get country expect added locale, got %q", country.Name)
+		}
+
+		if err := iCountry.DeleteCountryLocale(&domain.RCountryLocaleDelete{
+			CountryId: "de", Locale: "tlh",
+		}); err != nil {
+			t.Errorf("Delete country locale fail: %s", err)
+			return
+		}
+		country, _ = iCountry.GetCountry("de", "tlh")
+		if country.Name != "Germany" {
+			t.Errorf("Get country expect english name after delete, got %q", country.Name)
+		}
+	})
+
+	t.Run("test upsert country locale reject invalid locale", func(t *testing.T) {
+		_, err := iCountry.UpsertCountryLocale(&domain.RCountryLocaleUpsert{
+			CountryId: "de", Locale: "not a locale", Name: "name",
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Upsert country locale expect InvalidArgument, got %v", err)
+		}
+	})
+
+	t.Run("test resolve and lookup country id", func(t *testing.T) {
+		if id, err := iCountry.ResolveCountryId("DEU"); err != nil || id != "de" {
+			t.Errorf("Resolve country id expect de, got %q %v", id, err)
+		}
+		if _, err := iCountry.ResolveCountryId("Germany"); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Resolve country id expect InvalidArgument, got %v", err)
+		}
+		if id, ok := iCountry.LookupCountryId("viet nam"); !ok || id != "vn" {
+			t.Errorf("Lookup country id expect vn, got %q", id)
+		}
+		if _, ok := iCountry.LookupCountryId("Atlantis"); ok {
+			t.Errorf("Lookup country id expect no match")
+		}
+	})
+
+	t.Run("test list countries", func(t *testing.T) {
+		data, err := iCountry.ListCountries("en")
+		if err != nil {
+			t.Errorf("List countries fail: %s", err)
+			return
+		}
+		if len(data) < 249 || data[0].Name > data[1].Name {
+			t.Errorf("List countries expect every country ordered by name")
+		}
+	})
+}
+
+func testProjectCountryId(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	t.Run("test create normalize country id", func(t *testing.T) {
+		req := newCreateRequest()
+		req.CountryId = " VNM "
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail: %s", err)
+			return
+		}
+		data, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		if err != nil {
+			t.Errorf("Get project fail: %s", err)
+			return
+		}
+		if data.CountryId != "vn" || nil == data.Country || data.Country.Code != "VN" {
+			t.Errorf("Create expect country id vn got %q", data.CountryId)
+		}
+
+		_, err = service.Update(&domain.RProjectUpdate{
+			ProjectId: prj.Id,
+			Owner:     prj.Owner,
+			Location:  prj.Location,
+			CountryId: "AU",
+		})
+		if err != nil {
+			t.Errorf("Update project fail: %s", err)
+			return
+		}
+		data, _ = service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		if data.CountryId != "au" {
+			t.Errorf("Update expect country id au got %q", data.CountryId)
+		}
+	})
+
+	t.Run("test create and update reject unknown country", func(t *testing.T) {
+		req := newCreateRequest()
+		req.CountryId = "Vietnam"
+		_, err := service.Create(req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Create expect InvalidArgument got %v", err)
+		}
+
+		prj, err := service.Create(newCreateRequest())
+		if err != nil {
+			t.Errorf("Create new project fail: %s", err)
+			return
+		}
+		_, err = service.Update(&domain.RProjectUpdate{
+			ProjectId: prj.Id,
+			CountryId: "xx",
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Update expect InvalidArgument got %v", err)
+		}
+	})
+}
+
+func testProjectLocale(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	req := newCreateRequest()
+	req.OwnerId = "owner-locale"
+	req.CountryId = "de"
+	req.Descs = []*domain.RProjectUpdateDesc{
+		{Language: "en", Name: "English name", Desc: "English"},
+	}
+	prj, err := service.Create(req)
+	if err != nil {
+		t.Errorf("Create new project fail: %s", err)
+		return
+	}
+
+	t.Run("test get by id fallback to next locale", func(t *testing.T) {
+		data, err := service.GetById(&domain.RProjectGetById{
+			Id:      prj.Id,
+			Locales: []string{"vi", "en"},
+		})
+		if err != nil {
+			t.Errorf("Get project fail: %s", err)
+			return
+		}
+		if len(data.Descs) != 1 || data.Language != "en" {
+			t.Errorf("Get project expect en desc, got %q", data.Language)
+		}
+		if nil == data.Country || data.Country.Name != "Đức" || data.Country.Locale != "vi" {
+			t.Errorf("Get project expect vi country name, got %+v", data.Country)
+		}
+		if data.TypeName == "" {
+			t.Errorf("Get project expect a type name")
+		}
+	})
+
+	t.Run("test get list fallback to any desc", func(t *testing.T) {
+		_, data, err := service.GetList(&domain.RProjectGetList{
+			Owner:   "owner-locale",
+			Locales: []string{"fr"},
+		})
+		if err != nil {
+			t.Errorf("Get list fail: %s", err)
+			return
+		}
+		if len(data) != 1 || len(data[0].Descs) != 1 || data[0].Language != "en" {
+			t.Errorf("Get list expect the only desc")
+		}
+		if nil == data[0].Country || data[0].Country.Name != "Allemagne" {
+			t.Errorf("Get list expect fr country name, got %+v", data[0].Country)
+		}
+	})
+}
+
+func testProjectSpecSchema(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+
+	t.Run("test create validate specs", func(t *testing.T) {
+		req := newCreateRequest()
+		req.Type = int32(pb.ProjectType_PrjT_E)
+		req.Specs.Specs = map[string]float64{"digester_volume": -1, "unknown": 1}
+		_, err := service.Create(req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Create expect InvalidArgument got %v", err)
+			return
+		}
+		for _, it := range []string{"digester_volume must be at least", "generator_capacity_kw is required", "unknown is not a spec"} {
+			if !strings.Contains(err.Error(), it) {
+				t.Errorf("Create error expect %q in %q", it, err.Error())
+			}
+		}
+	})
+
+	t.Run("test update specs validate against project type", func(t *testing.T) {
+		req := newCreateRequest()
+		req.Type = int32(pb.ProjectType_PrjT_E)
+		req.Specs.Specs = newSpecs(req.Type)
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail: %s", err)
+			return
+		}
+
+		var specs = newSpecs(req.Type)
+		specs["methane_content"] = 120
+		_, err = service.UpdateSpecs(&domain.RProjectUpdateSpecs{ProjectId: prj.Id, Specs: specs})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Update specs expect InvalidArgument got %v", err)
+		}
+
+		specs["methane_content"] = 60
+		if _, err := service.UpdateSpecs(&domain.RProjectUpdateSpecs{ProjectId: prj.Id, Specs: specs}); err != nil {
+			t.Errorf("Update specs fail: %s", err)
+		}
+	})
+
+	t.Run("test update type validate stored specs", func(t *testing.T) {
+		req := newCreateRequest()
+		req.Type = int32(pb.ProjectType_PrjT_E)
+		req.Specs.Specs = newSpecs(req.Type)
+		prj, err := service.Create(req)
+		if err != nil {
+			t.Errorf("Create new project fail: %s", err)
+			return
+		}
+
+		var update = &domain.RProjectUpdate{ProjectId: prj.Id, Type: int64(pb.ProjectType_PrjT_G), OwnerId: prj.OwnerId}
+		if _, err := service.Update(update); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Update to a type the specs do not fit expect InvalidArgument got %v", err)
+		}
+		project, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		utils.PanicError("", err)
+		if project.Type != int64(req.Type) {
+			t.Errorf("Refused update must keep type %d, got %d", req.Type, project.Type)
+		}
+
+		update.Type = int64(req.Type)
+		if _, err := service.Update(update); err != nil {
+			t.Errorf("Update keeping the type fail: %s", err)
+		}
+	})
+}
+
+func testProjectSpecsHistory(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	prj, err := service.Create(newTypedRequest())
+	utils.PanicError("", err)
+
+	var created = prj.CreatedAt
+	var backdated = created.Add(-48 * time.Hour)
+	_, err = service.UpdateSpecs(&domain.RProjectUpdateSpecs{
+		Audit:     domain.Audit{Actor: "test"},
+		ProjectId: prj.Id,
+		Specs:     newCapacitySpecs(2),
+	})
+	utils.PanicError("", err)
+	_, err = service.UpdateSpecs(&domain.RProjectUpdateSpecs{
+		Audit:         domain.Audit{Actor: "test"},
+		ProjectId:     prj.Id,
+		Specs:         newCapacitySpecs(0),
+		EffectiveFrom: backdated,
+	})
+	utils.PanicError("", err)
+
+	t.Run("backdated specs keep the latest value current", func(t *testing.T) {
+		data, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		utils.PanicError("", err)
+		if data.Specs == nil || data.Specs.Specs["capacity_kw"] != 2 {
+			t.Errorf("Current specs expect capacity_kw=2, got %+v", data.Specs)
+		}
+	})
+
+	t.Run("specs at a time", func(t *testing.T) {
+		var cases = map[time.Time]float64{
+			backdated.Add(time.Hour): 0,
+			created.Add(-time.Hour):  0,
+			time.Now():               2,
+		}
+		for at, expect := range cases {
+			version, err := service.GetSpecsAt(&domain.RProjectSpecsAt{ProjectId: prj.Id, At: at})
+			if nil != err {
+				t.Errorf("GetSpecsAt(%s) fail: %s", at, err)
+				continue
+			}
+			if version.Specs["capacity_kw"] != expect {
+				t.Errorf("GetSpecsAt(%s) expect capacity_kw=%v got %v", at, expect, version.Specs["capacity_kw"])
+			}
+		}
+		if _, err := service.GetSpecsAt(&domain.RProjectSpecsAt{
+			ProjectId: prj.Id,
+			At:        backdated.Add(-time.Hour),
+		}); err != domain.ErrSpecsNotFound {
+			t.Errorf("GetSpecsAt before any version expect ErrSpecsNotFound, got %v", err)
+		}
+	})
+
+	t.Run("specs history", func(t *testing.T) {
+		data, count, err := service.GetSpecsHistory(&domain.RProjectSpecsHistory{ProjectId: prj.Id})
+		utils.PanicError("", err)
+		if count != 3 || len(data) != 3 {
+			t.Fatalf("Specs history expect 3 versions, got %d", count)
+		}
+		if data[0].Specs["capacity_kw"] != 2 || data[2].Specs["capacity_kw"] != 0 || data[2].Actor != "test" {
+			t.Errorf("Specs history expect newest effective first")
+		}
+
+		data, count, err = service.GetSpecsHistory(&domain.RProjectSpecsHistory{
+			ProjectId: prj.Id,
+			To:        created.Add(-time.Hour),
+		})
+		utils.PanicError("", err)
+		if count != 1 || data[0].Specs["capacity_kw"] != 0 {
+			t.Errorf("Specs history before creation expect the backdated version")
+		}
+	})
+
+	t.Run("specs of a deleted project are hidden", func(t *testing.T) {
+		deleted, err := service.Create(newCreateRequest())
+		utils.PanicError("", err)
+		utils.PanicError("", service.DeleteProject(&domain.RProjectDelete{ProjectId: deleted.Id}))
+		if _, err := service.GetSpecsAt(&domain.RProjectSpecsAt{ProjectId: deleted.Id}); err == nil {
+			t.Errorf("GetSpecsAt of a deleted project must fail")
+		}
+		if _, _, err := service.GetSpecsHistory(&domain.RProjectSpecsHistory{ProjectId: deleted.Id}); err == nil {
+			t.Errorf("GetSpecsHistory of a deleted project must fail")
+		}
+	})
+
+	t.Run("specs can not take effect in the future", func(t *testing.T) {
+		_, err := service.UpdateSpecs(&domain.RProjectUpdateSpecs{
+			ProjectId:     prj.Id,
+			Specs:         newCapacitySpecs(3),
+			EffectiveFrom: time.Now().Add(time.Hour),
+		})
+		if err != domain.ErrInvalidEffectiveFrom {
+			t.Errorf("Future effective-from expect ErrInvalidEffectiveFrom, got %v", err)
+		}
+	})
+}
+
+func testProjectImages(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	prj, err := service.Create(newCreateRequest())
+	utils.PanicError("", err)
+
+	var ids = make([]int64, 0)
+	for _, path := range []string{"/a.png", "/b.png", "/c.png"} {
+		img, err := service.AddImage(&domain.RProjectAddImage{ProjectId: prj.Id, ImgPath: path})
+		if nil != err || nil == img || img.Id == 0 {
+			t.Fatalf("AddImage expect the created image, got %v %v", img, err)
+		}
+		ids = append(ids, img.Id)
+	}
+
+	t.Run("images keep the order they were added in", func(t *testing.T) {
+		data, err := service.ListImages(&domain.RProjectImageList{ProjectId: prj.Id})
+		utils.PanicError("", err)
+		if len(data) != 3 || data[0].Image != "/a.png" || data[2].Image != "/c.png" {
+			t.Errorf("ListImages order is wrong: %+v", data)
+		}
+	})
+
+	t.Run("reorder images", func(t *testing.T) {
+		if _, err := service.ReorderImages(&domain.RProjectImageReorder{
+			ProjectId: prj.Id,
+			ImageIds:  []int64{ids[2], ids[0]},
+		}); err != domain.ErrInvalidImageOrder {
+			t.Errorf("Partial order expect ErrInvalidImageOrder, got %v", err)
+		}
+		_, err := service.ReorderImages(&domain.RProjectImageReorder{
+			ProjectId: prj.Id,
+			ImageIds:  []int64{ids[2], ids[0], ids[1]},
+		})
+		utils.PanicError("", err)
+		data, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		utils.PanicError("", err)
+		if len(data.Images) != 3 || data.Images[0].Id != ids[2] || data.Images[2].Id != ids[1] {
+			t.Errorf("GetById expect the new gallery order, got %+v", data.Images)
+		}
+	})
+
+	t.Run("caption and thumbnail", func(t *testing.T) {
+		img, err := service.UpdateImageCaption(&domain.RProjectImageCaption{
+			ProjectId: prj.Id,
+			ImageId:   ids[0],
+			Caption:   "Digester",
+		})
+		utils.PanicError("", err)
+		if img.Caption != "Digester" {
+			t.Errorf("UpdateImageCaption expect the new caption")
+		}
+		_, err = service.SetThumbnail(&domain.RProjectSetThumbnail{ProjectId: prj.Id, ImageId: ids[1]})
+		utils.PanicError("", err)
+		data, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		utils.PanicError("", err)
+		if data.Thumbnail != "/b.png" || data.Images[1].Caption != "Digester" {
+			t.Errorf("GetById expect thumbnail /b.png and caption, got %q %+v", data.Thumbnail, data.Images[1])
+		}
+	})
+
+	t.Run("delete image", func(t *testing.T) {
+		utils.PanicError("", service.DeleteImage(&domain.RProjectImageDelete{ProjectId: prj.Id, ImageId: ids[0]}))
+		if err := service.DeleteImage(&domain.RProjectImageDelete{
+			ProjectId: prj.Id,
+			ImageId:   ids[0],
+		}); err != domain.ErrImageNotFound {
+			t.Errorf("Deleting twice expect ErrImageNotFound, got %v", err)
+		}
+		data, err := service.ListImages(&domain.RProjectImageList{ProjectId: prj.Id})
+		utils.PanicError("", err)
+		if len(data) != 2 {
+			t.Errorf("ListImages expect 2 images after delete, got %d", len(data))
+		}
+		img, err := service.AddImage(&domain.RProjectAddImage{ProjectId: prj.Id, ImgPath: "/d.png"})
+		utils.PanicError("", err)
+		if img.Position <= data[1].Position {
+			t.Errorf("AddImage expect the image at the end of the gallery")
+		}
+	})
+
+	t.Run("image edits bump the version and delete the thumbnail", func(t *testing.T) {
+		before, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		utils.PanicError("", err)
+		_, err = service.UpdateImageCaption(&domain.RProjectImageCaption{ProjectId: prj.Id, ImageId: ids[2], Caption: "Site"})
+		utils.PanicError("", err)
+		_, err = service.ReorderImages(&domain.RProjectImageReorder{
+			ProjectId: prj.Id,
+			ImageIds:  imageIds(before.Images),
+		})
+		utils.PanicError("", err)
+		utils.PanicError("", service.DeleteImage(&domain.RProjectImageDelete{ProjectId: prj.Id, ImageId: ids[1]}))
+
+		data, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+		utils.PanicError("", err)
+		if data.Version != before.Version+3 {
+			t.Errorf("Expect version %d after 3 image edits, got %d", before.Version+3, data.Version)
+		}
+		if data.Thumbnail != "/c.png" {
+			t.Errorf("Deleting the thumbnail expect the first image in its place, got %q", data.Thumbnail)
+		}
+	})
+}
+
+func testProjectDocumentVersions(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	prj, err := service.Create(newCreateRequest())
+	utils.PanicError("", err)
+
+	var upsert = func(actor, url string, id int64) *domain.ProjectDocument {
+		docs, err := service.UpsertDocument(&domain.RProjectDocumentUpsert{
+			Audit: domain.Audit{Actor: actor},
+			Document: []*domain.Document{
+				{Id: id, ProjectId: prj.Id, DocumentName: "Permit", Url: url, DocumentType: "permit"},
+			},
+		})
+		utils.PanicError("", err)
+		return docs[0]
+	}
+	var doc = upsert("alice", "/permit-1.pdf", 0)
+	if doc.Version != 1 {
+		t.Fatalf("New document expect version 1, got %d", doc.Version)
+	}
+	if doc = upsert("bob", "/permit-2.pdf", doc.Id); doc.Version != 2 {
+		t.Fatalf("Replaced document expect version 2, got %d", doc.Version)
+	}
+	if doc = upsert("bob", "/permit-2.pdf", doc.Id); doc.Version != 2 {
+		t.Errorf("Unchanged document expect to keep version 2, got %d", doc.Version)
+	}
+
+	t.Run("list versions", func(t *testing.T) {
+		data, count, err := service.ListDocumentVersions(&domain.RProjectDocumentVersionList{DocumentId: doc.Id})
+		utils.PanicError("", err)
+		if count != 2 || data[0].Version != 2 || data[0].UploadedBy != "bob" ||
+			data[1].Url != "/permit-1.pdf" || data[1].UploadedBy != "alice" {
+			t.Errorf("ListDocumentVersions is wrong: %+v", data)
+		}
+	})
+
+	t.Run("restore version", func(t *testing.T) {
+		restored, err := service.RestoreDocumentVersion(&domain.RProjectDocumentRestore{
+			Audit:      domain.Audit{Actor: "carol"},
+			DocumentId: doc.Id,
+			Version:    1,
+		})
+		utils.PanicError("", err)
+		if restored.Version != 3 || restored.Url != "/permit-1.pdf" {
+			t.Errorf("Restore expect version 3 with the first file, got %+v", restored)
+		}
+		data, _, err := service.ListDocument(&domain.RProjectDocumentList{Ids: []int64{doc.Id}})
+		utils.PanicError("", err)
+		if len(data) != 1 || data[0].Version != 3 || data[0].Url != "/permit-1.pdf" {
+			t.Errorf("ListDocument expect the current version")
+		}
+		if _, err := service.RestoreDocumentVersion(&domain.RProjectDocumentRestore{
+			DocumentId: doc.Id,
+			Version:    9,
+		}); err != domain.ErrDocumentVersionNotFound {
+			t.Errorf("Unknown version expect ErrDocumentVersionNotFound, got %v", err)
+		}
+	})
+}
+
+func testProjectDocumentCompleteness(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	var req = newCreateRequest()
+	req.Type = int32(pb.ProjectType_PrjT_E)
+	req.Specs.Specs = newSpecs(req.Type)
+	prj, err := service.Create(req)
+	utils.PanicError("", err)
+
+	var activate = func() error {
+		return service.ChangeStatus(&domain.RProjectChangeStatus{
+			ProjectId:        prj.Id,
+			Status:           domain.ProjectStatusActived,
+			RequireDocuments: true,
+		})
+	}
+	var expect = domain.RequiredDocuments(int64(req.Type), "")
+
+	completeness, err := service.GetDocumentCompleteness(&domain.RProjectDocumentCompleteness{ProjectId: prj.Id})
+	utils.PanicError("", err)
+	if len(completeness.Missing) != len(expect) || completeness.Complete() {
+		t.Errorf("New project expect %v missing, got %v", expect, completeness.Missing)
+	}
+	if err := activate(); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Activate incomplete project expect FailedPrecondition, got %v", err)
+	}
+
+	var documents = make([]*domain.Document, 0, len(expect))
+	for _, key := range expect {
+		documents = append(documents, &domain.Document{
+			ProjectId: prj.Id, DocumentName: key, Url: "/" + key + ".pdf", DocumentType: key,
+		})
+	}
+	docs, err := service.UpsertDocument(&domain.RProjectDocumentUpsert{Document: documents})
+	utils.PanicError("", err)
+	utils.PanicError("", service.DeleteDocument(&domain.RProjectDocumentDelete{Id: []int64{docs[0].Id}}))
+
+	completeness, err = service.GetDocumentCompleteness(&domain.RProjectDocumentCompleteness{ProjectId: prj.Id})
+	utils.PanicError("", err)
+	if len(completeness.Missing) != 1 || completeness.Missing[0] != docs[0].DocumentType {
+		t.Errorf("Deleted %s expect missing, got %v", docs[0].DocumentType, completeness.Missing)
+	}
+
+	_, err = service.UpsertDocument(&domain.RProjectDocumentUpsert{Document: documents[:1]})
+	utils.PanicError("", err)
+	if err := activate(); nil != err {
+		t.Errorf("Activate complete project fail: %s", err)
+	}
+	project, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+	utils.PanicError("", err)
+	if project.Status != domain.ProjectStatusActived {
+		t.Errorf("Project expect actived, got %d", project.Status)
+	}
+}
+
+func testProjectDocumentFilter(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	var projects = make([]int64, 0, 2)
+	for i := 0; i < 2; i++ {
+		prj, err := service.Create(newCreateRequest())
+		utils.PanicError("", err)
+		projects = append(projects, prj.Id)
+	}
+	var start = time.Now().Add(-time.Second)
+	docs, err := service.UpsertDocument(&domain.RProjectDocumentUpsert{
+		Document: []*domain.Document{
+			{ProjectId: projects[0], DocumentName: "Design 100%", Url: "/pdd.pdf", DocumentType: "pdd"},
+			{ProjectId: projects[0], DocumentName: "Impact", Url: "/eia.pdf", DocumentType: "eia"},
+			{ProjectId: projects[1], DocumentName: "Another design", Url: "/pdd-2.pdf", DocumentType: "pdd"},
+		},
+	})
+	utils.PanicError("", err)
+
+	var names = func(req *domain.RProjectDocumentList) []string {
+		data, _, err := service.ListDocument(req)
+		utils.PanicError("", err)
+		var rs = make([]string, 0, len(data))
+		for _, it := range data {
+			rs = append(rs, it.Name)
+		}
+		return rs
+	}
+	var cases = []struct {
+		name   string
+		req    *domain.RProjectDocumentList
+		expect []string
+	}{
+		{"ids", &domain.RProjectDocumentList{Ids: []int64{docs[0].Id, docs[2].Id}, Sort: domain.DocumentSortNameAsc},
+			[]string{"Another design", "Design 100%"}},
+		{"project", &domain.RProjectDocumentList{ProjectIds: projects[:1], Sort: domain.DocumentSortNameDesc},
+			[]string{"Impact", "Design 100%"}},
+		{"type", &domain.RProjectDocumentList{ProjectIds: projects, DocumentTypes: []string{"eia"}},
+			[]string{"Impact"}},
+		{"name", &domain.RProjectDocumentList{ProjectIds: projects, Name: "DESIGN", Sort: domain.DocumentSortNameAsc},
+			[]string{"Another design", "Design 100%"}},
+		{"name escapes like", &domain.RProjectDocumentList{ProjectIds: projects, Name: "0%"},
+			[]string{"Design 100%"}},
+		{"created range", &domain.RProjectDocumentList{ProjectIds: projects, CreatedFrom: start, CreatedTo: start.Add(time.Hour)},
+			[]string{"Another design", "Impact", "Design 100%"}},
+		{"updated range", &domain.RProjectDocumentList{ProjectIds: projects, UpdatedTo: start}, []string{}},
+	}
+	for _, it := range cases {
+		if rs := names(it.req); strings.Join(rs, ",") != strings.Join(it.expect, ",") {
+			t.Errorf("List documents by %s expect %v got %v", it.name, it.expect, rs)
+		}
+	}
+
+	if _, _, err := service.ListDocument(&domain.RProjectDocumentList{
+		Sort: domain.DocumentSortNameAsc, Cursor: domain.NewCursor(time.Now(), 1).Encode(),
+	}); err != domain.ErrDocumentSortCursor {
+		t.Errorf("Cursor with name sort expect ErrDocumentSortCursor, got %v", err)
+	}
+	if _, _, err := service.ListDocument(&domain.RProjectDocumentList{Sort: 42}); err != domain.ErrDocumentSort {
+		t.Errorf("Unknown sort expect ErrDocumentSort, got %v", err)
+	}
+}
+
+func testProjectPurgeDocuments(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	prj, err := service.Create(newCreateRequest())
+	utils.PanicError("", err)
+
+	var upsert = func(doc *domain.Document) *domain.ProjectDocument {
+		doc.ProjectId = prj.Id
+		docs, err := service.UpsertDocument(&domain.RProjectDocumentUpsert{Document: []*domain.Document{doc}})
+		utils.PanicError("", err)
+		return docs[0]
+	}
+	var uploaded = upsert(&domain.Document{DocumentName: "PDD", Url: "/pdd-1.pdf", Checksum: "c1", DocumentType: "pdd"})
+	upsert(&domain.Document{Id: uploaded.Id, DocumentName: "PDD", Url: "/pdd-2.pdf", Checksum: "c2", DocumentType: "pdd"})
+	var linked = upsert(&domain.Document{DocumentName: "Link", Url: "https://example.com/eia.pdf", DocumentType: "eia"})
+	var shared = upsert(&domain.Document{DocumentName: "Copy", Url: "/shared.pdf", Checksum: "c3", DocumentType: "permit"})
+	var kept = upsert(&domain.Document{DocumentName: "Kept", Url: "/shared.pdf", Checksum: "c3", DocumentType: "permit"})
+	utils.PanicError("", service.DeleteDocument(&domain.RProjectDocumentDelete{
+		Id: []int64{uploaded.Id, linked.Id, shared.Id},
+	}))
+
+	report, err := service.PurgeDocuments(&domain.RDocumentPurge{Before: time.Now().Add(-time.Hour)})
+	utils.PanicError("", err)
+	if len(report.Documents) != 0 {
+		t.Errorf("Documents deleted within retention must stay, got %d purged", len(report.Documents))
+	}
+
+	var before = time.Now().Add(time.Second)
+	report, err = service.PurgeDocuments(&domain.RDocumentPurge{Before: before, DryRun: true})
+	utils.PanicError("", err)
+	if len(report.Documents) != 3 || report.Documents[0].Id != uploaded.Id || report.Documents[0].Versions != 2 {
+		t.Errorf("Dry run expect 3 documents, the first with 2 versions, got %+v", report.Documents)
+	}
+	if strings.Join(report.Files, ",") != "/pdd-1.pdf,/pdd-2.pdf" {
+		t.Errorf("Expect only the uploaded files no row refers to, got %v", report.Files)
+	}
+	if _, count, _ := service.ListDocumentVersions(&domain.RProjectDocumentVersionList{DocumentId: uploaded.Id}); count != 2 {
+		t.Errorf("Dry run must keep the versions, got %d", count)
+	}
+
+	report, err = service.PurgeDocuments(&domain.RDocumentPurge{Before: before})
+	utils.PanicError("", err)
+	if len(report.Documents) != 3 || len(report.Files) != 2 {
+		t.Errorf("Purge expect 3 documents and 2 files, got %d and %d", len(report.Documents), len(report.Files))
+	}
+	if _, count, _ := service.ListDocumentVersions(&domain.RProjectDocumentVersionList{DocumentId: uploaded.Id}); count != 0 {
+		t.Errorf("Purge must remove the versions, got %d", count)
+	}
+	docs, _, err := service.ListDocument(&domain.RProjectDocumentList{ProjectIds: []int64{prj.Id}})
+	utils.PanicError("", err)
+	if len(docs) != 1 || docs[0].Id != kept.Id {
+		t.Errorf("Purge must keep the live document")
+	}
+
+	report, err = service.PurgeDocuments(&domain.RDocumentPurge{Before: before})
+	utils.PanicError("", err)
+	if len(report.Documents) != 0 {
+		t.Errorf("Second purge expect nothing, got %d documents", len(report.Documents))
+	}
+}
+
+func testProjectImport(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	var rows = func() []*domain.ImportRow {
+		var valid = newCreateRequest()
+		valid.OwnerId = "owner-import"
+		valid.Descs = append(valid.Descs, &domain.RProjectUpdateDesc{Language: "en", Name: "Name"})
+		var invalid = newCreateRequest()
+		invalid.OwnerId = "owner-import"
+		invalid.Descs = append(invalid.Descs, invalid.Descs[0])
+		return []*domain.ImportRow{
+			{Row: 1, Create: valid},
+			{Row: 2, Create: invalid},
+			{Row: 3, Err: errors.New("unreadable")},
+		}
+	}
+	var owned = func() int64 {
+		count, _, err := service.GetList(&domain.RProjectGetList{Owner: "owner-import"})
+		utils.PanicError("", err)
+		return *count
+	}
+
+	report, err := service.ImportProjects(&domain.RProjectImport{Rows: rows(), DryRun: true})
+	utils.PanicError("", err)
+	if report.Failed != 2 || report.Rows[0].Error != "" || owned() != 0 {
+		t.Errorf("Dry run expect 2 failed rows and no project, got %+v", report)
+	}
+
+	report, err = service.ImportProjects(&domain.RProjectImport{Rows: rows(), AllOrNothing: true})
+	utils.PanicError("", err)
+	if report.Created != 0 || report.Failed != 3 || owned() != 0 {
+		t.Errorf("All or nothing import expect every row failed, got %+v", report)
+	}
+
+	report, err = service.ImportProjects(&domain.RProjectImport{Rows: rows()})
+	utils.PanicError("", err)
+	if report.Created != 1 || report.Failed != 2 || report.Rows[0].ProjectId == 0 {
+		t.Errorf("Import expect the valid row created, got %+v", report)
+	}
+	prj, err := service.GetById(&domain.RProjectGetById{Id: report.Rows[0].ProjectId})
+	utils.PanicError("", err)
+	if len(prj.Descs) != 2 || nil == prj.Specs {
+		t.Errorf("Imported project expect 2 descs and specs")
+	}
+
+	if _, err := service.ImportProjects(&domain.RProjectImport{}); err != domain.ErrImportEmpty {
+		t.Errorf("Empty import expect ErrImportEmpty, got %v", err)
+	}
+}
+
+func testProjectStatusTransitions(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	var req = newCreateRequest()
+	req.OwnerId = "owner-status"
+	prj, err := service.Create(req)
+	utils.PanicError("", err)
+
+	var change = func(actor string, to domain.ProjectStatus, reason string) error {
+		return service.ChangeStatus(&domain.RProjectChangeStatus{
+			Audit:     domain.Audit{Actor: actor, Action: "/pb.ProjectService/ChangeStatus"},
+			ProjectId: prj.Id,
+			Status:    to,
+			Reason:    reason,
+		})
+	}
+	if err := change("reviewer", domain.ProjectStatusReject, " "); err != domain.ErrStatusReason {
+		t.Errorf("Reject without reason expect ErrStatusReason, got %v", err)
+	}
+	if err := change("reviewer", domain.ProjectStatus(7), ""); err != domain.ErrInvalidStatus {
+		t.Errorf("Unknown status expect ErrInvalidStatus, got %v", err)
+	}
+	utils.PanicError("", change("reviewer", domain.ProjectStatusReject, "Missing land rights"))
+
+	project, err := service.GetById(&domain.RProjectGetById{Id: prj.Id})
+	utils.PanicError("", err)
+	if project.Status != domain.ProjectStatusReject || project.StatusReason != "Missing land rights" ||
+		project.StatusBy != "reviewer" || nil == project.StatusAt {
+		t.Errorf("Unexpected review %d %q %q %v", project.Status, project.StatusReason, project.StatusBy, project.StatusAt)
+	}
+	if err := change("reviewer", domain.ProjectStatusActived, ""); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Reject to actived expect FailedPrecondition, got %v", err)
+	}
+	var resubmit = func(actor string) error {
+		return service.ChangeStatus(&domain.RProjectChangeStatus{
+			Audit:     domain.Audit{Actor: actor, Action: "/pb.ProjectService/ResubmitProject"},
+			ProjectId: prj.Id,
+			Status:    domain.ProjectStatusRegister,
+			Resubmit:  true,
+		})
+	}
+	if err := resubmit("someone"); err != domain.ErrResubmitByOwner {
+		t.Errorf("Resubmit by another user expect ErrResubmitByOwner, got %v", err)
+	}
+	utils.PanicError("", resubmit("owner-status"))
+	if err := resubmit("owner-status"); err != domain.ErrResubmitStatus {
+		t.Errorf("Resubmit of a project in review expect ErrResubmitStatus, got %v", err)
+	}
+
+	// Reviewers reopen a rejected project without the owner.
+	utils.PanicError("", change("reviewer", domain.ProjectStatusReject, "Wrong area"))
+	utils.PanicError("", change("reviewer", domain.ProjectStatusRegister, ""))
+	utils.PanicError("", change("reviewer", domain.ProjectStatusActived, ""))
+
+	project, err = service.GetById(&domain.RProjectGetById{Id: prj.Id})
+	utils.PanicError("", err)
+	if project.Status != domain.ProjectStatusActived || project.StatusReason != "" {
+		t.Errorf("Actived project expect no reason, got %d %q", project.Status, project.StatusReason)
+	}
+	if err := change("reviewer", domain.ProjectStatusActived, ""); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Actived to actived expect FailedPrecondition, got %v", err)
+	}
+
+	data, _, err := service.GetHistory(&domain.RProjectHistoryList{ProjectId: prj.Id, Limit: 10})
+	utils.PanicError("", err)
+	var reasons = 0
+	for _, it := range data {
+		if nil != it.Diff["statusReason"] {
+			reasons++
+		}
+	}
+	if reasons != 4 {
+		t.Errorf("Expect the reason set and cleared in history, got %d changes", reasons)
+	}
+}
+
+func testProjectPurgeDeletedProject(t *testing.T, newRepo repoFactory) {
+	service := newRepo(t)
+	prj, err := service.Create(newCreateRequest())
+	utils.PanicError("", err)
+	docs, err := service.UpsertDocument(&domain.RProjectDocumentUpsert{Document: []*domain.Document{
+		{ProjectId: prj.Id, DocumentName: "PDD", Url: "/pdd.pdf", Checksum: "c1", DocumentType: "pdd"},
+		{ProjectId: prj.Id, DocumentName: "Old", Url: "/old.pdf", Checksum: "c2", DocumentType: "eia"},
+	}})
+	utils.PanicError("", err)
+	utils.PanicError("", service.DeleteDocument(&domain.RProjectDocumentDelete{Id: []int64{docs[1].Id}}))
+	utils.PanicError("", service.DeleteProject(&domain.RProjectDelete{ProjectId: prj.Id}))
+
+	report, err := service.PurgeDocuments(&domain.RDocumentPurge{Before: time.Now().Add(time.Hour)})
+	utils.PanicError("", err)
+	if len(report.Documents) != 1 || report.Documents[0].Id != docs[1].Id {
+		t.Errorf("Expect only the document deleted on its own purged, got %+v", report.Documents)
+	}
+
+	utils.PanicError("", service.RestoreProject(&domain.RProjectRestore{ProjectId: prj.Id}))
+	data, _, err := service.ListDocument(&domain.RProjectDocumentList{ProjectIds: []int64{prj.Id}})
+	utils.PanicError("", err)
+	if len(data) != 1 || data[0].Id != docs[0].Id {
+		t.Errorf("Restored project expect its document back, got %d documents", len(data))
+	}
+}
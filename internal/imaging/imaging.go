@@ -0,0 +1,143 @@
+// Package imaging builds the resized variants of project images and strips
+// metadata from uploaded files. It is pure Go, so variants are encoded as
+// JPEG; WebP is only decoded.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Quality of the encoded variants.
+const Quality = 82
+
+// Variant is a size served to clients. The longest side of the variant is
+// at most MaxSide, smaller images are not enlarged.
+type Variant struct {
+	Name    string
+	MaxSide int
+}
+
+var Variants = []Variant{
+	{Name: "thumbnail", MaxSide: 320},
+	{Name: "medium", MaxSide: 960},
+	{Name: "large", MaxSide: 1920},
+}
+
+// MaxPixels bounds the width times height of a decoded image. A decoded
+// image takes 4 bytes per pixel, and Orient may need a second copy.
+var MaxPixels = 40_000_000
+
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrTooLarge    = errors.New("image has too many pixels")
+)
+
+// Decode decodes a JPEG, PNG or WebP image and applies its EXIF
+// orientation, so the result is upright. The size declared in the header
+// is checked against MaxPixels before anything is decoded.
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrUnsupported
+	}
+	if nil != err {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > MaxPixels/config.Height {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrUnsupported
+	}
+	if nil != err {
+		return nil, err
+	}
+	return Orient(img, Orientation(data)), nil
+}
+
+// Resize scales img so its longest side is at most maxSide.
+func Resize(img image.Image, maxSide int) image.Image {
+	var b = img.Bounds()
+	var w, h = b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	if w >= h {
+		w, h = maxSide, h*maxSide/w
+	} else {
+		w, h = w*maxSide/h, maxSide
+	}
+	if w == 0 {
+		w = 1
+	}
+	if h == 0 {
+		h = 1
+	}
+	var dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// EncodeJPEG encodes img without metadata. Transparent areas become white
+// rather than black.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var b = img.Bounds()
+	var flat = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: Quality}); nil != err {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Orient transforms img as the EXIF orientation o asks for. Unknown values
+// leave img unchanged.
+func Orient(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+	var b = img.Bounds()
+	var w, h = b.Dx(), b.Dy()
+	var dst *image.RGBA
+	if o >= 5 {
+		dst = image.NewRGBA(image.Rect(0, 0, h, w))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,149 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func newImage(w, h int) *image.RGBA {
+	var img = image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 40), uint8(y * 40), 0, 255})
+		}
+	}
+	return img
+}
+
+// newJPEG encodes a w x h JPEG carrying orientation o and a private EXIF
+// segment.
+func newJPEG(t *testing.T, w, h, o int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, newImage(w, h), nil); nil != err {
+		t.Fatal(err)
+	}
+	var data = buf.Bytes()
+	var private = jpegSegment(0xE1, append(append([]byte{}, exifMagic...), "MM\x00\x2Asecret-gps"...))
+	var rs = append([]byte{}, jpegSOI...)
+	rs = append(rs, orientationSegment(o)...)
+	rs = append(rs, private...)
+	return append(rs, data[2:]...)
+}
+
+func TestStripJPEG(t *testing.T) {
+	var data = newJPEG(t, 4, 2, 6)
+	if Orientation(data) != 6 {
+		t.Fatalf("Orientation expect 6, got %d", Orientation(data))
+	}
+
+	var stripped = StripMetadata(data)
+	if bytes.Contains(stripped, []byte("secret-gps")) {
+		t.Errorf("StripMetadata expect the EXIF removed")
+	}
+	if Orientation(stripped) != 6 {
+		t.Errorf("StripMetadata expect the orientation kept, got %d", Orientation(stripped))
+	}
+	img, err := Decode(stripped)
+	if nil != err {
+		t.Fatalf("Decode stripped JPEG fail: %s", err)
+	}
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 4 {
+		t.Errorf("Decode expect the image rotated to 2x4, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newImage(3, 3)); nil != err {
+		t.Fatal(err)
+	}
+	var data = buf.Bytes()
+
+	// Insert a tEXt chunk after IHDR, which is 8 + 25 bytes in.
+	var text = []byte("Comment\x00secret")
+	var chunk = make([]byte, 8, 12+len(text))
+	binary.BigEndian.PutUint32(chunk, uint32(len(text)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	var withText = append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+
+	var stripped = StripMetadata(withText)
+	if !bytes.Equal(stripped, data) {
+		t.Errorf("StripMetadata expect the tEXt chunk removed")
+	}
+}
+
+func TestStripMetadataUnknown(t *testing.T) {
+	var data = []byte("%PDF-1.4")
+	if !bytes.Equal(StripMetadata(data), data) {
+		t.Errorf("StripMetadata expect other files unchanged")
+	}
+}
+
+func TestResize(t *testing.T) {
+	var cases = []struct {
+		w, h, max        int
+		expectW, expectH int
+	}{
+		{4000, 1000, 320, 320, 80},
+		{1000, 4000, 320, 80, 320},
+		{200, 100, 320, 200, 100},
+		{2000, 1, 320, 320, 1},
+	}
+	for _, c := range cases {
+		var b = Resize(image.NewRGBA(image.Rect(0, 0, c.w, c.h)), c.max).Bounds()
+		if b.Dx() != c.expectW || b.Dy() != c.expectH {
+			t.Errorf("Resize %dx%d to %d expect %dx%d, got %dx%d",
+				c.w, c.h, c.max, c.expectW, c.expectH, b.Dx(), b.Dy())
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	var img = newImage(3, 2)
+	var corner = img.At(0, 0)
+	var cases = map[int]image.Point{
+		1: {0, 0}, 2: {2, 0}, 3: {2, 1}, 4: {0, 1},
+		5: {0, 0}, 6: {1, 0}, 7: {1, 2}, 8: {0, 2},
+	}
+	for o, at := range cases {
+		if got := Orient(img, o).At(at.X, at.Y); got != corner {
+			t.Errorf("Orient %d expect the top-left pixel at %v", o, at)
+		}
+	}
+}
+
+func TestEncodeJPEG(t *testing.T) {
+	data, err := EncodeJPEG(newImage(5, 5))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err := Decode(data); nil != err {
+		t.Errorf("EncodeJPEG expect a decodable JPEG: %s", err)
+	}
+	if _, err := Decode([]byte("%PDF-1.4")); err != ErrUnsupported {
+		t.Errorf("Decode expect ErrUnsupported, got %v", err)
+	}
+}
+
+func TestDecodeTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newImage(1, 1)); nil != err {
+		t.Fatal(err)
+	}
+	// The IHDR chunk declares 50000x50000 pixels, the data stays 1x1.
+	var data = buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 50000)
+	binary.BigEndian.PutUint32(data[20:], 50000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	if _, err := Decode(data); err != ErrTooLarge {
+		t.Errorf("Decode expect ErrTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const orientationTag = 0x0112
+
+var (
+	jpegSOI   = []byte{0xFF, 0xD8}
+	pngMagic  = []byte("\x89PNG\r\n\x1a\n")
+	exifMagic = []byte("Exif\x00\x00")
+)
+
+// StripMetadata removes EXIF, XMP, IPTC and text metadata from a JPEG, PNG
+// or WebP file without re-encoding it. A JPEG keeps its orientation in a
+// minimal EXIF segment so it still displays upright. Other files, and files
+// that fail to parse, are returned unchanged.
+func StripMetadata(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, jpegSOI):
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngMagic):
+		return stripPNG(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return stripWebP(data)
+	}
+	return data
+}
+
+// Orientation returns the EXIF orientation of a JPEG, 1 when it has none.
+func Orientation(data []byte) int {
+	if !bytes.HasPrefix(data, jpegSOI) {
+		return 1
+	}
+	var o = 1
+	walkJPEG(data, func(marker byte, payload []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(payload, exifMagic) {
+			if v := exifOrientation(payload[len(exifMagic):]); v != 0 {
+				o = v
+			}
+		}
+		return true
+	})
+	return o
+}
+
+// walkJPEG calls fn for each segment before the image data. It returns
+// the offset of the start of scan marker, or -1 when the file is
+// malformed.
+func walkJPEG(data []byte, fn func(marker byte, payload []byte) bool) int {
+	var i = 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return -1
+		}
+		var marker = data[i+1]
+		if marker == 0xDA { // Start of scan
+			return i
+		}
+		var size = int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return -1
+		}
+		if !fn(marker, data[i+4:i+2+size]) {
+			return -1
+		}
+		i += 2 + size
+	}
+	return -1
+}
+
+func stripJPEG(data []byte) []byte {
+	var jfif []byte
+	var segments bytes.Buffer
+	var sos = walkJPEG(data, func(marker byte, payload []byte) bool {
+		if !keepJPEG(marker) {
+			return true
+		}
+		if marker == 0xE0 && nil == jfif {
+			jfif = jpegSegment(marker, payload)
+			return true
+		}
+		segments.Write(jpegSegment(marker, payload))
+		return true
+	})
+	if sos < 0 {
+		return data
+	}
+
+	// JFIF must directly follow SOI, the orientation comes next.
+	var out = bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(jpegSOI)
+	out.Write(jfif)
+	if o := Orientation(data); o != 1 {
+		out.Write(orientationSegment(o))
+	}
+	out.Write(segments.Bytes())
+	out.Write(data[sos:])
+	return out.Bytes()
+}
+
+// keepJPEG tells whether a segment affects rendering. Application segments
+// other than JFIF (APP0), the ICC profile (APP2) and the Adobe color
+// transform (APP14) only hold metadata, as do comments.
+func keepJPEG(marker byte) bool {
+	if marker >= 0xE0 && marker <= 0xEF {
+		return marker == 0xE0 || marker == 0xE2 || marker == 0xEE
+	}
+	return marker != 0xFE
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	var seg = []byte{0xFF, marker, 0x00, 0x00}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// exifOrientation reads tag 0x0112 from IFD0 of a TIFF structure, 0 when
+// it is missing.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	var ifd = int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	var count = int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		var entry = ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
+
+// orientationSegment builds an APP1 segment whose EXIF only holds the
+// orientation.
+func orientationSegment(o int) []byte {
+	var tiff = []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // Header, IFD0 at 8
+		0x00, 0x01, // One entry
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, // Orientation, SHORT, count 1
+		0x00, byte(o), 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, // No next IFD
+	}
+	return jpegSegment(0xE1, append(append([]byte{}, exifMagic...), tiff...))
+}
+
+// pngMetadata are the ancillary chunks dropped from PNG files.
+var pngMetadata = map[string]bool{
+	"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true,
+}
+
+func stripPNG(data []byte) []byte {
+	var out = bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngMagic)
+	var i = len(pngMagic)
+	for i+12 <= len(data) {
+		var size = int(binary.BigEndian.Uint32(data[i:]))
+		var end = i + 12 + size
+		if end > len(data) {
+			return data
+		}
+		if !pngMetadata[string(data[i+4:i+8])] {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	if i != len(data) {
+		return data
+	}
+	return out.Bytes()
+}
+
+func stripWebP(data []byte) []byte {
+	var out = bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+	var i = 12
+	for i+8 <= len(data) {
+		var id = string(data[i : i+4])
+		var size = int(binary.LittleEndian.Uint32(data[i+4:]))
+		var end = i + 8 + size + size%2 // Chunks are padded to even sizes
+		if end > len(data) {
+			return data
+		}
+		switch id {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			var chunk = append([]byte{}, data[i:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04 // EXIF and XMP flags
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	if i != len(data) {
+		return data
+	}
+	var rs = out.Bytes()
+	binary.LittleEndian.PutUint32(rs[4:], uint32(len(rs)-8))
+	return rs
+}
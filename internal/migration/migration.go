@@ -0,0 +1,288 @@
+package migration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TableName keeps track of applied versions.
+const TableName = "schema_migrations"
+
+// noTxMarker disables the wrapping transaction for a script, which is
+// required for statements like CREATE INDEX CONCURRENTLY. Such scripts are
+// run one statement at a time; each statement must end with ";" at the end
+// of a line.
+const noTxMarker = "-- migrate:no-transaction"
+
+//go:embed sql/*.sql
+var scripts embed.FS
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    ``
+	AppliedAt time.Time ``
+}
+
+func (*SchemaMigration) TableName() string { return TableName }
+
+// ErrSchemaBehind is returned by Check when there are pending migrations.
+type ErrSchemaBehind struct {
+	Pending []*Migration
+}
+
+func (e *ErrSchemaBehind) Error() string {
+	return fmt.Sprintf(
+		"database schema is behind: %d pending migration(s) from version %d, run `projects migrate up`",
+		len(e.Pending), e.Pending[0].Version,
+	)
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []*Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := load(scripts)
+	if nil != err {
+		return nil, err
+	}
+
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS ` + TableName + ` (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+	if nil != err {
+		return nil, fmt.Errorf("create %s: %w", TableName, err)
+	}
+
+	var m = &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+	return m, nil
+}
+
+// Migrations returns every known migration ordered by version.
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Applied returns the applied versions ordered by version.
+func (m *Migrator) Applied() ([]*SchemaMigration, error) {
+	var applied = make([]*SchemaMigration, 0)
+	var err = m.db.Order("version").Find(&applied).Error
+	if nil != err {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (m *Migrator) Pending() ([]*Migration, error) {
+	applied, err := m.Applied()
+	if nil != err {
+		return nil, err
+	}
+
+	var done = make(map[int64]bool, len(applied))
+	for _, it := range applied {
+		done[it.Version] = true
+	}
+
+	var pending = make([]*Migration, 0)
+	for _, it := range m.migrations {
+		if !done[it.Version] {
+			pending = append(pending, it)
+		}
+	}
+	return pending, nil
+}
+
+// Check returns *ErrSchemaBehind when the database is missing migrations.
+func (m *Migrator) Check() error {
+	pending, err := m.Pending()
+	if nil != err {
+		return err
+	}
+	if len(pending) > 0 {
+		return &ErrSchemaBehind{Pending: pending}
+	}
+	return nil
+}
+
+// Up applies every pending migration in order and returns those applied.
+func (m *Migrator) Up() ([]*Migration, error) {
+	pending, err := m.Pending()
+	if nil != err {
+		return nil, err
+	}
+
+	for i, it := range pending {
+		err = m.run(it.Up, func(tx *gorm.DB) error {
+			return tx.Create(&SchemaMigration{
+				Version:   it.Version,
+				Name:      it.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if nil != err {
+			return pending[:i], fmt.Errorf("migrate up %d_%s: %w", it.Version, it.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down rolls back the last steps applied migrations and returns those
+// rolled back.
+func (m *Migrator) Down(steps int) ([]*Migration, error) {
+	applied, err := m.Applied()
+	if nil != err {
+		return nil, err
+	}
+
+	var byVersion = make(map[int64]*Migration, len(m.migrations))
+	for _, it := range m.migrations {
+		byVersion[it.Version] = it
+	}
+
+	var reverted = make([]*Migration, 0, steps)
+	for i := len(applied) - 1; i >= 0 && len(reverted) < steps; i-- {
+		it, ok := byVersion[applied[i].Version]
+		if !ok {
+			return reverted, fmt.Errorf("migrate down %d: script not found", applied[i].Version)
+		}
+
+		err = m.run(it.Down, func(tx *gorm.DB) error {
+			return tx.Where("version = ?", it.Version).Delete(&SchemaMigration{}).Error
+		})
+		if nil != err {
+			return reverted, fmt.Errorf("migrate down %d_%s: %w", it.Version, it.Name, err)
+		}
+		reverted = append(reverted, it)
+	}
+	return reverted, nil
+}
+
+func (m *Migrator) run(script string, record func(tx *gorm.DB) error) error {
+	if strings.Contains(script, noTxMarker) {
+		for _, stmt := range splitStatements(script) {
+			if err := m.db.Exec(stmt).Error; nil != err {
+				return err
+			}
+		}
+		return record(m.db)
+	}
+
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(script).Error; nil != err {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+// splitStatements splits script on lines ending with ";" and drops parts
+// that only hold comments.
+func splitStatements(script string) []string {
+	var stmts = make([]string, 0)
+	var current = strings.Builder{}
+	var hasCode = false
+	for _, line := range strings.Split(script, "\n") {
+		var trimmed = strings.TrimSpace(line)
+		current.WriteString(line)
+		current.WriteString("\n")
+		if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			hasCode = true
+		}
+		if strings.HasSuffix(trimmed, ";") {
+			if hasCode {
+				stmts = append(stmts, current.String())
+			}
+			current.Reset()
+			hasCode = false
+		}
+	}
+	if hasCode {
+		stmts = append(stmts, current.String())
+	}
+	return stmts
+}
+
+// load reads scripts named <version>_<name>.up.sql and
+// <version>_<name>.down.sql. Every version needs both directions.
+func load(fsys fs.FS) ([]*Migration, error) {
+	files, err := fs.Glob(fsys, "sql/*.sql")
+	if nil != err {
+		return nil, err
+	}
+
+	var byVersion = make(map[int64]*Migration)
+	for _, file := range files {
+		var base = path.Base(file)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: missing .up.sql or .down.sql suffix", base)
+		}
+
+		var stem = strings.TrimSuffix(base, "."+direction+".sql")
+		versionStr, name, found := strings.Cut(stem, "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>", base)
+		}
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if nil != err {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", base, err)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if nil != err {
+			return nil, err
+		}
+
+		it, ok := byVersion[version]
+		if !ok {
+			it = &Migration{Version: version, Name: name}
+			byVersion[version] = it
+		} else if it.Name != name {
+			return nil, fmt.Errorf("migration %d: conflicting names %s and %s", version, it.Name, name)
+		}
+		if direction == "up" {
+			it.Up = string(content)
+		} else {
+			it.Down = string(content)
+		}
+	}
+
+	var migrations = make([]*Migration, 0, len(byVersion))
+	for _, it := range byVersion {
+		if it.Up == "" || it.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: both up and down scripts are required", it.Version, it.Name)
+		}
+		migrations = append(migrations, it)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
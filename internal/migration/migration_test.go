@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := load(scripts)
+	if err != nil {
+		t.Fatalf("load embedded migrations fail: %s", err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Fatalf("embedded migrations must start at version 1")
+	}
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			t.Errorf("migrations must be ordered by version")
+		}
+	}
+}
+
+func TestLoadRequireBothDirections(t *testing.T) {
+	_, err := load(fstest.MapFS{
+		"sql/0001_init.up.sql": &fstest.MapFile{Data: []byte("SELECT 1;")},
+	})
+	if err == nil {
+		t.Errorf("load must fail when down script is missing")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts := splitStatements(`-- migrate:no-transaction
+CREATE INDEX CONCURRENTLY a ON t (x);
+-- comment only;
+CREATE INDEX CONCURRENTLY b
+    ON t (y);
+`)
+	if len(stmts) != 2 {
+		t.Errorf("split statements expect 2 got %d", len(stmts))
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS projects_document;
+DROP TABLE IF EXISTS projects_desc;
+DROP TABLE IF EXISTS projects_specs;
+DROP TABLE IF EXISTS projects_image;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,65 @@
+-- Baseline schema. Matches what AutoMigrate created before versioned
+-- migrations, so existing databases are adopted without changes.
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+CREATE TABLE IF NOT EXISTS projects (
+    id            bigserial PRIMARY KEY,
+    owner         text,
+    owner_id      text,
+    status        bigint,
+    location_name text,
+    location      geometry(POINT, 4326),
+    area          decimal,
+    thumbnail     text,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    type          bigint,
+    unit          decimal,
+    country_id    text,
+    iframe        text,
+    owner_address text
+);
+CREATE INDEX IF NOT EXISTS idx_projects_owner ON projects (owner);
+CREATE INDEX IF NOT EXISTS idx_projects_owner_id ON projects (owner_id);
+
+CREATE TABLE IF NOT EXISTS projects_image (
+    id         bigserial PRIMARY KEY,
+    project_id bigint,
+    image      text,
+    created_at timestamptz,
+    CONSTRAINT fk_projects_images FOREIGN KEY (project_id) REFERENCES projects (id)
+);
+
+CREATE TABLE IF NOT EXISTS projects_specs (
+    id         bigserial PRIMARY KEY,
+    project_id bigint UNIQUE,
+    specs      json,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_projects_specs FOREIGN KEY (project_id) REFERENCES projects (id)
+);
+
+CREATE TABLE IF NOT EXISTS projects_desc (
+    id         bigserial PRIMARY KEY,
+    project_id bigint,
+    language   text,
+    name       text,
+    "desc"     text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_projects_descs FOREIGN KEY (project_id) REFERENCES projects (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_desc_lang ON projects_desc (project_id, language);
+
+CREATE TABLE IF NOT EXISTS projects_document (
+    id            bigserial PRIMARY KEY,
+    project_id    bigint,
+    name          text,
+    url           text,
+    document_type text,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    deleted_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_projects_document_project_id ON projects_document (project_id);
+CREATE INDEX IF NOT EXISTS idx_projects_document_deleted_at ON projects_document (deleted_at);
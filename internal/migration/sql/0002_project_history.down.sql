@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS projects_history;
+DROP FUNCTION IF EXISTS projects_history_append_only();
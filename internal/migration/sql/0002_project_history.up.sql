@@ -0,0 +1,20 @@
+CREATE TABLE projects_history (
+    id         bigserial PRIMARY KEY,
+    project_id bigint NOT NULL REFERENCES projects (id),
+    actor      text NOT NULL DEFAULT '',
+    action     text NOT NULL DEFAULT '',
+    diff       jsonb NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_projects_history_project_id ON projects_history (project_id, created_at DESC, id DESC);
+
+-- History is append-only.
+CREATE FUNCTION projects_history_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'projects_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_projects_history_append_only
+    BEFORE UPDATE OR DELETE ON projects_history
+    FOR EACH ROW EXECUTE FUNCTION projects_history_append_only();
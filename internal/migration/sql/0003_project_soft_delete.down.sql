@@ -0,0 +1,4 @@
+ALTER TABLE projects_image DROP COLUMN deleted_at;
+ALTER TABLE projects_specs DROP COLUMN deleted_at;
+ALTER TABLE projects_desc DROP COLUMN deleted_at;
+ALTER TABLE projects DROP COLUMN deleted_at;
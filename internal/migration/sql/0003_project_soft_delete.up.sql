@@ -0,0 +1,9 @@
+ALTER TABLE projects ADD COLUMN deleted_at timestamptz;
+ALTER TABLE projects_desc ADD COLUMN deleted_at timestamptz;
+ALTER TABLE projects_specs ADD COLUMN deleted_at timestamptz;
+ALTER TABLE projects_image ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX idx_projects_deleted_at ON projects (deleted_at);
+CREATE INDEX idx_projects_desc_deleted_at ON projects_desc (deleted_at);
+CREATE INDEX idx_projects_specs_deleted_at ON projects_specs (deleted_at);
+CREATE INDEX idx_projects_image_deleted_at ON projects_image (deleted_at);
@@ -0,0 +1 @@
+ALTER TABLE projects ADD COLUMN version bigint NOT NULL DEFAULT 1;
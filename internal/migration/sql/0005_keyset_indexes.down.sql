@@ -0,0 +1,3 @@
+-- migrate:no-transaction
+DROP INDEX CONCURRENTLY IF EXISTS idx_projects_document_created_at_id;
+DROP INDEX CONCURRENTLY IF EXISTS idx_projects_created_at_id;
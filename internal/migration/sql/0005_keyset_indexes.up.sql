@@ -0,0 +1,5 @@
+-- migrate:no-transaction
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_projects_created_at_id
+    ON projects (created_at DESC, id DESC);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_projects_document_created_at_id
+    ON projects_document (created_at DESC, id DESC);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_projects_search;
+ALTER TABLE projects DROP COLUMN search_vector;
+DROP INDEX IF EXISTS idx_projects_desc_search;
+ALTER TABLE projects_desc DROP COLUMN search_vector;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS projects_search;
@@ -0,0 +1,20 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- simple + unaccent: no stemming (there is none for Vietnamese), lower case
+-- and diacritics removed on both documents and queries.
+CREATE TEXT SEARCH CONFIGURATION projects_search (COPY = simple);
+ALTER TEXT SEARCH CONFIGURATION projects_search
+    ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;
+
+ALTER TABLE projects_desc ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('projects_search', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('projects_search', coalesce("desc", '')), 'B')
+    ) STORED;
+CREATE INDEX idx_projects_desc_search ON projects_desc USING gin (search_vector);
+
+ALTER TABLE projects ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('projects_search', coalesce(location_name, '')), 'C')
+    ) STORED;
+CREATE INDEX idx_projects_search ON projects USING gin (search_vector);
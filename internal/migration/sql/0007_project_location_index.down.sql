@@ -0,0 +1,3 @@
+-- migrate:no-transaction
+DROP INDEX CONCURRENTLY IF EXISTS idx_projects_location_geog;
+DROP INDEX CONCURRENTLY IF EXISTS idx_projects_location;
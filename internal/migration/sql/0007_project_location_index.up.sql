@@ -0,0 +1,7 @@
+-- migrate:no-transaction
+-- Bounding box and polygon filters use the geometry index, radius filters
+-- use the geography one.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_projects_location
+    ON projects USING gist (location);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_projects_location_geog
+    ON projects USING gist ((location::geography));
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS countries_name;
+DROP TABLE IF EXISTS countries;
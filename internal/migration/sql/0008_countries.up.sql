@@ -0,0 +1,276 @@
+CREATE TABLE IF NOT EXISTS countries (
+    id         text PRIMARY KEY,
+    code       char(2) NOT NULL UNIQUE,
+    alpha3     char(3) NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS countries_name (
+    country_id text NOT NULL REFERENCES countries (id) ON DELETE CASCADE,
+    locale     text NOT NULL,
+    name       text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (country_id, locale)
+);
+
+-- ISO 3166-1, names come from CLDR unless overridden in countries_name.
+INSERT INTO countries (id, code, alpha3) VALUES
+    ('ad', 'AD', 'AND'),
+    ('ae', 'AE', 'ARE'),
+    ('af', 'AF', 'AFG'),
+    ('ag', 'AG', 'ATG'),
+    ('ai', 'AI', 'AIA'),
+    ('al', 'AL', 'ALB'),
+    ('am', 'AM', 'ARM'),
+    ('ao', 'AO', 'AGO'),
+    ('aq', 'AQ', 'ATA'),
+    ('ar', 'AR', 'ARG'),
+    ('as', 'AS', 'ASM'),
+    ('at', 'AT', 'AUT'),
+    ('au', 'AU', 'AUS'),
+    ('aw', 'AW', 'ABW'),
+    ('ax', 'AX', 'ALA'),
+    ('az', 'AZ', 'AZE'),
+    ('ba', 'BA', 'BIH'),
+    ('bb', 'BB', 'BRB'),
+    ('bd', 'BD', 'BGD'),
+    ('be', 'BE', 'BEL'),
+    ('bf', 'BF', 'BFA'),
+    ('bg', 'BG', 'BGR'),
+    ('bh', 'BH', 'BHR'),
+    ('bi', 'BI', 'BDI'),
+    ('bj', 'BJ', 'BEN'),
+    ('bl', 'BL', 'BLM'),
+    ('bm', 'BM', 'BMU'),
+    ('bn', 'BN', 'BRN'),
+    ('bo', 'BO', 'BOL'),
+    ('bq', 'BQ', 'BES'),
+    ('br', 'BR', 'BRA'),
+    ('bs', 'BS', 'BHS'),
+    ('bt', 'BT', 'BTN'),
+    ('bv', 'BV', 'BVT'),
+    ('bw', 'BW', 'BWA'),
+    ('by', 'BY', 'BLR'),
+    ('bz', 'BZ', 'BLZ'),
+    ('ca', 'CA', 'CAN'),
+    ('cc', 'CC', 'CCK'),
+    ('cd', 'CD', 'COD'),
+    ('cf', 'CF', 'CAF'),
+    ('cg', 'CG', 'COG'),
+    ('ch', 'CH', 'CHE'),
+    ('ci', 'CI', 'CIV'),
+    ('ck', 'CK', 'COK'),
+    ('cl', 'CL', 'CHL'),
+    ('cm', 'CM', 'CMR'),
+    ('cn', 'CN', 'CHN'),
+    ('co', 'CO', 'COL'),
+    ('cr', 'CR', 'CRI'),
+    ('cu', 'CU', 'CUB'),
+    ('cv', 'CV', 'CPV'),
+    ('cw', 'CW', 'CUW'),
+    ('cx', 'CX', 'CXR'),
+    ('cy', 'CY', 'CYP'),
+    ('cz', 'CZ', 'CZE'),
+    ('de', 'DE', 'DEU'),
+    ('dj', 'DJ', 'DJI'),
+    ('dk', 'DK', 'DNK'),
+    ('dm', 'DM', 'DMA'),
+    ('do', 'DO', 'DOM'),
+    ('dz', 'DZ', 'DZA'),
+    ('ec', 'EC', 'ECU'),
+    ('ee', 'EE', 'EST'),
+    ('eg', 'EG', 'EGY'),
+    ('eh', 'EH', 'ESH'),
+    ('er', 'ER', 'ERI'),
+    ('es', 'ES', 'ESP'),
+    ('et', 'ET', 'ETH'),
+    ('fi', 'FI', 'FIN'),
+    ('fj', 'FJ', 'FJI'),
+    ('fk', 'FK', 'FLK'),
+    ('fm', 'FM', 'FSM'),
+    ('fo', 'FO', 'FRO'),
+    ('fr', 'FR', 'FRA'),
+    ('ga', 'GA', 'GAB'),
+    ('gb', 'GB', 'GBR'),
+    ('gd', 'GD', 'GRD'),
+    ('ge', 'GE', 'GEO'),
+    ('gf', 'GF', 'GUF'),
+    ('gg', 'GG', 'GGY'),
+    ('gh', 'GH', 'GHA'),
+    ('gi', 'GI', 'GIB'),
+    ('gl', 'GL', 'GRL'),
+    ('gm', 'GM', 'GMB'),
+    ('gn', 'GN', 'GIN'),
+    ('gp', 'GP', 'GLP'),
+    ('gq', 'GQ', 'GNQ'),
+    ('gr', 'GR', 'GRC'),
+    ('gs', 'GS', 'SGS'),
+    ('gt', 'GT', 'GTM'),
+    ('gu', 'GU', 'GUM'),
+    ('gw', 'GW', 'GNB'),
+    ('gy', 'GY', 'GUY'),
+    ('hk', 'HK', 'HKG'),
+    ('hm', 'HM', 'HMD'),
+    ('hn', 'HN', 'HND'),
+    ('hr', 'HR', 'HRV'),
+    ('ht', 'HT', 'HTI'),
+    ('hu', 'HU', 'HUN'),
+    ('id', 'ID', 'IDN'),
+    ('ie', 'IE', 'IRL'),
+    ('il', 'IL', 'ISR'),
+    ('im', 'IM', 'IMN'),
+    ('in', 'IN', 'IND'),
+    ('io', 'IO', 'IOT'),
+    ('iq', 'IQ', 'IRQ'),
+    ('ir', 'IR', 'IRN'),
+    ('is', 'IS', 'ISL'),
+    ('it', 'IT', 'ITA'),
+    ('je', 'JE', 'JEY'),
+    ('jm', 'JM', 'JAM'),
+    ('jo', 'JO', 'JOR'),
+    ('jp', 'JP', 'JPN'),
+    ('ke', 'KE', 'KEN'),
+    ('kg', 'KG', 'KGZ'),
+    ('kh', 'KH', 'KHM'),
+    ('ki', 'KI', 'KIR'),
+    ('km', 'KM', 'COM'),
+    ('kn', 'KN', 'KNA'),
+    ('kp', 'KP', 'PRK'),
+    ('kr', 'KR', 'KOR'),
+    ('kw', 'KW', 'KWT'),
+    ('ky', 'KY', 'CYM'),
+    ('kz', 'KZ', 'KAZ'),
+    ('la', 'LA', 'LAO'),
+    ('lb', 'LB', 'LBN'),
+    ('lc', 'LC', 'LCA'),
+    ('li', 'LI', 'LIE'),
+    ('lk', 'LK', 'LKA'),
+    ('lr', 'LR', 'LBR'),
+    ('ls', 'LS', 'LSO'),
+    ('lt', 'LT', 'LTU'),
+    ('lu', 'LU', 'LUX'),
+    ('lv', 'LV', 'LVA'),
+    ('ly', 'LY', 'LBY'),
+    ('ma', 'MA', 'MAR'),
+    ('mc', 'MC', 'MCO'),
+    ('md', 'MD', 'MDA'),
+    ('me', 'ME', 'MNE'),
+    ('mf', 'MF', 'MAF'),
+    ('mg', 'MG', 'MDG'),
+    ('mh', 'MH', 'MHL'),
+    ('mk', 'MK', 'MKD'),
+    ('ml', 'ML', 'MLI'),
+    ('mm', 'MM', 'MMR'),
+    ('mn', 'MN', 'MNG'),
+    ('mo', 'MO', 'MAC'),
+    ('mp', 'MP', 'MNP'),
+    ('mq', 'MQ', 'MTQ'),
+    ('mr', 'MR', 'MRT'),
+    ('ms', 'MS', 'MSR'),
+    ('mt', 'MT', 'MLT'),
+    ('mu', 'MU', 'MUS'),
+    ('mv', 'MV', 'MDV'),
+    ('mw', 'MW', 'MWI'),
+    ('mx', 'MX', 'MEX'),
+    ('my', 'MY', 'MYS'),
+    ('mz', 'MZ', 'MOZ'),
+    ('na', 'NA', 'NAM'),
+    ('nc', 'NC', 'NCL'),
+    ('ne', 'NE', 'NER'),
+    ('nf', 'NF', 'NFK'),
+    ('ng', 'NG', 'NGA'),
+    ('ni', 'NI', 'NIC'),
+    ('nl', 'NL', 'NLD'),
+    ('no', 'NO', 'NOR'),
+    ('np', 'NP', 'NPL'),
+    ('nr', 'NR', 'NRU'),
+    ('nu', 'NU', 'NIU'),
+    ('nz', 'NZ', 'NZL'),
+    ('om', 'OM', 'OMN'),
+    ('pa', 'PA', 'PAN'),
+    ('pe', 'PE', 'PER'),
+    ('pf', 'PF', 'PYF'),
+    ('pg', 'PG', 'PNG'),
+    ('ph', 'PH', 'PHL'),
+    ('pk', 'PK', 'PAK'),
+    ('pl', 'PL', 'POL'),
+    ('pm', 'PM', 'SPM'),
+    ('pn', 'PN', 'PCN'),
+    ('pr', 'PR', 'PRI'),
+    ('ps', 'PS', 'PSE'),
+    ('pt', 'PT', 'PRT'),
+    ('pw', 'PW', 'PLW'),
+    ('py', 'PY', 'PRY'),
+    ('qa', 'QA', 'QAT'),
+    ('re', 'RE', 'REU'),
+    ('ro', 'RO', 'ROU'),
+    ('rs', 'RS', 'SRB'),
+    ('ru', 'RU', 'RUS'),
+    ('rw', 'RW', 'RWA'),
+    ('sa', 'SA', 'SAU'),
+    ('sb', 'SB', 'SLB'),
+    ('sc', 'SC', 'SYC'),
+    ('sd', 'SD', 'SDN'),
+    ('se', 'SE', 'SWE'),
+    ('sg', 'SG', 'SGP'),
+    ('sh', 'SH', 'SHN'),
+    ('si', 'SI', 'SVN'),
+    ('sj', 'SJ', 'SJM'),
+    ('sk', 'SK', 'SVK'),
+    ('sl', 'SL', 'SLE'),
+    ('sm', 'SM', 'SMR'),
+    ('sn', 'SN', 'SEN'),
+    ('so', 'SO', 'SOM'),
+    ('sr', 'SR', 'SUR'),
+    ('ss', 'SS', 'SSD'),
+    ('st', 'ST', 'STP'),
+    ('sv', 'SV', 'SLV'),
+    ('sx', 'SX', 'SXM'),
+    ('sy', 'SY', 'SYR'),
+    ('sz', 'SZ', 'SWZ'),
+    ('tc', 'TC', 'TCA'),
+    ('td', 'TD', 'TCD'),
+    ('tf', 'TF', 'ATF'),
+    ('tg', 'TG', 'TGO'),
+    ('th', 'TH', 'THA'),
+    ('tj', 'TJ', 'TJK'),
+    ('tk', 'TK', 'TKL'),
+    ('tl', 'TL', 'TLS'),
+    ('tm', 'TM', 'TKM'),
+    ('tn', 'TN', 'TUN'),
+    ('to', 'TO', 'TON'),
+    ('tr', 'TR', 'TUR'),
+    ('tt', 'TT', 'TTO'),
+    ('tv', 'TV', 'TUV'),
+    ('tw', 'TW', 'TWN'),
+    ('tz', 'TZ', 'TZA'),
+    ('ua', 'UA', 'UKR'),
+    ('ug', 'UG', 'UGA'),
+    ('um', 'UM', 'UMI'),
+    ('us', 'US', 'USA'),
+    ('uy', 'UY', 'URY'),
+    ('uz', 'UZ', 'UZB'),
+    ('va', 'VA', 'VAT'),
+    ('vc', 'VC', 'VCT'),
+    ('ve', 'VE', 'VEN'),
+    ('vg', 'VG', 'VGB'),
+    ('vi', 'VI', 'VIR'),
+    ('vn', 'VN', 'VNM'),
+    ('vu', 'VU', 'VUT'),
+    ('wf', 'WF', 'WLF'),
+    ('ws', 'WS', 'WSM'),
+    ('ye', 'YE', 'YEM'),
+    ('yt', 'YT', 'MYT'),
+    ('za', 'ZA', 'ZAF'),
+    ('zm', 'ZM', 'ZMB'),
+    ('zw', 'ZW', 'ZWE')
+ON CONFLICT (id) DO NOTHING;
+
+-- Names of json/country.json, which the registry replaces.
+INSERT INTO countries_name (country_id, locale, name) VALUES
+    ('vn', 'vi', 'Việt Nam'),
+    ('vn', 'en', 'Viet Nam'),
+    ('au', 'vi', 'Úc'),
+    ('au', 'en', 'Australia')
+ON CONFLICT (country_id, locale) DO NOTHING;
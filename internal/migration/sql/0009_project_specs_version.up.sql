@@ -0,0 +1,17 @@
+CREATE TABLE projects_specs_version (
+    id             bigserial PRIMARY KEY,
+    project_id     bigint NOT NULL REFERENCES projects (id),
+    specs          json NOT NULL,
+    effective_from timestamptz NOT NULL,
+    actor          text NOT NULL DEFAULT '',
+    created_at     timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_projects_specs_version_effective
+    ON projects_specs_version (project_id, effective_from DESC, id DESC);
+
+-- Only the latest specs were kept so far, they are assumed effective since
+-- they were first written.
+INSERT INTO projects_specs_version (project_id, specs, effective_from, created_at)
+SELECT project_id, specs, COALESCE(created_at, updated_at, now()), COALESCE(updated_at, now())
+FROM projects_specs
+WHERE specs IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_projects_image_position;
+ALTER TABLE projects_image DROP COLUMN IF EXISTS position;
+ALTER TABLE projects_image DROP COLUMN IF EXISTS caption;
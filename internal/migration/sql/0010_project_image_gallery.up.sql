@@ -0,0 +1,12 @@
+ALTER TABLE projects_image ADD COLUMN caption text NOT NULL DEFAULT '';
+ALTER TABLE projects_image ADD COLUMN position integer NOT NULL DEFAULT 0;
+
+-- Existing images keep the order they were added in.
+UPDATE projects_image SET position = ordered.position
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY project_id ORDER BY created_at, id) AS position
+    FROM projects_image
+) AS ordered
+WHERE projects_image.id = ordered.id;
+
+CREATE INDEX idx_projects_image_position ON projects_image (project_id, position, id);
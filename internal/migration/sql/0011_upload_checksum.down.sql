@@ -0,0 +1,4 @@
+ALTER TABLE projects_document DROP COLUMN IF EXISTS size;
+ALTER TABLE projects_document DROP COLUMN IF EXISTS checksum;
+ALTER TABLE projects_image DROP COLUMN IF EXISTS size;
+ALTER TABLE projects_image DROP COLUMN IF EXISTS checksum;
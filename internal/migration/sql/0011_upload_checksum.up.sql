@@ -0,0 +1,4 @@
+ALTER TABLE projects_image ADD COLUMN checksum text NOT NULL DEFAULT '';
+ALTER TABLE projects_image ADD COLUMN size bigint NOT NULL DEFAULT 0;
+ALTER TABLE projects_document ADD COLUMN checksum text NOT NULL DEFAULT '';
+ALTER TABLE projects_document ADD COLUMN size bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE projects DROP COLUMN IF EXISTS thumbnail_variants;
+ALTER TABLE projects_image DROP COLUMN IF EXISTS variants;
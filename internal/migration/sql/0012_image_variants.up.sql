@@ -0,0 +1,2 @@
+ALTER TABLE projects_image ADD COLUMN variants json;
+ALTER TABLE projects ADD COLUMN thumbnail_variants json;
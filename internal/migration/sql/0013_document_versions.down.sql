@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS projects_document_version;
+DROP FUNCTION IF EXISTS projects_document_version_append_only();
+ALTER TABLE projects_document DROP COLUMN IF EXISTS version;
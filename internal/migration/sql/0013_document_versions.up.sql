@@ -0,0 +1,34 @@
+ALTER TABLE projects_document ADD COLUMN version bigint NOT NULL DEFAULT 1;
+
+CREATE TABLE projects_document_version (
+    id            bigserial PRIMARY KEY,
+    document_id   bigint NOT NULL REFERENCES projects_document (id),
+    project_id    bigint NOT NULL,
+    version       bigint NOT NULL,
+    name          text NOT NULL DEFAULT '',
+    url           text NOT NULL DEFAULT '',
+    document_type text NOT NULL DEFAULT '',
+    checksum      text NOT NULL DEFAULT '',
+    size          bigint NOT NULL DEFAULT 0,
+    uploaded_by   text NOT NULL DEFAULT '',
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (document_id, version)
+);
+
+-- The file each document holds today becomes its first version.
+INSERT INTO projects_document_version
+    (document_id, project_id, version, name, url, document_type, checksum, size, created_at)
+SELECT id, COALESCE(project_id, 0), 1, COALESCE(name, ''), COALESCE(url, ''),
+    COALESCE(document_type, ''), checksum, size, COALESCE(updated_at, created_at, now())
+FROM projects_document;
+
+-- Versions are append-only.
+CREATE FUNCTION projects_document_version_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'projects_document_version is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_projects_document_version_append_only
+    BEFORE UPDATE OR DELETE ON projects_document_version
+    FOR EACH ROW EXECUTE FUNCTION projects_document_version_append_only();
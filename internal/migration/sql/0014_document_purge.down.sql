@@ -0,0 +1,5 @@
+CREATE OR REPLACE FUNCTION projects_document_version_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'projects_document_version is append-only';
+END;
+$$ LANGUAGE plpgsql;
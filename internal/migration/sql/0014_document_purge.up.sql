@@ -0,0 +1,10 @@
+-- Versions stay append-only, except for the purge of expired documents
+-- which sets projects.purge for its transaction.
+CREATE OR REPLACE FUNCTION projects_document_version_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('projects.purge', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'projects_document_version is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,4 @@
+ALTER TABLE projects
+    DROP COLUMN status_at,
+    DROP COLUMN status_by,
+    DROP COLUMN status_reason;
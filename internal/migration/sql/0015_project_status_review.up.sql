@@ -0,0 +1,4 @@
+ALTER TABLE projects
+    ADD COLUMN status_reason text NOT NULL DEFAULT '',
+    ADD COLUMN status_by     text NOT NULL DEFAULT '',
+    ADD COLUMN status_at     timestamptz;
@@ -0,0 +1 @@
+DROP AGGREGATE tsvector_agg (tsvector);
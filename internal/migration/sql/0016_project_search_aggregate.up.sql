@@ -0,0 +1,7 @@
+-- tsvector_agg concatenates the search vectors of a group, so a search
+-- matches the descs and the location name of a project together.
+CREATE AGGREGATE tsvector_agg (tsvector) (
+    SFUNC = tsvector_concat,
+    STYPE = tsvector,
+    INITCOND = ''
+);
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGetAudit(t *testing.T) {
+	var sv = &Service{jwtKey: []byte(testJwtKey)}
+	if audit := sv.getAudit(withToken(newToken(testJwtKey, `{"id":"0xabc","role":"admin"}`))); audit.Actor != "0xabc" {
+		t.Errorf("Audit actor expect 0xabc got %q", audit.Actor)
+	}
+	if audit := sv.getAudit(withToken(newToken("forged", `{"id":"0xabc"}`))); audit.Actor != "" {
+		t.Errorf("Audit actor must be empty with a forged token, got %q", audit.Actor)
+	}
+	if audit := sv.getAudit(context.TODO()); audit.Actor != "" {
+		t.Errorf("Audit actor must be empty without token")
+	}
+}
+
+const testJwtKey = "test-secret"
+
+// newToken signs claims with key as an HS256 JWT.
+func newToken(key string, claims string) string {
+	var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	var payload = base64.RawURLEncoding.EncodeToString([]byte(claims))
+	var mac = hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.TODO(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestServiceIsAdmin(t *testing.T) {
+	var sv = &Service{jwtKey: []byte(testJwtKey)}
+	var forged = newToken("another-key", `{"role":"super-admin"}`)
+	var unsigned = strings.Join(strings.Split(newToken(testJwtKey, `{"role":"super-admin"}`), ".")[:2], ".") + "."
+	var expired = newToken(testJwtKey, `{"role":"super-admin","exp":1}`)
+	for _, token := range []string{forged, unsigned, expired, "header." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"role":"super-admin"}`)) + ".signature"} {
+		if sv.isAdmin(withToken(token)) {
+			t.Errorf("Token %s must not be trusted", token)
+		}
+	}
+	if !sv.isAdmin(withToken(newToken(testJwtKey, `{"role":"super-admin"}`))) {
+		t.Errorf("Signed admin token expect admin")
+	}
+	if sv.isAdmin(withToken(newToken(testJwtKey, `{"role":"user"}`))) {
+		t.Errorf("Signed user token must not be admin")
+	}
+	if (&Service{}).isAdmin(withToken(newToken("", `{"role":"super-admin"}`))) {
+		t.Errorf("No token is trusted without a JWT key")
+	}
+}
@@ -1,6 +1,12 @@
 package service
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/Dcarbon/arch-proto/pb"
 	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/go-shared/libs/utils"
@@ -8,21 +14,27 @@ import (
 )
 
 func convertProject(in *domain.Project) *pb.Project {
-	types := map[int]string{0: "None", 1: "Biomass to Gasification", 2: "Biogas to Electricity", 3: "Model S"}
-
 	if nil == in {
 		return nil
 	}
+	var typeName = in.TypeName
+	if typeName == "" {
+		typeName, _ = domain.TypeName(in.Type, nil)
+	}
 	var rs = &pb.Project{
 		Thumbnail:    in.Thumbnail,
+		Thumbnails:   convertVariants(in.Thumbnails),
 		Id:           in.Id,
 		Owner:        in.OwnerId,
 		LocationName: in.LocationName,
 		Location:     convertGPS(in.Location),
 		Status:       int32(in.Status),
+		StatusReason: in.StatusReason,
+		StatusBy:     in.StatusBy,
 		Ca:           in.CreatedAt.UnixMilli(),
 		Ua:           in.UpdatedAt.UnixMilli(),
 		Images:       convertImage(in.Images),
+		Gallery:      convertArr(in.Images, convertProjectImage),
 		Specs:        convertProjectSpecs(in.Specs),
 		Descs:        convertArr[domain.ProjectDesc, pb.ProjectDesc](in.Descs, convertProjectDesc),
 		Area:         in.Area,
@@ -31,11 +43,21 @@ func convertProject(in *domain.Project) *pb.Project {
 		Country:      convertCountry(in.Country),
 		Iframe:       in.Iframe,
 		OwnerAddress: in.OwnerAddress,
+		Version:      in.Version,
+		Highlight:    in.Highlight,
+		Distance:     in.Distance,
+		Language:     in.Language,
 		DetailType: &pb.Type{
 			Id:   int32(in.Type),
-			Name: types[int(in.Type)],
+			Name: typeName,
 		},
 	}
+	if nil != in.StatusAt {
+		rs.StatusAt = in.StatusAt.UnixMilli()
+	}
+	if in.DeletedAt.Valid {
+		rs.DeletedAt = in.DeletedAt.Time.UnixMilli()
+	}
 	return rs
 }
 
@@ -47,6 +69,21 @@ func convertCountry(in *domain.Country) *pb.Country {
 		//Id:          in.Id,
 		Name:        in.Name,
 		CountryCode: in.Code,
+		Alpha3:      in.Alpha3,
+		Locale:      in.Locale,
+	}
+	return rs
+}
+
+func convertCountryName(in *domain.CountryName) *pb.CountryName {
+	if nil == in {
+		return nil
+	}
+	var rs = &pb.CountryName{
+		CountryId: in.CountryId,
+		Locale:    in.Locale,
+		Name:      in.Name,
+		UpdatedAt: in.UpdatedAt.UnixMilli(),
 	}
 	return rs
 }
@@ -75,6 +112,48 @@ func convertProjectSpecs(in *domain.ProjectSpecs) *pb.ProjectSpecs {
 	return rs
 }
 
+func convertSpecsVersion(in *domain.ProjectSpecsVersion) *pb.ProjectSpecsVersion {
+	return &pb.ProjectSpecsVersion{
+		Id:            in.Id,
+		ProjectId:     in.ProjectId,
+		Specs:         in.Specs,
+		EffectiveFrom: in.EffectiveFrom.UnixMilli(),
+		Actor:         in.Actor,
+		CreatedAt:     in.CreatedAt.UnixMilli(),
+	}
+}
+
+// fromMillis converts milliseconds since epoch, 0 gives the zero time.
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// convertSpecSchema labels the fields in the first locale of chain.
+func convertSpecSchema(in *domain.SpecSchema, chain []string) *pb.SpecSchema {
+	if nil == in {
+		return nil
+	}
+	var rs = &pb.SpecSchema{
+		Type:   pb.ProjectType(in.Type),
+		Fields: make([]*pb.SpecField, len(in.Fields)),
+	}
+	for i, it := range in.Fields {
+		rs.Fields[i] = &pb.SpecField{
+			Key:      it.Key,
+			Unit:     it.Unit,
+			Min:      it.Min,
+			Max:      it.Max,
+			Required: it.Required,
+			Label:    it.Label(chain),
+			Labels:   it.Labels,
+		}
+	}
+	return rs
+}
+
 func convertGPS(in *dmodels.Coord) *pb.GPS {
 	if nil == in {
 		return nil
@@ -86,6 +165,65 @@ func convertGPS(in *dmodels.Coord) *pb.GPS {
 	return rs
 }
 
+func convertGetList(req *pb.RPGetList) (*domain.RProjectGetList, error) {
+	intArray := []int{}
+	if strings.TrimSpace(req.Ids) != "" {
+		datas := strings.Split(req.Ids, ",")
+		// Convert each string to an integer
+		for _, s := range datas {
+			// Convert string to integer
+			num, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				fmt.Printf("Error converting string to int: %v\n", err)
+				return nil, err
+			}
+			intArray = append(intArray, num) // Append the converted integer to intArray
+		}
+	}
+	var rs = &domain.RProjectGetList{
+		Skip:        int(req.Skip),
+		Limit:       int(req.Limit),
+		Owner:       req.OwnerId,
+		Unit:        int64(req.Unit),
+		CountryId:   req.CountryId,
+		Type:        int64(req.Type),
+		SearchValue: req.SearchValue,
+		Location:    req.Location,
+		Status:      int(req.Status),
+		Ids:         intArray,
+
+		IncludeDeleted: req.IncludeDeleted,
+		Cursor:         req.Cursor,
+		SkipTotal:      req.SkipTotal,
+
+		Near:    convertNear(req.Near),
+		RadiusM: req.RadiusM,
+		Bounds:  convertBounds(req.Bounds),
+		Polygon: req.Polygon,
+	}
+	return rs, nil
+}
+
+func convertNear(in *pb.GPS) *dmodels.Coord {
+	if nil == in {
+		return nil
+	}
+	return dmodels.NewCoord4326(in.Longitude, in.Latitude)
+}
+
+func convertBounds(in *pb.BoundingBox) *domain.BoundingBox {
+	if nil == in {
+		return nil
+	}
+	var rs = &domain.BoundingBox{
+		MinLng: in.MinLng,
+		MinLat: in.MinLat,
+		MaxLng: in.MaxLng,
+		MaxLat: in.MaxLat,
+	}
+	return rs
+}
+
 func convertImage(in []*domain.ProjectImage) []string {
 	if nil == in {
 		return nil
@@ -97,6 +235,31 @@ func convertImage(in []*domain.ProjectImage) []string {
 	return rs
 }
 
+// convertProjectImage keeps the id, caption and gallery position that
+// convertImage drops.
+func convertProjectImage(in *domain.ProjectImage) *pb.ProjectImage {
+	return &pb.ProjectImage{
+		Id:       in.Id,
+		Image:    utils.StringEnv("STORAGE_URL", "") + in.Image,
+		Caption:  in.Caption,
+		Position: int32(in.Position),
+		Checksum: in.Checksum,
+		Variants: convertVariants(in.Variants),
+	}
+}
+
+// convertVariants returns the URL of each image variant.
+func convertVariants(in domain.ImageVariants) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	var rs = make(map[string]string, len(in))
+	for name, it := range in {
+		rs[name] = utils.StringEnv("STORAGE_URL", "") + it
+	}
+	return rs
+}
+
 func convertArr[T any, T2 any](arr []*T, fn func(*T) *T2) []*T2 {
 	var rs = make([]*T2, len(arr))
 	for i, it := range arr {
@@ -115,8 +278,67 @@ func convertDocument(in *domain.ProjectDocument) *pb.Document {
 		DocumentName: in.Name,
 		Url:          in.Url,
 		DocumentType: in.DocumentType,
+		Checksum:     in.Checksum,
+		Size:         in.Size,
+		Version:      in.Version,
 		UpdatedAt:    in.CreatedAt.Unix(),
 		CreatedAt:    in.CreatedAt.Unix(),
 	}
 	return rs
 }
+
+func convertImportReport(in *domain.ImportReport) *pb.ImportReport {
+	var rs = &pb.ImportReport{
+		Created: int32(in.Created),
+		Failed:  int32(in.Failed),
+		Rows:    make([]*pb.ImportResult, len(in.Rows)),
+	}
+	for i, it := range in.Rows {
+		rs.Rows[i] = &pb.ImportResult{
+			Row:       int32(it.Row),
+			ProjectId: it.ProjectId,
+			Error:     it.Error,
+		}
+	}
+	return rs
+}
+
+func convertDocumentCompleteness(in *domain.DocumentCompleteness) *pb.DocumentCompleteness {
+	return &pb.DocumentCompleteness{
+		ProjectId: in.ProjectId,
+		Required:  in.Required,
+		Missing:   in.Missing,
+		Complete:  in.Complete(),
+	}
+}
+
+func convertDocumentVersion(in *domain.ProjectDocumentVersion) *pb.DocumentVersion {
+	return &pb.DocumentVersion{
+		DocumentId:   in.DocumentId,
+		ProjectId:    in.ProjectId,
+		Version:      in.Version,
+		DocumentName: in.Name,
+		Url:          in.Url,
+		DocumentType: in.DocumentType,
+		Checksum:     in.Checksum,
+		Size:         in.Size,
+		UploadedBy:   in.UploadedBy,
+		CreatedAt:    in.CreatedAt.Unix(),
+	}
+}
+
+func convertHistory(in *domain.ProjectHistory) *pb.ProjectHistory {
+	if nil == in {
+		return nil
+	}
+	diff, _ := json.Marshal(in.Diff)
+	var rs = &pb.ProjectHistory{
+		Id:        in.Id,
+		ProjectId: in.ProjectId,
+		Actor:     in.Actor,
+		Action:    in.Action,
+		Diff:      string(diff),
+		CreatedAt: in.CreatedAt.Unix(),
+	}
+	return rs
+}
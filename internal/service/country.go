@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+func (sv *Service) ListCountries(ctx context.Context, req *pb.RPListCountries,
+) (*pb.Countries, error) {
+	data, err := sv.iCountry.ListCountries(sv.getLocales(ctx, req.Lang)...)
+	if nil != err {
+		return nil, err
+	}
+	var rs = &pb.Countries{
+		Data: convertArr[domain.Country, pb.Country](data, convertCountry),
+	}
+	return rs, nil
+}
+
+func (sv *Service) GetCountry(ctx context.Context, req *pb.RPGetCountry,
+) (*pb.Country, error) {
+	data, err := sv.iCountry.GetCountry(req.Id, sv.getLocales(ctx, req.Lang)...)
+	if nil != err {
+		return nil, err
+	}
+	return convertCountry(data), nil
+}
+
+func (sv *Service) UpsertCountryLocale(ctx context.Context, req *pb.RPUpsertCountryLocale,
+) (*pb.CountryName, error) {
+	data, err := sv.iCountry.UpsertCountryLocale(&domain.RCountryLocaleUpsert{
+		CountryId: req.CountryId,
+		Locale:    req.Locale,
+		Name:      req.Name,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return convertCountryName(data), nil
+}
+
+func (sv *Service) DeleteCountryLocale(ctx context.Context, req *pb.RPDeleteCountryLocale,
+) (*pb.Empty, error) {
+	var err = sv.iCountry.DeleteCountryLocale(&domain.RCountryLocaleDelete{
+		CountryId: req.CountryId,
+		Locale:    req.Locale,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+const (
+	// EnvDocumentCatalogue is the path of a json file replacing the
+	// embedded catalogue of document types and requirements.
+	EnvDocumentCatalogue = "PROJECTS_DOCUMENT_CATALOGUE"
+
+	// EnvActivateRequireDocuments, when true, refuses to activate a project
+	// until it has every required document.
+	EnvActivateRequireDocuments = "PROJECTS_ACTIVATE_REQUIRE_DOCUMENTS"
+)
+
+func activateRequireDocuments() bool {
+	rs, _ := strconv.ParseBool(utils.StringEnv(EnvActivateRequireDocuments, "false"))
+	return rs
+}
+
+func (sv *Service) ListDocumentTypes(ctx context.Context, req *pb.RPListDocumentTypes,
+) (*pb.DocumentTypes, error) {
+	var chain = sv.getLocales(ctx, req.Lang)
+	var types = domain.GetDocumentTypes()
+	var rs = &pb.DocumentTypes{
+		Data: make([]*pb.DocumentType, len(types)),
+	}
+	for i, it := range types {
+		rs.Data[i] = &pb.DocumentType{Key: it.Key, Label: it.Label(chain)}
+	}
+	if req.Type != 0 || req.CountryId != "" {
+		rs.Required = domain.RequiredDocuments(int64(req.Type), req.CountryId)
+	}
+	return rs, nil
+}
+
+func (sv *Service) GetDocumentCompleteness(ctx context.Context, req *pb.RPGetDocumentCompleteness,
+) (*pb.DocumentCompleteness, error) {
+	completeness, err := sv.iProject.GetDocumentCompleteness(&domain.RProjectDocumentCompleteness{
+		ProjectId: req.ProjectId,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return convertDocumentCompleteness(completeness), nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/xlsx"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatXLSX  = "xlsx"
+	ExportFormatJSONL = "jsonl"
+
+	// exportPageSize is the number of projects read per GetList call.
+	exportPageSize = 200
+)
+
+var errExportFormat = status.Error(codes.InvalidArgument, "export format must be csv, xlsx or jsonl")
+
+// exportRow is a project as exported. It extends importRow, so a jsonl
+// export can be imported as is.
+type exportRow struct {
+	Id        int64     `json:"id"`
+	Status    int       `json:"status"`
+	TypeName  string    `json:"typeName"`
+	Country   string    `json:"country"` // Name in the default locale
+	Documents int64     `json:"documents"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	importRow
+}
+
+var exportColumns = []string{
+	"id", "status", "type", "typeName", "countryId", "country", "owner", "ownerId", "ownerAddress",
+	"locationName", "longitude", "latitude", "unit", "area", "iframe", "documents", "createdAt", "updatedAt",
+}
+
+func newExportRow(in *domain.Project, documents int64) *exportRow {
+	var rs = &exportRow{
+		Id:        in.Id,
+		Status:    int(in.Status),
+		Documents: documents,
+		CreatedAt: in.CreatedAt,
+		UpdatedAt: in.UpdatedAt,
+		importRow: importRow{
+			Owner:        string(in.Owner),
+			OwnerId:      in.OwnerId,
+			OwnerAddress: in.OwnerAddress,
+			CountryId:    in.CountryId,
+			Type:         int32(in.Type),
+			Unit:         in.Unit,
+			Area:         in.Area,
+			LocationName: in.LocationName,
+			Iframe:       in.Iframe,
+			Descs:        make([]*importDesc, 0, len(in.Descs)),
+		},
+	}
+	rs.TypeName, _ = domain.TypeName(in.Type, domain.DefaultFallbackLocales)
+	if nil != in.Country {
+		rs.Country = in.Country.Name
+	}
+	if nil != in.Location {
+		rs.Longitude, rs.Latitude = &in.Location.Lng, &in.Location.Lat
+	}
+	for _, it := range in.Descs {
+		rs.Descs = append(rs.Descs, &importDesc{Language: it.Language, Name: it.Name, Desc: it.Desc})
+	}
+	if nil != in.Specs {
+		rs.Specs = in.Specs.Specs
+	}
+	return rs
+}
+
+// cells returns the values of row in the order of exportColumns, then of
+// the desc and spec columns. Descs in other languages and specs outside
+// the schema go as json to the trailing descs and specs columns.
+func (row *exportRow) cells(languages, specs []string) []interface{} {
+	var rs = []interface{}{
+		row.Id, row.Status, row.Type, row.TypeName, row.CountryId, row.Country, row.Owner, row.OwnerId,
+		row.OwnerAddress, row.LocationName, nil, nil, row.Unit, row.Area, row.Iframe, row.Documents,
+		row.CreatedAt, row.UpdatedAt,
+	}
+	if nil != row.Longitude {
+		rs[10], rs[11] = *row.Longitude, *row.Latitude
+	}
+	var descs = make(map[string]*importDesc, len(row.Descs))
+	for _, it := range row.Descs {
+		descs[it.Language] = it
+	}
+	for _, language := range languages {
+		if desc, ok := descs[language]; ok {
+			rs = append(rs, desc.Name, desc.Desc)
+			delete(descs, language)
+		} else {
+			rs = append(rs, nil, nil)
+		}
+	}
+	var otherSpecs = make(map[string]float64)
+	for key, value := range row.Specs {
+		otherSpecs[key] = value
+	}
+	for _, key := range specs {
+		if value, ok := row.Specs[key]; ok {
+			rs = append(rs, value)
+			delete(otherSpecs, key)
+		} else {
+			rs = append(rs, nil)
+		}
+	}
+
+	var otherDescs = make([]*importDesc, 0, len(descs))
+	for _, it := range row.Descs {
+		if _, ok := descs[it.Language]; ok {
+			otherDescs = append(otherDescs, it)
+		}
+	}
+	return append(rs, jsonCell(otherDescs, len(otherDescs)), jsonCell(otherSpecs, len(otherSpecs)))
+}
+
+// jsonCell encodes v, or leaves the cell empty when it has no element.
+func jsonCell(v interface{}, n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// tableWriter writes the rows of a csv or xlsx export.
+type tableWriter interface {
+	WriteRow(cells []interface{}) error
+	Close() error
+}
+
+type csvTable struct {
+	w *csv.Writer
+}
+
+func (t *csvTable) WriteRow(cells []interface{}) error {
+	var record = make([]string, len(cells))
+	for i, it := range cells {
+		switch v := it.(type) {
+		case nil:
+		case string:
+			record[i] = v
+		case int:
+			record[i] = strconv.Itoa(v)
+		case int32:
+			record[i] = strconv.FormatInt(int64(v), 10)
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case float32:
+			record[i] = strconv.FormatFloat(float64(v), 'f', -1, 32)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339)
+		}
+	}
+	return t.w.Write(record)
+}
+
+func (t *csvTable) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// WriteProjects writes the projects matching filter to w in format, with
+// a desc column per default locale and a column per spec of the schemas.
+// The columns do not depend on the data, so projects are read once, page
+// by page.
+func WriteProjects(w io.Writer, iProject domain.IProject, filter domain.RProjectGetList, format string) error {
+	filter.Locales = nil
+	var buf = bufio.NewWriterSize(w, geojsonChunkSize)
+	var err error
+	switch format {
+	case ExportFormatJSONL:
+		err = writeProjectsJSONL(buf, iProject, filter)
+	case ExportFormatCSV:
+		err = writeProjectsTable(&csvTable{w: csv.NewWriter(buf)}, iProject, filter)
+	case ExportFormatXLSX:
+		var table *xlsx.Writer
+		if table, err = xlsx.NewWriter(buf, "Projects"); nil == err {
+			err = writeProjectsTable(table, iProject, filter)
+		}
+	default:
+		return errExportFormat
+	}
+	if nil != err {
+		return err
+	}
+	return buf.Flush()
+}
+
+// eachExportRow calls fn with the export row of every project matching
+// filter, counting documents page by page.
+func eachExportRow(iProject domain.IProject, filter domain.RProjectGetList, fn func(*exportRow) error) error {
+	return eachProjectPage(iProject, filter, exportPageSize, func(data []*domain.Project) error {
+		var ids = make([]int64, len(data))
+		for i, it := range data {
+			ids[i] = it.Id
+		}
+		counts, err := iProject.CountDocuments(ids)
+		if nil != err {
+			return err
+		}
+		for _, it := range data {
+			if err := fn(newExportRow(it, counts[it.Id])); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func writeProjectsJSONL(w io.Writer, iProject domain.IProject, filter domain.RProjectGetList) error {
+	var encoder = json.NewEncoder(w)
+	return eachExportRow(iProject, filter, func(row *exportRow) error {
+		return encoder.Encode(row)
+	})
+}
+
+// writeProjectsTable writes the header, then a row per project. Descs are
+// spread over the default locales and specs over the schema of
+// filter.Type, or of every type; the rest goes to the descs and specs
+// columns.
+func writeProjectsTable(table tableWriter, iProject domain.IProject, filter domain.RProjectGetList) error {
+	var languages = domain.DefaultFallbackLocales
+	var specs = domain.SpecKeys(filter.Type)
+
+	var header = make([]interface{}, 0, len(exportColumns)+2*len(languages)+len(specs)+2)
+	for _, it := range exportColumns {
+		header = append(header, it)
+	}
+	for _, it := range languages {
+		header = append(header, "desc."+it+".name", "desc."+it+".desc")
+	}
+	for _, it := range specs {
+		header = append(header, "spec."+it)
+	}
+	header = append(header, "descs", "specs")
+
+	if err := table.WriteRow(header); nil != err {
+		return err
+	}
+	err := eachExportRow(iProject, filter, func(row *exportRow) error {
+		return table.WriteRow(row.cells(languages, specs))
+	})
+	if nil != err {
+		return err
+	}
+	return table.Close()
+}
+
+func (sv *Service) ExportProjects(req *pb.RPExportProjects, stream pb.ProjectService_ExportProjectsServer,
+) error {
+	if nil == req.Filter {
+		req.Filter = &pb.RPGetList{}
+	}
+	if req.Filter.IncludeDeleted && !sv.isAdmin(stream.Context()) {
+		return errAdminOnly
+	}
+	filter, err := convertGetList(req.Filter)
+	if nil != err {
+		return err
+	}
+	return WriteProjects(&chunkWriter{stream: stream}, sv.iProject, *filter, req.Format)
+}
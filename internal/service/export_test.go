@@ -0,0 +1,140 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+func newExportProjects(t *testing.T) domain.IProject {
+	_, iProject := newTestService(t)
+	var reqs = []*domain.RProjectCreate{
+		{
+			OwnerId:  "owner-export",
+			Location: dmodels.NewCoord4326(105.8, 21.0),
+			Descs:    []*domain.RProjectUpdateDesc{{Language: "vi", Name: "Hầm", Desc: "Hộ gia đình, 10m³"}},
+			Type:     int32(pb.ProjectType_PrjT_E),
+			Specs: &domain.RProjectUpdateSpecs{Specs: map[string]float64{
+				"digester_volume": 10, "generator_capacity_kw": 2,
+			}},
+		},
+		{
+			OwnerId: "owner-export",
+			Descs:   []*domain.RProjectUpdateDesc{{Language: "en", Name: "Digester"}, {Language: "fr", Name: "Digesteur"}},
+			Specs:   &domain.RProjectUpdateSpecs{},
+		},
+	}
+	for _, req := range reqs {
+		prj, err := iProject.Create(req)
+		if err != nil {
+			t.Fatalf("Create project fail: %s", err)
+		}
+		_, err = iProject.UpsertDocument(&domain.RProjectDocumentUpsert{
+			Document: []*domain.Document{{ProjectId: prj.Id, DocumentName: "PDD", Url: "/pdd.pdf"}},
+		})
+		if err != nil {
+			t.Fatalf("Upsert document fail: %s", err)
+		}
+	}
+	return iProject
+}
+
+func TestWriteProjectsCSV(t *testing.T) {
+	var iProject = newExportProjects(t)
+	var buf = &bytes.Buffer{}
+	if err := WriteProjects(buf, iProject, domain.RProjectGetList{Owner: "owner-export"}, ExportFormatCSV); err != nil {
+		t.Fatalf("Write csv fail: %s", err)
+	}
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not csv: %s", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expect a header and 2 rows, got %d records", len(records))
+	}
+
+	var columns = map[string]int{}
+	for i, it := range records[0] {
+		columns[it] = i
+	}
+	var expect = []string{"desc.en.name", "desc.vi.name", "desc.vi.desc", "documents", "descs", "specs"}
+	for _, key := range domain.SpecKeys(0) {
+		expect = append(expect, "spec."+key)
+	}
+	for _, it := range expect {
+		if _, ok := columns[it]; !ok {
+			t.Errorf("Expect column %s in %v", it, records[0])
+		}
+	}
+	// Newest project first.
+	var row = records[2]
+	if row[columns["desc.vi.desc"]] != "Hộ gia đình, 10m³" || row[columns["spec.digester_volume"]] != "10" ||
+		row[columns["specs"]] != "" ||
+		row[columns["descs"]] != "" || row[columns["longitude"]] != "105.8" || row[columns["documents"]] != "1" {
+		t.Errorf("Unexpected row %v", row)
+	}
+	row = records[1]
+	if row[columns["descs"]] != `[{"language":"fr","name":"Digesteur","desc":""}]` || row[columns["specs"]] != "" {
+		t.Errorf("Expect the fr desc as json, got %v", row)
+	}
+
+	// The descs and specs columns import back.
+	var data = "owner,countryId,type,desc.en.name,descs,specs\n" +
+		`0x01,vn,0,Digester,"[{""language"":""fr"",""name"":""Digesteur""}]","{""a"":1.5}"` + "\n"
+	rows, err := ParseImport(strings.NewReader(data), ImportFormatCSV)
+	if err != nil {
+		t.Fatalf("Parse descs and specs columns fail: %s", err)
+	}
+	var create = rows[0].Create
+	if rows[0].Err != nil || len(create.Descs) != 2 || create.Descs[1].Language != "fr" || create.Specs.Specs["a"] != 1.5 {
+		t.Errorf("Unexpected imported row %+v %v", create, rows[0].Err)
+	}
+}
+
+func TestWriteProjectsJSONL(t *testing.T) {
+	var iProject = newExportProjects(t)
+	var buf = &bytes.Buffer{}
+	if err := WriteProjects(buf, iProject, domain.RProjectGetList{Owner: "owner-export"}, ExportFormatJSONL); err != nil {
+		t.Fatalf("Write jsonl fail: %s", err)
+	}
+	rows, err := ParseImport(buf, ImportFormatJSON)
+	if err != nil {
+		t.Fatalf("Jsonl export must import again: %s", err)
+	}
+	if len(rows) != 2 || rows[1].Err != nil || rows[1].Create.Descs[0].Name != "Hầm" || nil == rows[1].Create.Location {
+		t.Errorf("Unexpected rows %+v", rows)
+	}
+}
+
+func TestWriteProjectsXLSX(t *testing.T) {
+	var iProject = newExportProjects(t)
+	var buf = &bytes.Buffer{}
+	if err := WriteProjects(buf, iProject, domain.RProjectGetList{Owner: "owner-export"}, ExportFormatXLSX); err != nil {
+		t.Fatalf("Write xlsx fail: %s", err)
+	}
+	if _, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+		t.Errorf("Output is not a workbook: %s", err)
+	}
+	if err := WriteProjects(buf, iProject, domain.RProjectGetList{}, "pdf"); err != errExportFormat {
+		t.Errorf("Unknown format expect errExportFormat, got %v", err)
+	}
+}
+
+func TestServiceExportProjectsIncludeDeleted(t *testing.T) {
+	sv, _ := newTestService(t)
+	var req = &pb.RPExportProjects{Filter: &pb.RPGetList{IncludeDeleted: true}, Format: ExportFormatJSONL}
+	var forged = &chunkStream{ctx: withToken(newToken("forged", `{"role":"super-admin"}`))}
+	if err := sv.ExportProjects(req, forged); err != errAdminOnly {
+		t.Errorf("Forged admin token expect errAdminOnly, got %v", err)
+	}
+	var admin = &chunkStream{ctx: withToken(newToken(testJwtKey, `{"role":"super-admin"}`))}
+	if err := sv.ExportProjects(req, admin); err != nil {
+		t.Errorf("Admin export fail: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+// geojsonPageSize is the number of projects read per GetList call while
+// writing a FeatureCollection.
+const geojsonPageSize = 200
+
+// geojsonChunkSize is the write buffer size, and so the size of the chunks
+// sent by ExportGeoJSON.
+const geojsonChunkSize = 32 * 1024
+
+type geoFeature struct {
+	Type       string         `json:"type"`
+	Geometry   *geoPoint      `json:"geometry"`
+	Properties *geoProperties `json:"properties"`
+}
+
+type geoPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type geoProperties struct {
+	Id       int64   `json:"id"`
+	Name     string  `json:"name"`
+	Type     int32   `json:"type"`
+	TypeName string  `json:"typeName"`
+	Unit     float32 `json:"unit"`
+	Status   int32   `json:"status"`
+	Country  string  `json:"country"`
+}
+
+// newGeoFeature builds a feature from the convertProject output of a
+// localized project. Projects without location have a null geometry.
+func newGeoFeature(in *pb.Project) *geoFeature {
+	var rs = &geoFeature{
+		Type: "Feature",
+		Properties: &geoProperties{
+			Id:     in.Id,
+			Type:   int32(in.Type),
+			Unit:   in.Unit,
+			Status: in.Status,
+		},
+	}
+	if len(in.Descs) > 0 {
+		rs.Properties.Name = in.Descs[0].Name
+	}
+	if nil != in.DetailType {
+		rs.Properties.TypeName = in.DetailType.Name
+	}
+	if nil != in.Country {
+		rs.Properties.Country = in.Country.CountryCode
+	}
+	if nil != in.Location {
+		rs.Geometry = &geoPoint{
+			Type:        "Point",
+			Coordinates: [2]float64{in.Location.Longitude, in.Location.Latitude},
+		}
+	}
+	return rs
+}
+
+// WriteGeoJSON writes the projects matching filter to w as a GeoJSON
+// FeatureCollection, named in filter.Locales. Projects are read page by
+// page so the collection is never held in memory; Skip and Limit of filter
+// are ignored.
+func WriteGeoJSON(w io.Writer, iProject domain.IProject, filter domain.RProjectGetList) error {
+	var buf = bufio.NewWriterSize(w, geojsonChunkSize)
+	if _, err := buf.WriteString(`{"type":"FeatureCollection","features":[`); nil != err {
+		return err
+	}
+
+	var first = true
+	err := eachProjectPage(iProject, filter, geojsonPageSize, func(data []*domain.Project) error {
+		for _, it := range data {
+			raw, err := json.Marshal(newGeoFeature(convertProject(it)))
+			if nil != err {
+				return err
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			if _, err := buf.Write(raw); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+	if nil != err {
+		return err
+	}
+
+	if _, err := buf.WriteString("]}\n"); nil != err {
+		return err
+	}
+	return buf.Flush()
+}
+
+// eachProjectPage calls fn with the pages of size projects matching
+// filter, so that callers never hold every project. Skip and Limit of
+// filter are ignored.
+func eachProjectPage(iProject domain.IProject, filter domain.RProjectGetList, size int,
+	fn func(data []*domain.Project) error,
+) error {
+	// Search ranks results, which does not work with cursors.
+	var useCursor = domain.SearchQuery(filter.SearchValue) == ""
+	filter.Skip = 0
+	filter.Limit = size
+	filter.Cursor = ""
+	filter.SkipTotal = true
+
+	for {
+		_, data, err := iProject.GetList(&filter)
+		if nil != err {
+			return err
+		}
+		if err := fn(data); nil != err {
+			return err
+		}
+		if len(data) < size {
+			return nil
+		}
+		if useCursor {
+			var last = data[len(data)-1]
+			filter.Cursor = domain.NewCursor(last.CreatedAt, last.Id).Encode()
+		} else {
+			filter.Skip += len(data)
+		}
+	}
+}
+
+// chunkWriter sends everything written to it as pb.Chunk messages.
+type chunkWriter struct {
+	stream interface{ Send(*pb.Chunk) error }
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&pb.Chunk{Data: p}); nil != err {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (sv *Service) ExportGeoJSON(req *pb.RPExportGeoJSON, stream pb.ProjectService_ExportGeoJSONServer,
+) error {
+	var ctx = stream.Context()
+	if nil == req.Filter {
+		req.Filter = &pb.RPGetList{}
+	}
+	if req.Filter.IncludeDeleted && !sv.isAdmin(ctx) {
+		return errAdminOnly
+	}
+	filter, err := convertGetList(req.Filter)
+	if nil != err {
+		return err
+	}
+	var lang = req.Lang
+	if lang == "" {
+		lang = req.Filter.Lang
+	}
+	filter.Locales = sv.getLocales(ctx, lang)
+	return WriteGeoJSON(&chunkWriter{stream: stream}, sv.iProject, *filter)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"google.golang.org/grpc"
+)
+
+func TestWriteGeoJSON(t *testing.T) {
+	_, iProject := newTestService(t)
+	for i := 0; i < geojsonPageSize+1; i++ {
+		var req = &domain.RProjectCreate{
+			Specs:   &domain.RProjectUpdateSpecs{},
+			Descs:   []*domain.RProjectUpdateDesc{{Language: "en", Name: "Name"}},
+			OwnerId: "owner",
+		}
+		if i == 0 {
+			req.Location = dmodels.NewCoord4326(105.8, 21.0)
+		}
+		if _, err := iProject.Create(req); err != nil {
+			t.Fatalf("Create project fail: %s", err)
+		}
+	}
+
+	var buf = &bytes.Buffer{}
+	var err = WriteGeoJSON(buf, iProject, domain.RProjectGetList{
+		Owner:   "owner",
+		Locales: []string{"vi"},
+	})
+	if err != nil {
+		t.Fatalf("Write geojson fail: %s", err)
+	}
+
+	var rs struct {
+		Type     string
+		Features []*geoFeature
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rs); err != nil {
+		t.Fatalf("Output is not json: %s", err)
+	}
+	if rs.Type != "FeatureCollection" || len(rs.Features) != geojsonPageSize+1 {
+		t.Fatalf("Expect a FeatureCollection of %d features", geojsonPageSize+1)
+	}
+
+	var last = rs.Features[len(rs.Features)-1]
+	if nil == last.Geometry || last.Geometry.Coordinates != [2]float64{105.8, 21.0} {
+		t.Errorf("Expect point geometry on the oldest project")
+	}
+	if last.Properties.Name != "Name" {
+		t.Errorf("Expect name fallback to the first desc, got %q", last.Properties.Name)
+	}
+	if nil != rs.Features[0].Geometry {
+		t.Errorf("Expect null geometry without location")
+	}
+}
+
+// chunkStream collects the chunks of a server stream.
+type chunkStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks bytes.Buffer
+}
+
+func (s *chunkStream) Context() context.Context { return s.ctx }
+
+func (s *chunkStream) Send(chunk *pb.Chunk) error {
+	_, err := s.chunks.Write(chunk.Data)
+	return err
+}
+
+func TestServiceExportGeoJSONIncludeDeleted(t *testing.T) {
+	sv, _ := newTestService(t)
+	var req = &pb.RPExportGeoJSON{Filter: &pb.RPGetList{IncludeDeleted: true}}
+	var forged = &chunkStream{ctx: withToken(newToken("forged", `{"role":"super-admin"}`))}
+	if err := sv.ExportGeoJSON(req, forged); err != errAdminOnly {
+		t.Errorf("Forged admin token expect errAdminOnly, got %v", err)
+	}
+	var admin = &chunkStream{ctx: withToken(newToken(testJwtKey, `{"role":"super-admin"}`))}
+	if err := sv.ExportGeoJSON(req, admin); err != nil || admin.chunks.Len() == 0 {
+		t.Errorf("Admin export fail: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+func (sv *Service) ListImages(ctx context.Context, req *pb.RPListImages,
+) (*pb.ProjectImages, error) {
+	if req.IncludeDeleted && !sv.isAdmin(ctx) {
+		return nil, errAdminOnly
+	}
+	data, err := sv.iProject.ListImages(&domain.RProjectImageList{
+		ProjectId:      req.ProjectId,
+		IncludeDeleted: req.IncludeDeleted,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return &pb.ProjectImages{Data: convertArr(data, convertProjectImage)}, nil
+}
+
+func (sv *Service) DeleteImage(ctx context.Context, req *pb.RPDeleteImage,
+) (*pb.Int64, error) {
+	err := sv.iProject.DeleteImage(&domain.RProjectImageDelete{
+		Audit:     sv.getAudit(ctx),
+		ProjectId: req.ProjectId,
+		ImageId:   req.ImageId,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return &pb.Int64{Data: req.ImageId}, nil
+}
+
+func (sv *Service) ReorderImages(ctx context.Context, req *pb.RPReorderImages,
+) (*pb.ProjectImages, error) {
+	data, err := sv.iProject.ReorderImages(&domain.RProjectImageReorder{
+		Audit:     sv.getAudit(ctx),
+		ProjectId: req.ProjectId,
+		ImageIds:  req.ImageIds,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return &pb.ProjectImages{Data: convertArr(data, convertProjectImage)}, nil
+}
+
+func (sv *Service) UpdateImageCaption(ctx context.Context, req *pb.RPUpdateImageCaption,
+) (*pb.ProjectImage, error) {
+	image, err := sv.iProject.UpdateImageCaption(&domain.RProjectImageCaption{
+		Audit:     sv.getAudit(ctx),
+		ProjectId: req.ProjectId,
+		ImageId:   req.ImageId,
+		Caption:   req.Caption,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return convertProjectImage(image), nil
+}
+
+func (sv *Service) SetThumbnail(ctx context.Context, req *pb.RPSetThumbnail,
+) (*pb.String, error) {
+	image, err := sv.iProject.SetThumbnail(&domain.RProjectSetThumbnail{
+		Audit:     sv.getAudit(ctx),
+		ProjectId: req.ProjectId,
+		ImageId:   req.ImageId,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return &pb.String{Data: image.Image}, nil
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/projects/internal/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	EnvImportMaxBytes     = "PROJECTS_IMPORT_MAX_BYTES"
+	defaultImportMaxBytes = 10 << 20
+
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+var (
+	errImportFormat   = status.Error(codes.InvalidArgument, "import format must be csv or json")
+	errImportTooLarge = status.Error(codes.InvalidArgument, "import exceeds the size limit")
+	errImportLocation = errors.New("longitude and latitude go together")
+)
+
+// importRow is a row of an import file. Csv columns use the json names,
+// with desc.<language>.name, desc.<language>.desc and spec.<key> for the
+// descs and specs. The descs and specs columns hold more of them as json,
+// as the export writes them.
+type importRow struct {
+	Owner        string             `json:"owner"`
+	OwnerId      string             `json:"ownerId"`
+	OwnerAddress string             `json:"ownerAddress"`
+	CountryId    string             `json:"countryId"`
+	Type         int32              `json:"type"`
+	Unit         float32            `json:"unit"`
+	Area         float64            `json:"area"`
+	LocationName string             `json:"locationName"`
+	Iframe       string             `json:"iframe"`
+	Longitude    *float64           `json:"longitude"`
+	Latitude     *float64           `json:"latitude"`
+	Descs        []*importDesc      `json:"descs"`
+	Specs        map[string]float64 `json:"specs"`
+}
+
+type importDesc struct {
+	Language string `json:"language"`
+	Name     string `json:"name"`
+	Desc     string `json:"desc"`
+}
+
+func (row *importRow) toCreate() (*domain.RProjectCreate, error) {
+	var req = &domain.RProjectCreate{
+		Owner:        dmodels.EthAddress(row.Owner),
+		Specs:        &domain.RProjectUpdateSpecs{Specs: row.Specs},
+		Area:         row.Area,
+		LocationName: row.LocationName,
+		Type:         row.Type,
+		Unit:         row.Unit,
+		CountryId:    row.CountryId,
+		OwnerId:      row.OwnerId,
+		Iframe:       row.Iframe,
+		OwnerAddress: row.OwnerAddress,
+	}
+	switch {
+	case nil != row.Longitude && nil != row.Latitude:
+		req.Location = dmodels.NewCoord4326(*row.Longitude, *row.Latitude)
+	case nil != row.Longitude || nil != row.Latitude:
+		return nil, errImportLocation
+	}
+	for _, it := range row.Descs {
+		req.Descs = append(req.Descs, &domain.RProjectUpdateDesc{
+			Language: it.Language,
+			Name:     it.Name,
+			Desc:     it.Desc,
+		})
+	}
+	return req, nil
+}
+
+// ParseImport reads the rows of an import in format. A row that cannot be
+// read keeps its error, only a malformed file fails the whole import.
+func ParseImport(r io.Reader, format string) ([]*domain.ImportRow, error) {
+	switch strings.ToLower(format) {
+	case ImportFormatCSV:
+		return parseImportCSV(r)
+	case ImportFormatJSON:
+		return parseImportJSON(r)
+	}
+	return nil, errImportFormat
+}
+
+func parseImportCSV(r io.Reader) ([]*domain.ImportRow, error) {
+	var reader = csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if nil != err {
+		if err == io.EOF {
+			return nil, domain.ErrImportEmpty
+		}
+		return nil, status.Error(codes.InvalidArgument, "import header: "+err.Error())
+	}
+	for i, it := range header {
+		header[i] = strings.TrimSpace(it)
+		if err := checkImportColumn(header[i]); nil != err {
+			return nil, status.Error(codes.InvalidArgument, "import header: "+err.Error())
+		}
+	}
+
+	var rows = make([]*domain.ImportRow, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var row = &domain.ImportRow{Row: len(rows) + 1}
+		rows = append(rows, row)
+		if nil != err {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) || parseErr.Err != csv.ErrFieldCount {
+				return nil, status.Error(codes.InvalidArgument, "import: "+err.Error())
+			}
+			row.Err = err
+			continue
+		}
+		row.Create, row.Err = csvRow(header, record)
+	}
+}
+
+var importColumns = map[string]bool{
+	"owner": true, "ownerId": true, "ownerAddress": true, "countryId": true,
+	"type": true, "unit": true, "area": true, "locationName": true,
+	"iframe": true, "longitude": true, "latitude": true, "descs": true, "specs": true,
+}
+
+func checkImportColumn(name string) error {
+	if importColumns[name] {
+		return nil
+	}
+	if key, ok := strings.CutPrefix(name, "spec."); ok && key != "" {
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(name, "desc."); ok {
+		var language, field, _ = strings.Cut(rest, ".")
+		if language != "" && (field == "name" || field == "desc") {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown column %q", name)
+}
+
+// csvRow maps a record on header. Empty cells are left unset.
+func csvRow(header, record []string) (*domain.RProjectCreate, error) {
+	var row = &importRow{Specs: map[string]float64{}}
+	var descs = map[string]*importDesc{}
+	for i, name := range header {
+		var value = strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+		if err := row.set(name, value, descs); nil != err {
+			return nil, fmt.Errorf("column %s: %s", name, err)
+		}
+	}
+	for _, name := range header {
+		if rest, ok := strings.CutPrefix(name, "desc."); ok {
+			var language, _, _ = strings.Cut(rest, ".")
+			if desc, ok := descs[language]; ok {
+				row.Descs = append(row.Descs, desc)
+				delete(descs, language)
+			}
+		}
+	}
+	var languages = make([]string, 0, len(descs))
+	for it := range descs {
+		languages = append(languages, it)
+	}
+	sort.Strings(languages)
+	for _, it := range languages {
+		row.Descs = append(row.Descs, descs[it])
+	}
+	if len(row.Specs) == 0 {
+		row.Specs = nil
+	}
+	return row.toCreate()
+}
+
+func (row *importRow) set(name, value string, descs map[string]*importDesc) error {
+	var err error
+	switch name {
+	case "owner":
+		row.Owner = value
+	case "ownerId":
+		row.OwnerId = value
+	case "ownerAddress":
+		row.OwnerAddress = value
+	case "countryId":
+		row.CountryId = value
+	case "type":
+		var t int64
+		t, err = strconv.ParseInt(value, 10, 32)
+		row.Type = int32(t)
+	case "unit":
+		var unit float64
+		unit, err = strconv.ParseFloat(value, 32)
+		row.Unit = float32(unit)
+	case "area":
+		row.Area, err = strconv.ParseFloat(value, 64)
+	case "locationName":
+		row.LocationName = value
+	case "iframe":
+		row.Iframe = value
+	case "descs":
+		var list = make([]*importDesc, 0)
+		if err := json.Unmarshal([]byte(value), &list); nil != err {
+			return fmt.Errorf("invalid json %q", value)
+		}
+		for _, it := range list {
+			if nil != it {
+				descs[it.Language] = it
+			}
+		}
+	case "specs":
+		if err := json.Unmarshal([]byte(value), &row.Specs); nil != err {
+			return fmt.Errorf("invalid json %q", value)
+		}
+	case "longitude", "latitude":
+		var coord float64
+		coord, err = strconv.ParseFloat(value, 64)
+		if name == "longitude" {
+			row.Longitude = &coord
+		} else {
+			row.Latitude = &coord
+		}
+	default:
+		if key, ok := strings.CutPrefix(name, "spec."); ok {
+			row.Specs[key], err = strconv.ParseFloat(value, 64)
+			break
+		}
+		var language, field, _ = strings.Cut(strings.TrimPrefix(name, "desc."), ".")
+		var desc, ok = descs[language]
+		if !ok {
+			desc = &importDesc{Language: language}
+			descs[language] = desc
+		}
+		if field == "name" {
+			desc.Name = value
+		} else {
+			desc.Desc = value
+		}
+	}
+	if nil != err {
+		return fmt.Errorf("invalid number %q", value)
+	}
+	return nil
+}
+
+// parseImportJSON reads a json array of rows, or one row per line.
+func parseImportJSON(r io.Reader) ([]*domain.ImportRow, error) {
+	var reader = bufio.NewReader(r)
+	var first, err = skipSpaces(reader)
+	if nil != err {
+		return nil, domain.ErrImportEmpty
+	}
+	var decoder = json.NewDecoder(reader)
+	var array = first == '['
+	if array {
+		if _, err := decoder.Token(); nil != err {
+			return nil, status.Error(codes.InvalidArgument, "import: "+err.Error())
+		}
+	}
+
+	var rows = make([]*domain.ImportRow, 0)
+	for !array || decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); nil != err {
+			if err == io.EOF && !array {
+				break
+			}
+			return nil, status.Error(codes.InvalidArgument, "import: "+err.Error())
+		}
+		var row = &domain.ImportRow{Row: len(rows) + 1}
+		rows = append(rows, row)
+
+		var it = &importRow{}
+		if err := json.Unmarshal(raw, it); nil != err {
+			row.Err = err
+			continue
+		}
+		row.Create, row.Err = it.toCreate()
+	}
+	return rows, nil
+}
+
+// skipSpaces discards the leading white space of reader and returns the
+// next byte, left unread.
+func skipSpaces(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.Peek(1)
+		if nil != err {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			reader.Discard(1)
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// ImportProjects creates the projects of a csv or json file streamed by
+// the client. The first message carries the format and options.
+func (sv *Service) ImportProjects(stream pb.ProjectService_ImportProjectsServer) error {
+	req, err := stream.Recv()
+	if nil != err {
+		return recvError(err)
+	}
+	var body = &uploadStream{buf: req.Data, recv: func() ([]byte, error) {
+		msg, err := stream.Recv()
+		if nil != err {
+			return nil, err
+		}
+		return msg.Data, nil
+	}}
+
+	var max = int64(utils.IntEnv(EnvImportMaxBytes, defaultImportMaxBytes))
+	data, err := io.ReadAll(io.LimitReader(body, max+1))
+	if nil != err {
+		return err
+	}
+	if int64(len(data)) > max {
+		return errImportTooLarge
+	}
+	rows, err := ParseImport(bytes.NewReader(data), req.Format)
+	if nil != err {
+		return err
+	}
+	report, err := sv.iProject.ImportProjects(&domain.RProjectImport{
+		Rows:         rows,
+		AllOrNothing: req.AllOrNothing,
+		DryRun:       req.DryRun,
+	})
+	if nil != err {
+		return err
+	}
+	return stream.SendAndClose(convertImportReport(report))
+}
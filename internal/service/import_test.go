@@ -0,0 +1,59 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	var input = "ownerId,type,countryId,longitude,latitude,desc.vi.name,desc.en.name,desc.en.desc,spec.digester_volume\n" +
+		"o1,2,vn,105.8,21.0,Hầm biogas,Digester,\"Household, 10m³\",10\n" +
+		"o2,x,vn,,,,,,\n" +
+		"o3,2,vn,105.8,,A,,,\n"
+	rows, err := ParseImport(strings.NewReader(input), "csv")
+	if err != nil {
+		t.Fatalf("Parse csv fail: %s", err)
+	}
+	if len(rows) != 3 || rows[0].Err != nil || rows[1].Err == nil || rows[2].Err != errImportLocation {
+		t.Fatalf("Expect row 1 valid, 2 and 3 failed, got %+v", rows)
+	}
+	var req = rows[0].Create
+	if req.OwnerId != "o1" || req.Type != 2 || nil == req.Location || req.GetSpecs()["digester_volume"] != 10 {
+		t.Errorf("Unexpected row 1: %+v", req)
+	}
+	if len(req.Descs) != 2 || req.Descs[0].Language != "vi" || req.Descs[1].Desc != "Household, 10m³" {
+		t.Errorf("Unexpected descs of row 1: %+v %+v", req.Descs[0], req.Descs[1])
+	}
+
+	if _, err := ParseImport(strings.NewReader("ownerId,colour\n"), "csv"); err == nil {
+		t.Errorf("Unknown column expect an error")
+	}
+}
+
+func TestParseImportJSON(t *testing.T) {
+	var array = `[
+		{"ownerId": "o1", "type": 2, "descs": [{"language": "en", "name": "Digester"}], "specs": {"digester_volume": 10}},
+		{"ownerId": "o2", "type": "biogas"}
+	]`
+	var lines = `{"ownerId": "o1", "longitude": 105.8, "latitude": 21.0}
+{"ownerId": "o2"}
+`
+	for name, input := range map[string]string{"array": array, "lines": lines} {
+		rows, err := ParseImport(strings.NewReader(input), "json")
+		if err != nil {
+			t.Errorf("Parse json %s fail: %s", name, err)
+			continue
+		}
+		if len(rows) != 2 || rows[0].Err != nil || rows[0].Create.OwnerId != "o1" || rows[1].Row != 2 {
+			t.Errorf("Unexpected rows of json %s: %+v", name, rows)
+		}
+	}
+	if _, err := ParseImport(strings.NewReader("  "), "json"); err != domain.ErrImportEmpty {
+		t.Errorf("Empty json expect ErrImportEmpty, got %v", err)
+	}
+	if _, err := ParseImport(strings.NewReader("{}"), "xml"); err != errImportFormat {
+		t.Errorf("Unknown format expect errImportFormat, got %v", err)
+	}
+}
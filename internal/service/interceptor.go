@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// StreamInterceptor runs a unary interceptor in front of a streaming RPC,
+// so the panic, log and auth interceptors also guard UploadImage,
+// ImportProjects and the other streams. The unary interceptor gets a nil
+// request; the context it passes on becomes the context of the stream.
+func StreamInterceptor(unary grpc.UnaryServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		var unaryInfo = &grpc.UnaryServerInfo{Server: srv, FullMethod: info.FullMethod}
+		_, err := unary(ss.Context(), nil, unaryInfo, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		})
+		return err
+	}
+}
+
+// contextStream is a stream with the context of its interceptors.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type actorKey struct{}
+
+// requireToken stands for the auth interceptor on a Require: true RPC.
+func requireToken(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, h grpc.UnaryHandler,
+) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("authorization")) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "token required")
+	}
+	return h(context.WithValue(ctx, actorKey{}, info.FullMethod), req)
+}
+
+type contextOnlyStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextOnlyStream) Context() context.Context { return s.ctx }
+
+func TestStreamInterceptorAuth(t *testing.T) {
+	var intercept = StreamInterceptor(requireToken)
+	var info = &grpc.StreamServerInfo{FullMethod: "/pb.ProjectService/ImportProjects", IsClientStream: true}
+	var called = false
+	var handler = func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		if ss.Context().Value(actorKey{}) != info.FullMethod {
+			t.Errorf("Handler expect the context of the interceptor")
+		}
+		return nil
+	}
+
+	err := intercept(nil, &contextOnlyStream{ctx: context.TODO()}, info, handler)
+	if status.Code(err) != codes.Unauthenticated || called {
+		t.Errorf("Anonymous stream expect Unauthenticated before the handler, got %v", err)
+	}
+
+	var ctx = metadata.NewIncomingContext(context.TODO(), metadata.Pairs("authorization", "Bearer token"))
+	if err := intercept(nil, &contextOnlyStream{ctx: ctx}, info, handler); nil != err || !called {
+		t.Errorf("Authenticated stream expect the handler, got %v", err)
+	}
+}
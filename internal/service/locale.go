@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Dcarbon/projects/internal/domain"
+	"google.golang.org/grpc/metadata"
+)
+
+// EnvFallbackLocales sets the locales tried after the requested ones, as
+// in "en,vi". Descs fall back to any language after the chain.
+const EnvFallbackLocales = "PROJECTS_FALLBACK_LOCALES"
+
+// acceptLanguageKeys hold the Accept-Language header, as sent by gRPC
+// clients or forwarded by grpc-gateway.
+var acceptLanguageKeys = []string{"accept-language", "grpcgateway-accept-language"}
+
+// getLocales returns the locale chain of a request: lang when set, else
+// the accept-language metadata, followed by the fallback chain.
+func (sv *Service) getLocales(ctx context.Context, lang string) []string {
+	if lang == "" {
+		lang = getAcceptLanguage(ctx)
+	}
+	return sv.locales.Negotiate(lang)
+}
+
+func getAcceptLanguage(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, key := range acceptLanguageKeys {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func newLocaleResolver(fallback string) *domain.LocaleResolver {
+	return domain.NewLocaleResolver(domain.ParseFallbackLocales(fallback))
+}
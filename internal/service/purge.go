@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+const (
+	// EnvPurgeRetention is how long deleted documents are kept before the
+	// purge, as a Go duration such as "720h".
+	EnvPurgeRetention = "PROJECTS_PURGE_RETENTION"
+
+	// EnvPurgeInterval is the period of the purge worker of the server.
+	// Empty or 0 leaves purging to the purge-documents command.
+	EnvPurgeInterval = "PROJECTS_PURGE_INTERVAL"
+
+	defaultPurgeRetention = 30 * 24 * time.Hour
+)
+
+// PurgeRetention reads EnvPurgeRetention.
+func PurgeRetention() (time.Duration, error) {
+	return durationEnv(EnvPurgeRetention, defaultPurgeRetention)
+}
+
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	var value = utils.StringEnv(key, "")
+	if value == "" {
+		return def, nil
+	}
+	rs, err := time.ParseDuration(value)
+	if nil != err || rs < 0 {
+		return 0, fmt.Errorf("%s: invalid duration %q", key, value)
+	}
+	return rs, nil
+}
+
+// DocumentPurger hard-deletes the documents deleted for longer than
+// Retention, then their storage objects.
+type DocumentPurger struct {
+	iProject  domain.IProject
+	storage   fileStorage
+	Retention time.Duration
+}
+
+func NewDocumentPurger(iProject domain.IProject, storage fileStorage, retention time.Duration,
+) *DocumentPurger {
+	return &DocumentPurger{iProject: iProject, storage: storage, Retention: retention}
+}
+
+// Purge removes the documents deleted before now - Retention. Storage
+// objects are deleted after the rows, a failure only leaves an orphan
+// object which is reported in Failed.
+func (p *DocumentPurger) Purge(now time.Time, dryRun bool) (*domain.DocumentPurgeReport, error) {
+	report, err := p.iProject.PurgeDocuments(&domain.RDocumentPurge{
+		Before: now.Add(-p.Retention),
+		DryRun: dryRun,
+	})
+	if nil != err || dryRun {
+		return report, err
+	}
+	for _, path := range report.Files {
+		if err := p.storage.Delete(path); nil != err {
+			if nil == report.Failed {
+				report.Failed = make(map[string]string)
+			}
+			report.Failed[path] = err.Error()
+		}
+	}
+	return report, nil
+}
+
+// Run purges every interval until ctx is done.
+func (p *DocumentPurger) Run(ctx context.Context, interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			report, err := p.Purge(now, false)
+			if nil != err {
+				log.Println("Purge documents: ", err)
+				continue
+			}
+			if len(report.Documents) > 0 || len(report.Failed) > 0 {
+				log.Printf("Purged %d document(s) deleted before %s, %d file(s), %d failed",
+					len(report.Documents), report.Before.Format(time.RFC3339),
+					len(report.Files)-len(report.Failed), len(report.Failed))
+			}
+		}
+	}
+}
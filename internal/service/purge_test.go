@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/domain/repo"
+)
+
+type failingStorage struct {
+	memoryStorage
+	fail string
+}
+
+func (s *failingStorage) Delete(path string) error {
+	if path == s.fail {
+		return errors.New("storage unavailable")
+	}
+	return s.memoryStorage.Delete(path)
+}
+
+func TestDocumentPurger(t *testing.T) {
+	var iProject = repo.NewMemoryImpl()
+	var storage = &failingStorage{
+		memoryStorage: memoryStorage{files: map[string][]byte{"/a.pdf": {1}, "/b.pdf": {2}}},
+		fail:          "/b.pdf",
+	}
+	prj, err := iProject.Create(&domain.RProjectCreate{Specs: &domain.RProjectUpdateSpecs{}})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+	docs, err := iProject.UpsertDocument(&domain.RProjectDocumentUpsert{
+		Document: []*domain.Document{
+			{ProjectId: prj.Id, DocumentName: "A", Url: "/a.pdf", Checksum: "a"},
+			{ProjectId: prj.Id, DocumentName: "B", Url: "/b.pdf", Checksum: "b"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Upsert documents fail: %s", err)
+	}
+	if err := iProject.DeleteDocument(&domain.RProjectDocumentDelete{Id: []int64{docs[0].Id, docs[1].Id}}); err != nil {
+		t.Fatalf("Delete documents fail: %s", err)
+	}
+
+	var purger = NewDocumentPurger(iProject, storage, time.Hour)
+	if report, _ := purger.Purge(time.Now(), false); len(report.Documents) != 0 {
+		t.Errorf("Documents within retention must stay, got %d purged", len(report.Documents))
+	}
+
+	var later = time.Now().Add(2 * time.Hour)
+	report, err := purger.Purge(later, true)
+	if err != nil || len(report.Files) != 2 || len(storage.files) != 2 {
+		t.Errorf("Dry run expect 2 files reported and kept, got %v (%v)", report, err)
+	}
+
+	report, err = purger.Purge(later, false)
+	if err != nil {
+		t.Fatalf("Purge fail: %s", err)
+	}
+	if _, ok := storage.files["/a.pdf"]; ok {
+		t.Errorf("Purge must delete /a.pdf from storage")
+	}
+	if len(report.Failed) != 1 || report.Failed["/b.pdf"] == "" {
+		t.Errorf("Purge expect /b.pdf failed, got %v", report.Failed)
+	}
+}
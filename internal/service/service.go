@@ -3,8 +3,6 @@ package service
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/Dcarbon/projects/internal/domain/repo"
 
@@ -12,7 +10,9 @@ import (
 	"github.com/Dcarbon/go-shared/dmodels"
 	"github.com/Dcarbon/go-shared/gutils"
 	"github.com/Dcarbon/go-shared/libs/sclient"
+	"github.com/Dcarbon/go-shared/libs/utils"
 	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/migration"
 	"github.com/Dcarbon/projects/internal/rss"
 )
 
@@ -20,14 +20,38 @@ type Service struct {
 	pb.UnimplementedProjectServiceServer
 	*gutils.GService
 	iProject domain.IProject
-	storage  sclient.IStorage
+	iCountry domain.ICountry
+	storage  fileStorage
+	locales  *domain.LocaleResolver
+	fetch    func(ctx context.Context, path string) ([]byte, error) // Downloads stored images, nil skips variants of AddImage
+	jwtKey   []byte                                                 // Checks the tokens of callers claiming admin
+
+	requireDocuments bool // Activation waits for the required documents
 }
 
 func NewProjectService(config *gutils.Config,
 ) (*Service, error) {
 	rss.SetUrl(config.GetDBUrl())
 
-	iProject, err := repo.NewProjectImpl(rss.GetDB())
+	if path := utils.StringEnv(EnvDocumentCatalogue, ""); path != "" {
+		if err := domain.LoadDocumentCatalogue(path); nil != err {
+			return nil, err
+		}
+	}
+
+	migrator, err := migration.NewMigrator(rss.GetDB())
+	if nil != err {
+		return nil, err
+	}
+	if err := migrator.Check(); nil != err {
+		return nil, err
+	}
+
+	iCountry, err := repo.NewCountryImpl(rss.GetDB())
+	if nil != err {
+		return nil, err
+	}
+	iProject, err := repo.NewProjectImpl(rss.GetDB(), iCountry)
 	if nil != err {
 		return nil, err
 	}
@@ -45,7 +69,25 @@ func NewProjectService(config *gutils.Config,
 	}
 	var sv = &Service{
 		iProject: iProject,
+		iCountry: iCountry,
 		storage:  storage,
+		fetch:    fetchStorage,
+		locales:  newLocaleResolver(utils.StringEnv(EnvFallbackLocales, "")),
+		jwtKey:   []byte(config.JwtKey),
+
+		requireDocuments: activateRequireDocuments(),
+	}
+
+	retention, err := PurgeRetention()
+	if nil != err {
+		return nil, err
+	}
+	interval, err := durationEnv(EnvPurgeInterval, 0)
+	if nil != err {
+		return nil, err
+	}
+	if interval > 0 {
+		go NewDocumentPurger(iProject, storage, retention).Run(context.Background(), interval)
 	}
 
 	return sv, nil
@@ -83,6 +125,7 @@ func (sv *Service) Create(ctx context.Context, req *pb.RPCreate,
 func (sv *Service) UpdateDesc(ctx context.Context, req *pb.RPUpdateDesc,
 ) (*pb.ProjectDesc, error) {
 	desc, err := sv.iProject.UpdateDesc(&domain.RProjectUpdateDesc{
+		Audit:     sv.getAudit(ctx),
 		ProjectId: req.ProjectId,
 		Language:  req.Language,
 		Name:      req.Name,
@@ -97,8 +140,12 @@ func (sv *Service) UpdateDesc(ctx context.Context, req *pb.RPUpdateDesc,
 func (sv *Service) UpdateSpecs(ctx context.Context, req *pb.RPUpdateSpecs,
 ) (*pb.ProjectSpecs, error) {
 	spec, err := sv.iProject.UpdateSpecs(&domain.RProjectUpdateSpecs{
+		Audit:     sv.getAudit(ctx),
 		ProjectId: req.ProjectId,
 		Specs:     req.Specs,
+		Version:   req.Version,
+		// Milliseconds since epoch, 0 means now.
+		EffectiveFrom: fromMillis(req.EffectiveFrom),
 	})
 	if err != nil {
 		return nil, err
@@ -106,10 +153,23 @@ func (sv *Service) UpdateSpecs(ctx context.Context, req *pb.RPUpdateSpecs,
 	return convertProjectSpecs(spec), nil
 }
 
+// AddImage attaches an image the client stored itself, with the variants
+// variantsOf could build. The original keeps its metadata.
 func (sv *Service) AddImage(ctx context.Context, req *pb.RPAddImage,
 ) (*pb.String, error) {
-	image, err := sv.iProject.AddImage(&domain.RProjectAddImage{ProjectId: req.ProjectId, ImgPath: req.Image, Type: req.Type})
+	if _, err := sv.iProject.GetById(&domain.RProjectGetById{Id: req.ProjectId}); nil != err {
+		return nil, err
+	}
+	var variants = sv.variantsOf(ctx, req.ProjectId, req.Image)
+	image, err := sv.iProject.AddImage(&domain.RProjectAddImage{
+		Audit:     sv.getAudit(ctx),
+		ProjectId: req.ProjectId,
+		ImgPath:   req.Image,
+		Type:      req.Type,
+		Variants:  variants,
+	})
 	if err != nil {
+		sv.removeFiles(variantPaths(variants)...)
 		return nil, err
 	}
 	return &pb.String{Data: image.Image}, nil
@@ -117,7 +177,14 @@ func (sv *Service) AddImage(ctx context.Context, req *pb.RPAddImage,
 
 func (sv *Service) GetById(ctx context.Context, req *pb.RPGetById,
 ) (*pb.Project, error) {
-	data, err := sv.iProject.GetById(req.ProjectId, req.Lang)
+	if req.IncludeDeleted && !sv.isAdmin(ctx) {
+		return nil, errAdminOnly
+	}
+	data, err := sv.iProject.GetById(&domain.RProjectGetById{
+		Id:             req.ProjectId,
+		Locales:        sv.getLocales(ctx, req.Lang),
+		IncludeDeleted: req.IncludeDeleted,
+	})
 	if nil != err {
 		return nil, err
 	}
@@ -128,7 +195,28 @@ func (sv *Service) GetById(ctx context.Context, req *pb.RPGetById,
 
 func (sv *Service) ChangeStatus(ctx context.Context, req *pb.RPChangeStatus,
 ) (*pb.Int64, error) {
-	if err := sv.iProject.ChangeStatus(int(req.ProjectId), domain.ProjectStatus(req.Status)); nil != err {
+	if err := sv.iProject.ChangeStatus(&domain.RProjectChangeStatus{
+		Audit:            sv.getAudit(ctx),
+		ProjectId:        req.ProjectId,
+		Status:           domain.ProjectStatus(req.Status),
+		Reason:           req.Reason,
+		RequireDocuments: sv.requireDocuments,
+	}); nil != err {
+		return nil, err
+	}
+	return &pb.Int64{Data: req.ProjectId}, nil
+}
+
+// ResubmitProject sends a rejected project back to review. Only its owner
+// may resubmit it.
+func (sv *Service) ResubmitProject(ctx context.Context, req *pb.RPResubmitProject,
+) (*pb.Int64, error) {
+	if err := sv.iProject.ChangeStatus(&domain.RProjectChangeStatus{
+		Audit:     sv.getAudit(ctx),
+		ProjectId: req.ProjectId,
+		Status:    domain.ProjectStatusRegister,
+		Resubmit:  true,
+	}); nil != err {
 		return nil, err
 	}
 	return &pb.Int64{Data: req.ProjectId}, nil
@@ -136,45 +224,58 @@ func (sv *Service) ChangeStatus(ctx context.Context, req *pb.RPChangeStatus,
 
 func (sv *Service) GetList(ctx context.Context, req *pb.RPGetList,
 ) (*pb.Projects, error) {
-	intArray := []int{}
-	if strings.TrimSpace(req.Ids) != "" {
-		datas := strings.Split(req.Ids, ",")
-		// Convert each string to an integer
-		for _, s := range datas {
-			// Convert string to integer
-			num, err := strconv.Atoi(strings.TrimSpace(s))
-			if err != nil {
-				fmt.Printf("Error converting string to int: %v\n", err)
-				return nil, err
-			}
-			intArray = append(intArray, num) // Append the converted integer to intArray
-		}
+	if req.IncludeDeleted && !sv.isAdmin(ctx) {
+		return nil, errAdminOnly
 	}
-	count, data, err := sv.iProject.GetList(&domain.RProjectGetList{
-		Skip:        int(req.Skip),
-		Limit:       int(req.Limit),
-		Owner:       req.OwnerId,
-		Unit:        int64(req.Unit),
-		CountryId:   req.CountryId,
-		Type:        int64(req.Type),
-		SearchValue: req.SearchValue,
-		Location:    req.Location,
-		Status:      int(req.Status),
-		Ids:         intArray,
+	filter, err := convertGetList(req)
+	if nil != err {
+		return nil, err
+	}
+	filter.Locales = sv.getLocales(ctx, req.Lang)
+	count, data, err := sv.iProject.GetList(filter)
+	if nil != err {
+		return nil, err
+	}
+	var rs = &pb.Projects{
+		Data: convertArr[domain.Project, pb.Project](data, convertProject),
+	}
+	if nil != count {
+		rs.Total = *count
+	}
+	if req.Limit > 0 && len(data) == int(req.Limit) && filter.CursorPaging() {
+		var last = data[len(data)-1]
+		rs.NextCursor = domain.NewCursor(last.CreatedAt, last.Id).Encode()
+	}
+	return rs, nil
+}
+
+func (sv *Service) GetNearby(ctx context.Context, req *pb.RPGetNearby,
+) (*pb.Projects, error) {
+	data, err := sv.iProject.GetNearby(&domain.RProjectGetNearby{
+		Near:      convertNear(req.Location),
+		Limit:     int(req.Limit),
+		Status:    int(req.Status),
+		Type:      int64(req.Type),
+		Unit:      int64(req.Unit),
+		CountryId: req.CountryId,
+		Locales:   sv.getLocales(ctx, req.Lang),
 	})
 	if nil != err {
 		return nil, err
 	}
-	return &pb.Projects{
-		Total: *count,
+	var rs = &pb.Projects{
+		Total: int64(len(data)),
 		Data:  convertArr[domain.Project, pb.Project](data, convertProject),
-	}, nil
+	}
+	return rs, nil
 }
 
 func (sv *Service) Update(ctx context.Context, req *pb.RPUpdate,
 ) (*pb.Int64, error) {
 	id, err := sv.iProject.Update(&domain.RProjectUpdate{
+		Audit:        sv.getAudit(ctx),
 		ProjectId:    req.ProjectId,
+		Version:      req.Version,
 		Owner:        dmodels.EthAddress(req.Owner),
 		Location:     dmodels.NewCoord4326(req.Location.Longitude, req.Location.Latitude),
 		LocationName: req.LocationName,
@@ -203,7 +304,10 @@ func (sv *Service) UpsertDocument(ctx context.Context, req *pb.RUpsertDocument)
 			DocumentName: val.DocumentName,
 		})
 	}
-	data, err := sv.iProject.UpsertDocument(&domain.RProjectDocumentUpsert{Document: documents})
+	data, err := sv.iProject.UpsertDocument(&domain.RProjectDocumentUpsert{
+		Audit:    sv.getAudit(ctx),
+		Document: documents,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -221,17 +325,102 @@ func (sv *Service) DeleteDocument(ctx context.Context, req *pb.RDeleteDocument)
 	return nil, nil
 }
 func (sv *Service) ListDocument(ctx context.Context, req *pb.RListDocument) (*pb.RPListDocument, error) {
-	data, count, err := sv.iProject.ListDocument(&domain.RProjectDocumentList{
-		Skip:  int(req.Skip),
-		Limit: int(req.Limit),
-		Ids:   req.Ids,
-	})
+	var filter = &domain.RProjectDocumentList{
+		Skip:          int(req.Skip),
+		Limit:         int(req.Limit),
+		Ids:           req.Ids,
+		ProjectIds:    req.ProjectIds,
+		DocumentTypes: req.DocumentTypes,
+		Name:          req.Name,
+		CreatedFrom:   fromMillis(req.CreatedFrom),
+		CreatedTo:     fromMillis(req.CreatedTo),
+		UpdatedFrom:   fromMillis(req.UpdatedFrom),
+		UpdatedTo:     fromMillis(req.UpdatedTo),
+		Sort:          domain.DocumentSort(req.Sort),
+		Cursor:        req.Cursor,
+		SkipTotal:     req.SkipTotal,
+	}
+	data, count, err := sv.iProject.ListDocument(filter)
 	if err != nil {
 		return nil, err
 	}
-	return &pb.RPListDocument{
+	var rs = &pb.RPListDocument{
 		Documents: convertArr(data, convertDocument),
 		Total:     count,
+	}
+	if req.Limit > 0 && len(data) == int(req.Limit) && filter.Sort == domain.DocumentSortCreatedDesc {
+		var last = data[len(data)-1]
+		rs.NextCursor = domain.NewCursor(last.CreatedAt, last.Id).Encode()
+	}
+	return rs, nil
+}
+
+func (sv *Service) ListDocumentVersions(ctx context.Context, req *pb.RPListDocumentVersions,
+) (*pb.DocumentVersions, error) {
+	data, count, err := sv.iProject.ListDocumentVersions(&domain.RProjectDocumentVersionList{
+		DocumentId: req.DocumentId,
+		Skip:       int(req.Skip),
+		Limit:      int(req.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DocumentVersions{
+		Total: count,
+		Data:  convertArr(data, convertDocumentVersion),
+	}, nil
+}
+
+func (sv *Service) RestoreDocumentVersion(ctx context.Context, req *pb.RPRestoreDocumentVersion,
+) (*pb.Document, error) {
+	doc, err := sv.iProject.RestoreDocumentVersion(&domain.RProjectDocumentRestore{
+		Audit:      sv.getAudit(ctx),
+		DocumentId: req.DocumentId,
+		Version:    req.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertDocument(doc), nil
+}
+
+func (sv *Service) DeleteProject(ctx context.Context, req *pb.RPDeleteProject,
+) (*pb.Int64, error) {
+	err := sv.iProject.DeleteProject(&domain.RProjectDelete{
+		Audit:     sv.getAudit(ctx),
+		ProjectId: req.ProjectId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Int64{Data: req.ProjectId}, nil
+}
+
+func (sv *Service) RestoreProject(ctx context.Context, req *pb.RPRestoreProject,
+) (*pb.Int64, error) {
+	err := sv.iProject.RestoreProject(&domain.RProjectRestore{
+		Audit:     sv.getAudit(ctx),
+		ProjectId: req.ProjectId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Int64{Data: req.ProjectId}, nil
+}
+
+func (sv *Service) GetProjectHistory(ctx context.Context, req *pb.RPGetProjectHistory,
+) (*pb.ProjectHistories, error) {
+	data, count, err := sv.iProject.GetHistory(&domain.RProjectHistoryList{
+		ProjectId: req.ProjectId,
+		Skip:      int(req.Skip),
+		Limit:     int(req.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ProjectHistories{
+		Total: count,
+		Data:  convertArr(data, convertHistory),
 	}, nil
 }
 
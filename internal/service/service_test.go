@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/go-shared/dmodels"
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/domain/repo"
+	"github.com/Dcarbon/projects/internal/imaging"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTestService(t *testing.T) (*Service, *repo.MemoryImpl) {
+	var iProject = repo.NewMemoryImpl()
+	var sv = &Service{
+		iProject: iProject,
+		iCountry: repo.NewMemoryCountryImpl(),
+		locales:  newLocaleResolver(""),
+		jwtKey:   []byte(testJwtKey),
+	}
+	return sv, iProject
+}
+
+func TestServiceGetById(t *testing.T) {
+	sv, iProject := newTestService(t)
+	prj, err := iProject.Create(&domain.RProjectCreate{
+		Owner:        dmodels.EthAddress("0x5348a62dc343a9fa6881a64b51ea6137968506c5"),
+		Location:     dmodels.NewCoord4326(105.8, 21.0),
+		Specs:        &domain.RProjectUpdateSpecs{},
+		Descs:        []*domain.RProjectUpdateDesc{{Language: "vi", Name: "Name", Desc: "Desc"}},
+		LocationName: "Ha Noi",
+		OwnerId:      "owner",
+	})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+
+	data, err := sv.GetById(context.TODO(), &pb.RPGetById{ProjectId: prj.Id})
+	if err != nil {
+		t.Fatalf("Get project by id fail: %s", err)
+	}
+	if data.Id != prj.Id || data.Address != "Ha Noi" || len(data.Descs) != 1 {
+		t.Errorf("Get project by id return wrong project")
+	}
+
+	if _, err := sv.GetById(context.TODO(), &pb.RPGetById{ProjectId: prj.Id + 1}); err == nil {
+		t.Errorf("Get project by id must fail when id not exist")
+	}
+}
+
+func TestServiceGetList(t *testing.T) {
+	sv, iProject := newTestService(t)
+	for i := 0; i < 3; i++ {
+		if _, err := iProject.Create(&domain.RProjectCreate{
+			Specs:   &domain.RProjectUpdateSpecs{},
+			OwnerId: "owner",
+		}); err != nil {
+			t.Fatalf("Create project fail: %s", err)
+		}
+	}
+
+	data, err := sv.GetList(context.TODO(), &pb.RPGetList{Limit: 2, OwnerId: "owner"})
+	if err != nil {
+		t.Fatalf("Get list fail: %s", err)
+	}
+	if data.Total != 3 || len(data.Data) != 2 {
+		t.Errorf("Get list expect total 3 and 2 items, got %d and %d", data.Total, len(data.Data))
+	}
+
+	if data.NextCursor == "" {
+		t.Errorf("Get list expect a cursor after a full page")
+	}
+	next, err := sv.GetList(context.TODO(), &pb.RPGetList{Limit: 2, OwnerId: "owner", Cursor: data.NextCursor})
+	if err != nil || len(next.Data) != 1 {
+		t.Errorf("Get list expect the last item after the cursor, got %v", err)
+	}
+
+	if _, err := sv.GetList(context.TODO(), &pb.RPGetList{Ids: "1,a"}); err == nil {
+		t.Errorf("Get list must fail with invalid ids")
+	}
+}
+
+func TestServiceGetByIdIncludeDeleted(t *testing.T) {
+	sv, iProject := newTestService(t)
+	prj, err := iProject.Create(&domain.RProjectCreate{Specs: &domain.RProjectUpdateSpecs{}, OwnerId: "owner"})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+	if err := iProject.DeleteProject(&domain.RProjectDelete{ProjectId: prj.Id}); err != nil {
+		t.Fatalf("Delete project fail: %s", err)
+	}
+
+	var req = &pb.RPGetById{ProjectId: prj.Id, IncludeDeleted: true}
+	var forged = withToken(newToken("forged", `{"role":"super-admin"}`))
+	if _, err := sv.GetById(forged, req); err != errAdminOnly {
+		t.Errorf("Forged admin token expect errAdminOnly, got %v", err)
+	}
+	if _, err := sv.GetList(forged, &pb.RPGetList{IncludeDeleted: true}); err != errAdminOnly {
+		t.Errorf("Forged admin token expect errAdminOnly on list, got %v", err)
+	}
+	data, err := sv.GetById(withToken(newToken(testJwtKey, `{"role":"super-admin"}`)), req)
+	if err != nil || data.DeletedAt == 0 {
+		t.Errorf("Admin expect the deleted project, got %v", err)
+	}
+}
+
+func TestServiceGetListSearchCursor(t *testing.T) {
+	sv, iProject := newTestService(t)
+	for i := 0; i < 3; i++ {
+		if _, err := iProject.Create(&domain.RProjectCreate{
+			Specs:   &domain.RProjectUpdateSpecs{},
+			Descs:   []*domain.RProjectUpdateDesc{{Language: "en", Name: "Biogas digester"}},
+			OwnerId: "owner",
+		}); err != nil {
+			t.Fatalf("Create project fail: %s", err)
+		}
+	}
+
+	data, err := sv.GetList(context.TODO(), &pb.RPGetList{Limit: 2, SearchValue: "biogas"})
+	if err != nil || len(data.Data) != 2 {
+		t.Fatalf("Search expect 2 items, got %v", err)
+	}
+	if data.NextCursor != "" {
+		t.Errorf("Search must not return a cursor, it pages with skip")
+	}
+	data, err = sv.GetList(context.TODO(), &pb.RPGetList{Skip: 2, Limit: 2, SearchValue: "biogas"})
+	if err != nil || len(data.Data) != 1 {
+		t.Errorf("Search expect the last item with skip, got %v", err)
+	}
+}
+
+func TestServiceGetByIdAcceptLanguage(t *testing.T) {
+	sv, iProject := newTestService(t)
+	prj, err := iProject.Create(&domain.RProjectCreate{
+		Specs: &domain.RProjectUpdateSpecs{},
+		Descs: []*domain.RProjectUpdateDesc{
+			{Language: "vi", Name: "Tên"},
+			{Language: "en", Name: "Name"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+
+	var ctx = metadata.NewIncomingContext(context.TODO(), metadata.Pairs("accept-language", "vi-VN,vi;q=0.9"))
+	data, err := sv.GetById(ctx, &pb.RPGetById{ProjectId: prj.Id})
+	if err != nil {
+		t.Fatalf("Get project by id fail: %s", err)
+	}
+	if data.Language != "vi" || len(data.Descs) != 1 || data.Descs[0].Name != "Tên" {
+		t.Errorf("Get project by id expect vi desc, got %q", data.Language)
+	}
+
+	data, _ = sv.GetById(ctx, &pb.RPGetById{ProjectId: prj.Id, Lang: "en"})
+	if data.Language != "en" {
+		t.Errorf("Get project by id expect lang over accept-language, got %q", data.Language)
+	}
+}
+
+func TestServiceAddImage(t *testing.T) {
+	sv, iProject := newTestService(t)
+	prj, err := iProject.Create(&domain.RProjectCreate{
+		Specs:   &domain.RProjectUpdateSpecs{},
+		OwnerId: "owner",
+	})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+
+	// Gallery images used to come back as nil, nil.
+	data, err := sv.AddImage(context.TODO(), &pb.RPAddImage{ProjectId: prj.Id, Image: "/a.png"})
+	if err != nil || data.Data != "/a.png" {
+		t.Fatalf("Add gallery image fail: %v %v", data, err)
+	}
+	project, err := sv.GetById(context.TODO(), &pb.RPGetById{ProjectId: prj.Id})
+	if err != nil {
+		t.Fatalf("Get project by id fail: %s", err)
+	}
+	if len(project.Gallery) != 1 || project.Gallery[0].Id == 0 || project.Gallery[0].Position != 1 {
+		t.Errorf("Get project by id expect the gallery, got %+v", project.Gallery)
+	}
+}
+
+func TestServiceListImagesIncludeDeleted(t *testing.T) {
+	sv, iProject := newTestService(t)
+	prj, err := iProject.Create(&domain.RProjectCreate{Specs: &domain.RProjectUpdateSpecs{}, OwnerId: "owner"})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+
+	var req = &pb.RPListImages{ProjectId: prj.Id, IncludeDeleted: true}
+	if _, err := sv.ListImages(withToken(newToken("forged", `{"role":"super-admin"}`)), req); err != errAdminOnly {
+		t.Errorf("Forged admin token expect errAdminOnly, got %v", err)
+	}
+	if _, err := sv.ListImages(withToken(newToken(testJwtKey, `{"role":"super-admin"}`)), req); err != nil {
+		t.Errorf("Admin list images fail: %s", err)
+	}
+}
+
+func TestServiceAddImageVariants(t *testing.T) {
+	sv, iProject := newTestService(t)
+	prj, err := iProject.Create(&domain.RProjectCreate{Specs: &domain.RProjectUpdateSpecs{}, OwnerId: "owner"})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+	other, err := iProject.Create(&domain.RProjectCreate{Specs: &domain.RProjectUpdateSpecs{}, OwnerId: "other"})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+	var own = fmt.Sprintf("projects/%d/images/a.png", prj.Id)
+	var foreign = fmt.Sprintf("projects/%d/images/b.png", other.Id)
+	var storage = &memoryStorage{files: map[string][]byte{
+		own:     newPNG(t, 400, 300),
+		foreign: newPNG(t, 400, 300),
+	}}
+	sv.storage = storage
+	var fetched = 0
+	sv.fetch = func(ctx context.Context, path string) ([]byte, error) {
+		fetched++
+		return storage.files[path], nil
+	}
+
+	if variants := sv.variantsOf(context.TODO(), prj.Id, own); len(variants) != len(imaging.Variants) {
+		t.Errorf("Expect %d variants, got %v", len(imaging.Variants), variants)
+	}
+
+	// Another project's image, a path out of the project folder or an
+	// unknown project must not touch storage.
+	for _, it := range []*pb.RPAddImage{
+		{ProjectId: prj.Id, Image: foreign},
+		{ProjectId: prj.Id, Image: fmt.Sprintf("projects/%d/../%d/images/b.png", prj.Id, other.Id)},
+		{ProjectId: other.Id + 100, Image: foreign},
+	} {
+		var files = len(storage.files)
+		fetched = 0
+		sv.AddImage(context.TODO(), it)
+		if fetched != 0 || len(storage.files) != files {
+			t.Errorf("Add image %s to %d expect no variants, fetched %d", it.Image, it.ProjectId, fetched)
+		}
+	}
+	if _, err := sv.AddImage(context.TODO(), &pb.RPAddImage{ProjectId: other.Id + 100, Image: foreign}); err == nil {
+		t.Errorf("Add image to an unknown project expect an error")
+	}
+
+	// Every build slot is taken: the image is added without variants once
+	// the caller gives up.
+	for i := 0; i < maxVariantBuilds; i++ {
+		variantBuilds <- struct{}{}
+	}
+	var ctx, cancel = context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	fetched = 0
+	data, err := sv.AddImage(ctx, &pb.RPAddImage{ProjectId: prj.Id, Image: own})
+	for i := 0; i < maxVariantBuilds; i++ {
+		<-variantBuilds
+	}
+	if err != nil || data.Data != own || fetched != 0 {
+		t.Errorf("Add image with busy builds expect no fetch, got %v %v fetched %d", data, err, fetched)
+	}
+}
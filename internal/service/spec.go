@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/projects/internal/domain"
+)
+
+func (sv *Service) GetSpecSchema(ctx context.Context, req *pb.RPGetSpecSchema,
+) (*pb.SpecSchema, error) {
+	schema, err := domain.GetSpecSchema(int64(req.Type))
+	if nil != err {
+		return nil, err
+	}
+	return convertSpecSchema(schema, sv.getLocales(ctx, req.Lang)), nil
+}
+
+// GetSpecsAt returns the specs in effect at req.At, in milliseconds since
+// epoch. 0 means now.
+func (sv *Service) GetSpecsAt(ctx context.Context, req *pb.RPGetSpecsAt,
+) (*pb.ProjectSpecsVersion, error) {
+	version, err := sv.iProject.GetSpecsAt(&domain.RProjectSpecsAt{
+		ProjectId: req.ProjectId,
+		At:        fromMillis(req.At),
+	})
+	if nil != err {
+		return nil, err
+	}
+	return convertSpecsVersion(version), nil
+}
+
+func (sv *Service) GetSpecsHistory(ctx context.Context, req *pb.RPGetSpecsHistory,
+) (*pb.ProjectSpecsVersions, error) {
+	data, count, err := sv.iProject.GetSpecsHistory(&domain.RProjectSpecsHistory{
+		ProjectId: req.ProjectId,
+		From:      fromMillis(req.From),
+		To:        fromMillis(req.To),
+		Skip:      int(req.Skip),
+		Limit:     int(req.Limit),
+	})
+	if nil != err {
+		return nil, err
+	}
+	return &pb.ProjectSpecsVersions{
+		Total: count,
+		Data:  convertArr(data, convertSpecsVersion),
+	}, nil
+}
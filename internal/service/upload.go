@@ -0,0 +1,310 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/imaging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	EnvUploadMaxImageBytes    = "PROJECTS_UPLOAD_MAX_IMAGE_BYTES"
+	EnvUploadMaxDocumentBytes = "PROJECTS_UPLOAD_MAX_DOCUMENT_BYTES"
+
+	defaultMaxImageBytes    = 10 << 20
+	defaultMaxDocumentBytes = 50 << 20
+)
+
+var (
+	errUploadEmpty     = status.Error(codes.InvalidArgument, "upload is empty")
+	errUploadProject   = status.Error(codes.InvalidArgument, "the first upload message must carry the project id")
+	errUploadTooLarge  = status.Error(codes.InvalidArgument, "upload exceeds the size limit")
+	errUploadMediaType = status.Error(codes.InvalidArgument, "upload content type is not allowed")
+)
+
+// fileStorage is the part of sclient.IStorage used by the uploads.
+type fileStorage interface {
+	Upload(path string, r io.Reader) (string, error)
+	Delete(path string) error
+}
+
+// uploadPolicy limits what a client may upload. Types maps the sniffed
+// content type to the extension of the stored file.
+type uploadPolicy struct {
+	Folder   string
+	MaxBytes int64
+	Types    map[string]string
+}
+
+func imagePolicy() *uploadPolicy {
+	return &uploadPolicy{
+		Folder:   "images",
+		MaxBytes: int64(utils.IntEnv(EnvUploadMaxImageBytes, defaultMaxImageBytes)),
+		Types: map[string]string{
+			"image/jpeg": ".jpg",
+			"image/png":  ".png",
+			"image/webp": ".webp",
+		},
+	}
+}
+
+func documentPolicy() *uploadPolicy {
+	return &uploadPolicy{
+		Folder:   "documents",
+		MaxBytes: int64(utils.IntEnv(EnvUploadMaxDocumentBytes, defaultMaxDocumentBytes)),
+		Types: map[string]string{
+			"application/pdf": ".pdf",
+			"image/jpeg":      ".jpg",
+			"image/png":       ".png",
+		},
+	}
+}
+
+// uploadedFile describes a file written to storage.
+type uploadedFile struct {
+	Path        string
+	Checksum    string // Hex SHA-256
+	Size        int64
+	ContentType string
+}
+
+// UploadImage stores an image without its metadata together with its
+// variants, then adds it to the project. The first message carries the
+// project id and type, every message may carry data.
+func (sv *Service) UploadImage(stream pb.ProjectService_UploadImageServer) error {
+	req, err := stream.Recv()
+	if nil != err {
+		return recvError(err)
+	}
+	if req.ProjectId == 0 {
+		return errUploadProject
+	}
+	if _, err := sv.iProject.GetById(&domain.RProjectGetById{Id: req.ProjectId}); nil != err {
+		return err
+	}
+
+	var body = &uploadStream{buf: req.Data, recv: func() ([]byte, error) {
+		msg, err := stream.Recv()
+		if nil != err {
+			return nil, err
+		}
+		return msg.Data, nil
+	}}
+	var policy = imagePolicy()
+	data, err := readUpload(policy, body)
+	if nil != err {
+		return err
+	}
+	img, err := imaging.Decode(data)
+	if err == imaging.ErrTooLarge {
+		return errImageTooLarge
+	}
+	if nil != err {
+		return errUploadImage
+	}
+	file, err := sv.upload(policy, req.ProjectId, bytes.NewReader(imaging.StripMetadata(data)))
+	if nil != err {
+		return err
+	}
+	variants, err := sv.storeVariants(file.Path, img)
+	if nil != err {
+		sv.removeFiles(file.Path)
+		return err
+	}
+
+	image, err := sv.iProject.AddImage(&domain.RProjectAddImage{
+		Audit:     sv.getAudit(stream.Context()),
+		ProjectId: req.ProjectId,
+		ImgPath:   file.Path,
+		Type:      req.Type,
+		Checksum:  file.Checksum,
+		Size:      file.Size,
+		Variants:  variants,
+	})
+	if nil != err {
+		sv.removeFiles(append(variantPaths(variants), file.Path)...)
+		return err
+	}
+	return stream.SendAndClose(convertUpload(file, image.Id))
+}
+
+// UploadDocument streams a document into storage, then adds it to the
+// project. The first message carries the project id, type and name.
+func (sv *Service) UploadDocument(stream pb.ProjectService_UploadDocumentServer) error {
+	req, err := stream.Recv()
+	if nil != err {
+		return recvError(err)
+	}
+	if req.ProjectId == 0 {
+		return errUploadProject
+	}
+	if _, err := sv.iProject.GetById(&domain.RProjectGetById{Id: req.ProjectId}); nil != err {
+		return err
+	}
+
+	var body = &uploadStream{buf: req.Data, recv: func() ([]byte, error) {
+		msg, err := stream.Recv()
+		if nil != err {
+			return nil, err
+		}
+		return msg.Data, nil
+	}}
+	file, err := sv.upload(documentPolicy(), req.ProjectId, body)
+	if nil != err {
+		return err
+	}
+
+	data, err := sv.iProject.UpsertDocument(&domain.RProjectDocumentUpsert{
+		Audit: sv.getAudit(stream.Context()),
+		Document: []*domain.Document{{
+			Url:          file.Path,
+			DocumentName: req.DocumentName,
+			DocumentType: req.DocumentType,
+			ProjectId:    req.ProjectId,
+			Checksum:     file.Checksum,
+			Size:         file.Size,
+		}},
+	})
+	if nil != err {
+		sv.removeFiles(file.Path)
+		return err
+	}
+	return stream.SendAndClose(convertUpload(file, data[0].Id))
+}
+
+// upload sniffs the content type from the first bytes of body, then
+// streams body into storage while enforcing policy.MaxBytes and hashing.
+func (sv *Service) upload(policy *uploadPolicy, projectId int64, body io.Reader,
+) (*uploadedFile, error) {
+	var head = make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if nil != err && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, recvError(err)
+	}
+	if n == 0 {
+		return nil, errUploadEmpty
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	ext, ok := policy.Types[contentType]
+	if !ok {
+		return nil, errUploadMediaType
+	}
+
+	var file = &uploadedFile{
+		Path:        fmt.Sprintf("projects/%d/%s/%d%s", projectId, policy.Folder, time.Now().UnixNano(), ext),
+		ContentType: contentType,
+	}
+	var reader = &limitHashReader{
+		r:    io.MultiReader(bytes.NewReader(head[:n]), body),
+		hash: sha256.New(),
+		max:  policy.MaxBytes,
+	}
+	path, err := sv.storage.Upload(file.Path, reader)
+	if nil != reader.err {
+		// The storage client may wrap or drop the error of the reader.
+		sv.removeFiles(file.Path)
+		return nil, reader.err
+	}
+	if nil != err {
+		sv.removeFiles(file.Path)
+		return nil, status.Error(codes.Unavailable, "upload to storage: "+err.Error())
+	}
+	if path != "" {
+		file.Path = path
+	}
+	file.Size = reader.size
+	file.Checksum = hex.EncodeToString(reader.hash.Sum(nil))
+	return file, nil
+}
+
+// removeFiles deletes uploaded files whose project update failed. It is
+// best effort, an orphan file is harmless.
+func (sv *Service) removeFiles(paths ...string) {
+	for _, it := range paths {
+		if err := sv.storage.Delete(it); nil != err {
+			log.Printf("Remove upload %s: %s", it, err)
+		}
+	}
+}
+
+func convertUpload(file *uploadedFile, id int64) *pb.UploadResult {
+	return &pb.UploadResult{
+		Id:          id,
+		Path:        file.Path,
+		Url:         utils.StringEnv("STORAGE_URL", "") + file.Path,
+		Checksum:    file.Checksum,
+		Size:        file.Size,
+		ContentType: file.ContentType,
+	}
+}
+
+// recvError maps the error of the first Recv. A stream closed without any
+// message is an empty upload.
+func recvError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return errUploadEmpty
+	}
+	return err
+}
+
+// uploadStream reads the data of a client-streaming upload. buf holds the
+// data of the first message.
+type uploadStream struct {
+	recv func() ([]byte, error)
+	buf  []byte
+}
+
+func (r *uploadStream) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		data, err := r.recv()
+		if nil != err {
+			return 0, err
+		}
+		r.buf = data
+	}
+	var n = copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// limitHashReader hashes what it reads and fails once more than max bytes
+// went through. The failure is kept in err.
+type limitHashReader struct {
+	r    io.Reader
+	hash hash.Hash
+	size int64
+	max  int64
+	err  error
+}
+
+func (r *limitHashReader) Read(p []byte) (int, error) {
+	if nil != r.err {
+		return 0, r.err
+	}
+	n, err := r.r.Read(p)
+	r.size += int64(n)
+	if r.size > r.max {
+		r.err = errUploadTooLarge
+		return 0, r.err
+	}
+	r.hash.Write(p[:n])
+	if nil != err && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
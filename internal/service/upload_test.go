@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/Dcarbon/arch-proto/pb"
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/imaging"
+	"google.golang.org/grpc"
+)
+
+type memoryStorage struct {
+	files map[string][]byte
+}
+
+func (s *memoryStorage) Upload(path string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if nil != err {
+		return "", err
+	}
+	s.files[path] = data
+	return path, nil
+}
+
+func (s *memoryStorage) Delete(path string) error {
+	delete(s.files, path)
+	return nil
+}
+
+type imageUploadStream struct {
+	grpc.ServerStream
+	msgs   []*pb.RPUploadImage
+	result *pb.UploadResult
+}
+
+func (s *imageUploadStream) Context() context.Context { return context.TODO() }
+
+func (s *imageUploadStream) Recv() (*pb.RPUploadImage, error) {
+	if len(s.msgs) == 0 {
+		return nil, io.EOF
+	}
+	var msg = s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+func (s *imageUploadStream) SendAndClose(rs *pb.UploadResult) error {
+	s.result = rs
+	return nil
+}
+
+// newPNG encodes a w x h PNG of noise, so it does not compress well.
+func newPNG(t *testing.T, w, h int) []byte {
+	var img = image.NewRGBA(image.Rect(0, 0, w, h))
+	var rnd = rand.New(rand.NewSource(1))
+	rnd.Read(img.Pix)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); nil != err {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestServiceUploadImage(t *testing.T) {
+	sv, iProject := newTestService(t)
+	var storage = &memoryStorage{files: map[string][]byte{}}
+	sv.storage = storage
+	prj, err := iProject.Create(&domain.RProjectCreate{
+		Specs:   &domain.RProjectUpdateSpecs{},
+		OwnerId: "owner",
+	})
+	if err != nil {
+		t.Fatalf("Create project fail: %s", err)
+	}
+
+	var data = newPNG(t, 400, 300)
+	var stream = &imageUploadStream{msgs: []*pb.RPUploadImage{
+		{ProjectId: prj.Id, Data: data[:100]},
+		{Data: data[100:1500]},
+		{Data: data[1500:]},
+	}}
+	if err := sv.UploadImage(stream); nil != err {
+		t.Fatalf("Upload image fail: %s", err)
+	}
+	var sum = sha256.Sum256(data)
+	if stream.result.Checksum != hex.EncodeToString(sum[:]) || stream.result.Size != int64(len(data)) ||
+		stream.result.ContentType != "image/png" || stream.result.Id == 0 {
+		t.Errorf("Upload image result is wrong: %+v", stream.result)
+	}
+	if !bytes.Equal(storage.files[stream.result.Path], data) {
+		t.Errorf("Upload image expect the bytes in storage")
+	}
+	images, _ := iProject.ListImages(&domain.RProjectImageList{ProjectId: prj.Id})
+	if len(images) != 1 || images[0].Checksum != stream.result.Checksum {
+		t.Fatalf("Upload image expect the image attached with its checksum")
+	}
+	for _, it := range imaging.Variants {
+		img, _, err := image.Decode(bytes.NewReader(storage.files[images[0].Variants[it.Name]]))
+		if nil != err {
+			t.Errorf("Upload image expect the %s variant in storage: %v", it.Name, err)
+			continue
+		}
+		if b := img.Bounds(); b.Dx() > it.MaxSide || b.Dy() > it.MaxSide {
+			t.Errorf("Variant %s expect at most %d px, got %v", it.Name, it.MaxSide, b)
+		}
+	}
+	var files = len(storage.files)
+
+	var maxPixels = imaging.MaxPixels
+	imaging.MaxPixels = 400*300 - 1
+	err = sv.UploadImage(&imageUploadStream{msgs: []*pb.RPUploadImage{{ProjectId: prj.Id, Data: data}}})
+	imaging.MaxPixels = maxPixels
+	if err != errImageTooLarge {
+		t.Errorf("Upload image over the pixel limit expect errImageTooLarge, got %v", err)
+	}
+
+	t.Setenv(EnvUploadMaxImageBytes, "1000")
+	var cases = map[string]*imageUploadStream{
+		"too large":       {msgs: []*pb.RPUploadImage{{ProjectId: prj.Id, Data: data}}},
+		"not an image":    {msgs: []*pb.RPUploadImage{{ProjectId: prj.Id, Data: []byte("%PDF-1.4 hello")}}},
+		"empty":           {msgs: []*pb.RPUploadImage{{ProjectId: prj.Id}}},
+		"missing id":      {msgs: []*pb.RPUploadImage{{Data: data[:10]}}},
+		"unknown project": {msgs: []*pb.RPUploadImage{{ProjectId: prj.Id + 100, Data: data[:10]}}},
+	}
+	for name, stream := range cases {
+		if err := sv.UploadImage(stream); nil == err {
+			t.Errorf("Upload image %s expect an error", name)
+		}
+	}
+	if len(storage.files) != files {
+		t.Errorf("Failed uploads expect no file left in storage, got %d files", len(storage.files))
+	}
+}
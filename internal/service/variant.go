@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Dcarbon/go-shared/libs/utils"
+	"github.com/Dcarbon/projects/internal/domain"
+	"github.com/Dcarbon/projects/internal/imaging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errUploadImage   = status.Error(codes.InvalidArgument, "image can not be decoded")
+	errImageTooLarge = status.Error(codes.InvalidArgument, "image has too many pixels")
+)
+
+var storageClient = &http.Client{Timeout: 30 * time.Second}
+
+const (
+	// variantTimeout bounds the fetch of an image added by path, AddImage
+	// waits for its variants.
+	variantTimeout = 10 * time.Second
+
+	// maxVariantBuilds is the number of AddImage calls building variants
+	// at once. Each holds a decoded image in memory.
+	maxVariantBuilds = 2
+)
+
+var variantBuilds = make(chan struct{}, maxVariantBuilds)
+
+// readUpload reads a whole upload. Images are decoded in memory to build
+// their variants anyway.
+func readUpload(policy *uploadPolicy, body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, policy.MaxBytes+1))
+	if nil != err {
+		return nil, recvError(err)
+	}
+	if int64(len(data)) > policy.MaxBytes {
+		return nil, errUploadTooLarge
+	}
+	return data, nil
+}
+
+// storeVariants uploads a JPEG per imaging.Variant next to the original.
+// Nothing is left in storage on failure.
+func (sv *Service) storeVariants(original string, img image.Image,
+) (domain.ImageVariants, error) {
+	var base = strings.TrimSuffix(original, path.Ext(original))
+	var variants = domain.ImageVariants{}
+	for _, it := range imaging.Variants {
+		data, err := imaging.EncodeJPEG(imaging.Resize(img, it.MaxSide))
+		if nil != err {
+			sv.removeFiles(variantPaths(variants)...)
+			return nil, status.Error(codes.Internal, "encode image variant: "+err.Error())
+		}
+		var name = base + "_" + it.Name + ".jpg"
+		stored, err := sv.storage.Upload(name, bytes.NewReader(data))
+		if nil != err {
+			sv.removeFiles(variantPaths(variants)...)
+			return nil, status.Error(codes.Unavailable, "upload to storage: "+err.Error())
+		}
+		if stored == "" {
+			stored = name
+		}
+		variants[it.Name] = stored
+	}
+	return variants, nil
+}
+
+// variantsOf builds the variants of an image the client uploaded itself.
+// It is best effort, the image stays at original size when it is not in
+// the folder of projectId, can not be fetched or decoded in variantTimeout,
+// or when maxVariantBuilds are already running. The variants are
+// re-encoded without metadata, but the original is left as the client
+// stored it, unlike UploadImage.
+func (sv *Service) variantsOf(ctx context.Context, projectId int64, original string,
+) domain.ImageVariants {
+	if nil == sv.fetch || !inProjectFolder(projectId, original) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, variantTimeout)
+	defer cancel()
+	select {
+	case variantBuilds <- struct{}{}:
+		defer func() { <-variantBuilds }()
+	case <-ctx.Done():
+		log.Printf("Variants of %s: %s", original, ctx.Err())
+		return nil
+	}
+
+	data, err := sv.fetch(ctx, original)
+	if nil != err {
+		log.Printf("Fetch image %s: %s", original, err)
+		return nil
+	}
+	img, err := imaging.Decode(data)
+	if nil != err {
+		log.Printf("Decode image %s: %s", original, err)
+		return nil
+	}
+	variants, err := sv.storeVariants(original, img)
+	if nil != err {
+		log.Printf("Store variants of %s: %s", original, err)
+		return nil
+	}
+	return variants
+}
+
+// inProjectFolder tells whether name is a file under the storage folder of
+// projectId, where UploadImage stores it. The variants are stored next to
+// the original, so they are never built for a file of another project.
+func inProjectFolder(projectId int64, name string) bool {
+	var rel = strings.TrimPrefix(name, "/")
+	return path.Clean(rel) == rel && strings.HasPrefix(rel, fmt.Sprintf("projects/%d/", projectId))
+}
+
+// fetchStorage downloads a stored file through STORAGE_URL.
+func fetchStorage(ctx context.Context, name string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, utils.StringEnv("STORAGE_URL", "")+name, nil)
+	if nil != err {
+		return nil, err
+	}
+	resp, err := storageClient.Do(req)
+	if nil != err {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("storage responded %s", resp.Status)
+	}
+	return readUpload(imagePolicy(), resp.Body)
+}
+
+func variantPaths(variants domain.ImageVariants) []string {
+	var paths = make([]string, 0, len(variants))
+	for _, it := range variants {
+		paths = append(paths, it)
+	}
+	return paths
+}
@@ -0,0 +1,141 @@
+// Package xlsx writes a single-sheet workbook row by row. Rows go straight
+// to the zip stream, so the sheet is never held in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
+
+const workbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const sheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const sheetEnd = `</sheetData></worksheet>`
+
+// Writer writes the rows of the only sheet of a workbook.
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+// NewWriter starts a workbook on w with one sheet named sheet, which must
+// be a valid sheet name: at most 31 characters, none of []:*?/\.
+func NewWriter(w io.Writer, sheet string) (*Writer, error) {
+	var rs = &Writer{zip: zip.NewWriter(w)}
+	var name = &strings.Builder{}
+	xml.EscapeText(name, []byte(sheet))
+	var parts = []struct{ path, content string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, name)},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+	}
+	for _, it := range parts {
+		part, err := rs.zip.Create(it.path)
+		if nil != err {
+			return nil, err
+		}
+		if _, err := io.WriteString(part, it.content); nil != err {
+			return nil, err
+		}
+	}
+
+	part, err := rs.zip.Create("xl/worksheets/sheet1.xml")
+	if nil != err {
+		return nil, err
+	}
+	rs.sheet = bufio.NewWriter(part)
+	if _, err := rs.sheet.WriteString(sheetStart); nil != err {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// WriteRow adds a row. Numbers become numeric cells, times are written in
+// RFC 3339, nil leaves the cell empty and anything else is written as text.
+func (w *Writer) WriteRow(cells []interface{}) error {
+	w.rows++
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.rows)
+	for i, it := range cells {
+		var ref = ColumnName(i) + strconv.Itoa(w.rows)
+		switch v := it.(type) {
+		case nil:
+			continue
+		case int:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int32:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int64:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float32:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(float64(v), 'g', -1, 32))
+		case float64:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+		case time.Time:
+			w.writeText(ref, v.Format(time.RFC3339))
+		case string:
+			w.writeText(ref, v)
+		default:
+			w.writeText(ref, fmt.Sprint(v))
+		}
+	}
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+func (w *Writer) writeText(ref, text string) {
+	fmt.Fprintf(w.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+	xml.EscapeText(w.sheet, []byte(text))
+	w.sheet.WriteString("</t></is></c>")
+}
+
+// Close ends the sheet and the workbook. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetEnd); nil != err {
+		return err
+	}
+	if err := w.sheet.Flush(); nil != err {
+		return err
+	}
+	return w.zip.Close()
+}
+
+// ColumnName returns the letters of the column i, from 0: A, B, ... Z, AA.
+func ColumnName(i int) string {
+	var rs = ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		rs = string(rune('A'+(i-1)%26)) + rs
+	}
+	return rs
+}
@@ -0,0 +1,69 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	var cases = map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for i, expect := range cases {
+		if rs := ColumnName(i); rs != expect {
+			t.Errorf("ColumnName(%d) expect %s got %s", i, expect, rs)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	w, err := NewWriter(buf, "Projects")
+	if err != nil {
+		t.Fatalf("New writer fail: %s", err)
+	}
+	if err := w.WriteRow([]interface{}{"id", "name"}); err != nil {
+		t.Fatalf("Write row fail: %s", err)
+	}
+	if err := w.WriteRow([]interface{}{int64(7), "<Hầm & biogas>", nil, 1.5}); err != nil {
+		t.Fatalf("Write row fail: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close fail: %s", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Output is not a zip: %s", err)
+	}
+	var files = map[string]string{}
+	for _, it := range reader.File {
+		rc, err := it.Open()
+		if err != nil {
+			t.Fatalf("Open %s fail: %s", it.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[it.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expect part %s", name)
+		}
+	}
+	var sheet = files["xl/worksheets/sheet1.xml"]
+	for _, expect := range []string{
+		`<c r="A2"><v>7</v></c>`,
+		`&lt;Hầm &amp; biogas&gt;`,
+		`<c r="D2"><v>1.5</v></c>`,
+		`</sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, expect) {
+			t.Errorf("Expect %s in sheet", expect)
+		}
+	}
+	if strings.Contains(sheet, `r="C2"`) {
+		t.Errorf("Nil cell must be left empty")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
 
 	"github.com/Dcarbon/arch-proto/pb"
 	"github.com/Dcarbon/go-shared/gutils"
@@ -39,10 +40,45 @@ var config = gutils.Config{
 			PermDesc:   "Update project specification",
 		},
 		"/pb.ProjectService/AddImage": {
+			Require:    true,
+			Permission: "project-info-add-image",
+			PermDesc:   "Add image to project",
+		},
+		"/pb.ProjectService/ListImages": {
 			Require:    false,
+			Permission: "project-info-list-image",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/DeleteImage": {
+			Require:    true,
+			Permission: "project-info-update-image",
+			PermDesc:   "Delete, reorder and caption project images",
+		},
+		"/pb.ProjectService/ReorderImages": {
+			Require:    true,
+			Permission: "project-info-update-image",
+			PermDesc:   "Delete, reorder and caption project images",
+		},
+		"/pb.ProjectService/UpdateImageCaption": {
+			Require:    true,
+			Permission: "project-info-update-image",
+			PermDesc:   "Delete, reorder and caption project images",
+		},
+		"/pb.ProjectService/SetThumbnail": {
+			Require:    true,
+			Permission: "project-info-update-image",
+			PermDesc:   "Delete, reorder and caption project images",
+		},
+		"/pb.ProjectService/UploadImage": {
+			Require:    true,
 			Permission: "project-info-add-image",
 			PermDesc:   "Add image to project",
 		},
+		"/pb.ProjectService/UploadDocument": {
+			Require:    true,
+			Permission: "upsert-document",
+			PermDesc:   "",
+		},
 		"/pb.ProjectService/GetById": {
 			Require:    false,
 			Permission: "project-info-get-by-id",
@@ -53,16 +89,41 @@ var config = gutils.Config{
 			Permission: "project-info-get-list",
 			PermDesc:   "",
 		},
+		"/pb.ProjectService/GetNearby": {
+			Require:    false,
+			Permission: "project-info-get-nearby",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/ExportGeoJSON": {
+			Require:    false,
+			Permission: "project-info-export-geojson",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/ExportProjects": {
+			Require:    true,
+			Permission: "project-export",
+			PermDesc:   "Export projects to csv, xlsx or jsonl",
+		},
 		"/pb.ProjectService/Update": {
 			Require:    true,
 			Permission: "project-info-update",
 			PermDesc:   "",
 		},
+		"/pb.ProjectService/ImportProjects": {
+			Require:    true,
+			Permission: "project-import",
+			PermDesc:   "Create projects from a csv or json file",
+		},
 		"/pb.ProjectService/ChangeStatus": {
 			Require:    true,
 			Permission: "project-info-update",
 			PermDesc:   "",
 		},
+		"/pb.ProjectService/ResubmitProject": {
+			Require:    true,
+			Permission: "project-resubmit",
+			PermDesc:   "Resubmit an own rejected project for review",
+		},
 		"/pb.ProjectService/UpsertDocument": {
 			Require:    true,
 			Permission: "upsert-document",
@@ -73,15 +134,95 @@ var config = gutils.Config{
 			Permission: "delete-document",
 			PermDesc:   "",
 		},
+		"/pb.ProjectService/ListDocumentVersions": {
+			Require:    true,
+			Permission: "list-document-version",
+			PermDesc:   "List the versions of a project document",
+		},
+		"/pb.ProjectService/RestoreDocumentVersion": {
+			Require:    true,
+			Permission: "upsert-document",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/ListDocumentTypes": {
+			Require:    false,
+			Permission: "document-type-list",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/GetDocumentCompleteness": {
+			Require:    true,
+			Permission: "document-completeness-get",
+			PermDesc:   "",
+		},
 		"/pb.ProjectService/ListDocument": {
 			Require:    true,
-			Permission: "delete-document",
+			Permission: "list-document",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/DeleteProject": {
+			Require:    true,
+			Permission: "project-info-delete",
+			PermDesc:   "Delete project",
+		},
+		"/pb.ProjectService/RestoreProject": {
+			Require:    true,
+			Permission: "project-info-delete",
+			PermDesc:   "Restore deleted project",
+		},
+		"/pb.ProjectService/GetProjectHistory": {
+			Require:    true,
+			Permission: "project-info-get-history",
+			PermDesc:   "Get project change history",
+		},
+		"/pb.ProjectService/GetSpecsAt": {
+			Require:    false,
+			Permission: "project-info-get-specs",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/GetSpecsHistory": {
+			Require:    false,
+			Permission: "project-info-get-specs",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/GetSpecSchema": {
+			Require:    false,
+			Permission: "project-spec-schema-get",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/ListCountries": {
+			Require:    false,
+			Permission: "country-get",
+			PermDesc:   "",
+		},
+		"/pb.ProjectService/GetCountry": {
+			Require:    false,
+			Permission: "country-get",
 			PermDesc:   "",
 		},
+		"/pb.ProjectService/UpsertCountryLocale": {
+			Require:    true,
+			Permission: "country-locale-update",
+			PermDesc:   "Add or rename a country locale",
+		},
+		"/pb.ProjectService/DeleteCountryLocale": {
+			Require:    true,
+			Permission: "country-locale-update",
+			PermDesc:   "Delete a country locale",
+		},
 	},
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); nil != err {
+			log.Fatal(config.Name+" "+os.Args[1]+": ", err)
+		}
+		return
+	}
+	serve()
+}
+
+func serve() {
 	listen, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Port))
 	utils.PanicError(config.Name+" open port", err)
 
@@ -102,6 +243,11 @@ func main() {
 			logger.Intercept,
 			auth.Intercept,
 		),
+		grpc.ChainStreamInterceptor(
+			service.StreamInterceptor(gutils.UnaryPreventPanic),
+			service.StreamInterceptor(logger.Intercept),
+			service.StreamInterceptor(auth.Intercept),
+		),
 	)
 	pb.RegisterProjectServiceServer(sv, handler)
 	log.Println(config.Name+" listen and serve at ", config.Port)